@@ -1,7 +1,6 @@
 package main
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -9,6 +8,18 @@ import (
 	"os"
 	"strings"
 	"time"
+	_ "time/tzdata" // timezone user tetap valid di image tanpa zoneinfo
+
+	"kotoba-backend/internal/audit"
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/handlers"
+	"kotoba-backend/internal/jobs"
+	"kotoba-backend/internal/middleware"
+	"kotoba-backend/internal/mlclient"
+	"kotoba-backend/internal/models"
+	"kotoba-backend/internal/search"
+	"kotoba-backend/internal/streak"
+	"kotoba-backend/internal/tagging"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
@@ -22,15 +33,6 @@ import (
 var (
 	db        *gorm.DB
 	jwtSecret = []byte(os.Getenv("JWT_SECRET"))
-
-	//URL Service Python
-	mlServiceURL = "http://ml_service:5000/predict_retention"
-	mlChatURL    = "http://ml_service:5000/chat" // Endpoint Chat Python
-
-	//HTTP Client dengan Timeout
-	httpClient = &http.Client{
-		Timeout: 30 * time.Second,
-	}
 )
 
 // --- DATABASE MODELS ---
@@ -42,9 +44,18 @@ type User struct {
 	Password  string         `gorm:"not null" json:"-"`
 	Role      string         `gorm:"default:'user'" json:"role"`
 	Avatar    string         `json:"avatar" gorm:"default:'default'"`
+	Timezone  string         `json:"timezone"` // IANA, kosong = USER_DEFAULT_TIMEZONE
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	TokenVersion int `gorm:"default:0" json:"-"`
+
+	LockedAt          *time.Time `json:"locked_at,omitempty"`
+	MustResetPassword bool       `gorm:"default:false" json:"must_reset_password"`
+
+	// Diisi kalau email sudah diverifikasi (mis. oleh IdP OIDC); dikosongkan saat email diganti
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
 }
 
 type Vocabulary struct {
@@ -55,6 +66,8 @@ type Vocabulary struct {
 	Meaning         string         `gorm:"not null" json:"meaning"`
 	ExampleSentence string         `json:"example_sentence"`
 	DifficultyLevel int            `gorm:"default:1" json:"difficulty_level"`
+	OwnerID         *uint          `gorm:"index" json:"owner_id,omitempty"` // kartu privat deck user
+	TagsSeededAt    *time.Time     `json:"-"`                               // lihat models.Vocabulary
 	CreatedAt       time.Time      `json:"created_at"`
 	UpdatedAt       time.Time      `json:"updated_at"`
 	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
@@ -81,39 +94,22 @@ type UserStatsDTO struct {
 	LupaCount    int `json:"lupa_count"`
 }
 
-type MLResponse struct {
-	RetentionRate   float64   `json:"retention_rate"`
-	Status          string    `json:"status"`
-	DecayRisk       string    `json:"decay_risk"`
-	NextReviewHours float64   `json:"next_review_hours"`
-	GraphData       []float64 `json:"graph_data"`
-}
-
-// Chat DTOs
-type ChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
-}
-
-type ChatRequest struct {
-	Message string        `json:"message"`
-	History []ChatMessage `json:"history"`
-}
-
-type ChatResponse struct {
-	Reply string `json:"reply"`
-}
-
 // --- DATABASE INITIALIZATION ---
 
 func initDB() {
+	// Timezone sesi DB saja; batas hari belajar dihitung per user (lihat internal/streak)
+	dbTimezone := os.Getenv("DB_TIMEZONE")
+	if dbTimezone == "" {
+		dbTimezone = "UTC"
+	}
 	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=Asia/Jakarta",
+		"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=%s",
 		os.Getenv("DB_HOST"),
 		os.Getenv("DB_USER"),
 		os.Getenv("DB_PASSWORD"),
 		os.Getenv("DB_NAME"),
 		os.Getenv("DB_PORT"),
+		dbTimezone,
 	)
 
 	var err error
@@ -133,8 +129,65 @@ func initDB() {
 		log.Fatalf("[FATAL] DB Connection failed: %v", err)
 	}
 
+	// Handler di internal/ pakai koneksi yang sama
+	database.DB = db
+
 	db.AutoMigrate(&User{}, &ReviewLog{}, &Vocabulary{})
+	db.AutoMigrate(&models.ExamResult{}, &models.ExamSession{}, &models.ExportJob{})
+	db.AutoMigrate(&models.UserIdentity{}, &models.OIDCLoginState{}, &models.OIDCPendingLink{})
+	db.AutoMigrate(&models.AuditLog{})
+	db.AutoMigrate(&models.Conversation{}, &models.ChatMessage{}, &models.SenseiQuiz{})
+	db.AutoMigrate(&models.StudyQueueItem{})
+	db.AutoMigrate(&models.RoleplaySession{}, &models.RoleplayReport{})
+	db.AutoMigrate(&models.LLMUsage{}, &models.LLMBudgetCounter{}, &models.ChatGuardEvent{})
+	db.AutoMigrate(&models.UserPrediction{}, &models.UserPredictionHistory{})
+	db.AutoMigrate(&models.XPEvent{}, &models.KanaDrillResult{})
+	db.AutoMigrate(&models.UserStreak{}, &models.StudyDay{})
+	db.AutoMigrate(&models.UserAchievement{}, &models.AchievementBackfillJob{}, &models.KanaProgress{})
+	db.AutoMigrate(&models.PrivacySettings{}, &models.LeaderboardEntry{}, &models.UserLeague{}, &models.LeagueMembership{})
+	db.AutoMigrate(&models.Friendship{}, &models.Follow{}, &models.Block{}, &models.ActivityEvent{})
+	db.AutoMigrate(&models.Classroom{}, &models.ClassroomMember{}, &models.ClassroomAssignment{})
+	db.AutoMigrate(&models.Deck{}, &models.DeckCard{}, &models.DeckSubscription{}, &models.DeckChange{})
+	db.AutoMigrate(&models.Tag{}, &models.VocabularyTag{})
+	db.AutoMigrate(&models.UserVocabState{}, &models.DataMigration{})
+	if err := audit.Migrate(db); err != nil {
+		log.Printf("[WARN] Audit trigger setup failed: %v", err)
+	}
+	if err := search.Migrate(db); err != nil {
+		log.Printf("[WARN] Search index setup failed: %v", err)
+	}
+	if err := handlers.MigrateSocial(); err != nil {
+		log.Printf("[WARN] Friendship pair index setup failed: %v", err)
+	}
+	if err := handlers.MigrateSenseiQuizzes(); err != nil {
+		log.Printf("[WARN] Pending quiz index setup failed: %v", err)
+	}
 	log.Println("[INFO] Migration completed")
+
+	if err := jobs.SeedVocabularyTags(); err != nil {
+		log.Printf("[WARN] Vocabulary tag seeding failed: %v", err)
+	}
+	if err := jobs.BackfillVocabStates(); err != nil {
+		log.Printf("[WARN] Vocabulary state backfill failed: %v", err)
+	}
+
+	bootstrapAdmin()
+}
+
+// Promote akun BOOTSTRAP_ADMIN_EMAIL jadi admin (untuk admin pertama)
+func bootstrapAdmin() {
+	email := os.Getenv("BOOTSTRAP_ADMIN_EMAIL")
+	if email == "" {
+		return
+	}
+
+	result := db.Model(&User{}).Where("LOWER(email) = LOWER(?) AND role <> ?", email, middleware.RoleAdmin).
+		Update("role", middleware.RoleAdmin)
+	if result.Error != nil {
+		log.Printf("[ERROR] Bootstrap admin failed: %v", result.Error)
+	} else if result.RowsAffected > 0 {
+		log.Printf("[INFO] %s promoted to admin", email)
+	}
 }
 
 // --- MIDDLEWARE ---
@@ -179,7 +232,40 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 
 		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			c.Set("user_id", uint(claims["user_id"].(float64)))
+			userID := uint(claims["user_id"].(float64))
+			tokenVersion, _ := claims["tv"].(float64)
+
+			// Token lama (sebelum ganti password/email) atau akun terhapus ditolak
+			var user User
+			if err := db.Select("id", "token_version", "role", "locked_at", "must_reset_password").First(&user, userID).Error; err != nil || user.TokenVersion != int(tokenVersion) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Session expired"})
+				c.Abort()
+				return
+			}
+
+			if user.LockedAt != nil {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Account locked"})
+				c.Abort()
+				return
+			}
+
+			// Reset paksa dari admin: hanya boleh ganti password dulu
+			if user.MustResetPassword && c.FullPath() != "/api/account/password" {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Password reset required"})
+				c.Abort()
+				return
+			}
+
+			// Role selalu dari DB, bukan dari token
+			role := user.Role
+			if role == "" {
+				role = middleware.RoleUser
+			}
+			c.Set("user_id", userID)
+			c.Set("role", role)
+			if at, ok := claims[middleware.ClaimOIDCAuthAt].(float64); ok {
+				c.Set("oidc_auth_at", time.Unix(int64(at), 0))
+			}
 		}
 		c.Next()
 	}
@@ -192,15 +278,11 @@ func getUserStats(userID uint) (UserStatsDTO, error) {
 		Result int
 	}
 
-	// Query: Ambil status TERAKHIR user
+	// Query: Ambil status TERAKHIR user (user_vocab_states)
 	err := db.Raw(`
-		SELECT result 
-		FROM (
-			SELECT DISTINCT ON (vocab_id) result, vocab_id
-			FROM review_logs 
-			WHERE user_id = ? 
-			ORDER BY vocab_id, reviewed_at DESC
-		) as latest_reviews
+		SELECT result
+		FROM user_vocab_states
+		WHERE user_id = ?
 	`, userID).Scan(&results).Error
 
 	if err != nil {
@@ -275,13 +357,47 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": user.ID,
-		"exp":     time.Now().Add(24 * time.Hour).Unix(),
+	if user.LockedAt != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account locked"})
+		return
+	}
+
+	tokenString, _ := middleware.IssueToken(user.ID, user.TokenVersion, user.Role)
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":               tokenString,
+		"username":            user.Username,
+		"must_reset_password": user.MustResetPassword,
 	})
-	tokenString, _ := token.SignedString(jwtSecret)
+}
+
+// Get Profile Handler
+func GetProfile(c *gin.Context) {
+	userID, _ := c.Get("user_id")
 
-	c.JSON(http.StatusOK, gin.H{"token": tokenString, "username": user.Username})
+	var user User
+	if err := db.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	xp, err := handlers.XPProgress(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":           user.ID,
+		"username":     user.Username,
+		"email":        user.Email,
+		"role":         user.Role,
+		"avatar":       user.Avatar,
+		"passwordless": user.Password == "", // akun OIDC, re-auth lewat login OIDC
+		"timezone":     streak.Location(user.Timezone).String(),
+		"created_at":   user.CreatedAt,
+		"xp":           xp,
+	})
 }
 
 // Update Profile Handler
@@ -290,18 +406,24 @@ func UpdateProfile(c *gin.Context) {
 	var input struct {
 		Username string `json:"username"`
 		Avatar   string `json:"avatar"`
+		Timezone string `json:"timezone"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Input invalid"})
 		return
 	}
+	if input.Timezone != "" && !streak.ValidTimezone(input.Timezone) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid timezone"})
+		return
+	}
 
 	var user User
 	if err := db.First(&user, userID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
+	before := gin.H{"username": user.Username, "avatar": user.Avatar, "timezone": user.Timezone}
 
 	if input.Username != "" && input.Username != user.Username {
 		var check User
@@ -316,49 +438,69 @@ func UpdateProfile(c *gin.Context) {
 		user.Avatar = input.Avatar
 	}
 
-	db.Save(&user)
+	if input.Timezone != "" {
+		user.Timezone = input.Timezone
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&user).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, audit.Entry{
+			Action: "profile.updated", TargetType: "user", TargetID: user.ID,
+			Before: before, After: gin.H{"username": user.Username, "avatar": user.Avatar, "timezone": user.Timezone},
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message":  "Profile updated",
 		"username": user.Username,
 		"avatar":   user.Avatar,
+		"timezone": streak.Location(user.Timezone).String(),
 	})
 }
 
 func GetVocabularies(c *gin.Context) {
-	var vocabs []Vocabulary
-	if result := db.Order("RANDOM()").Limit(50).Find(&vocabs); result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch data"})
-		return
-	}
-	c.JSON(http.StatusOK, gin.H{"data": vocabs})
-}
-
-func SubmitReview(c *gin.Context) {
 	userID, _ := c.Get("user_id")
-	var input struct {
-		VocabID uint `json:"vocab_id" binding:"required"`
-		Result  int  `json:"result" binding:"gte=0,lte=2"`
-	}
 
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	// ?tag=verb atau ?tag=food,time: sesi belajar per topik / jenis kata
+	tags, ok := handlers.TagFilter(c)
+	if !ok {
 		return
 	}
-
-	review := ReviewLog{
-		UserID:     userID.(uint),
-		VocabID:    input.VocabID,
-		Result:     input.Result,
-		ReviewedAt: time.Now(),
+	byTag := func(q *gorm.DB) *gorm.DB {
+		if len(tags) == 0 {
+			return q
+		}
+		return q.Where(tagging.FilterSQL("vocabularies.id"), tags)
 	}
 
-	if err := db.Create(&review).Error; err != nil {
-		log.Printf("[ERROR] Save log failed: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save"})
+	// Kata di antrian belajar (study queue) muncul lebih dulu, sisanya acak
+	var vocabs []Vocabulary
+	if result := byTag(db.Joins("JOIN study_queue_items q ON q.vocab_id = vocabularies.id")).
+		Where("q.user_id = ?", userID).Where(handlers.ReadableVocabSQL("vocabularies"), userID, userID, userID, userID).
+		Order("q.created_at").Limit(50).Find(&vocabs); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch data"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Saved"})
+	if len(vocabs) < 50 {
+		ids := []uint{0}
+		for _, v := range vocabs {
+			ids = append(ids, v.ID)
+		}
+		var random []Vocabulary
+		if result := byTag(db.Where("id NOT IN ? AND owner_id IS NULL", ids)).Order("RANDOM()").Limit(50 - len(vocabs)).Find(&random); result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch data"})
+			return
+		}
+		vocabs = append(vocabs, random...)
+	}
+	c.JSON(http.StatusOK, gin.H{"data": vocabs, "tags": tags})
 }
 
 func GetStats(c *gin.Context) {
@@ -371,33 +513,55 @@ func GetStats(c *gin.Context) {
 	}
 
 	var totalVocabs int64
-	db.Model(&Vocabulary{}).Count(&totalVocabs)
+	db.Model(&Vocabulary{}).Where("owner_id IS NULL").Count(&totalVocabs)
 	mastery := 0.0
 	if totalVocabs > 0 {
 		mastery = (float64(stats.IngatCount) / float64(totalVocabs)) * 100
 	}
 
-	//Call ML for Prediction
-	var mlData MLResponse
-	reqBody, _ := json.Marshal(map[string]int{
-		"total_learned": stats.TotalLearned,
-		"ingat_count":   stats.IngatCount,
-		"ragu_count":    stats.RaguCount,
-		"lupa_count":    stats.LupaCount,
-	})
-
-	resp, err := httpClient.Post(mlServiceURL, "application/json", bytes.NewBuffer(reqBody))
-	if err == nil && resp.StatusCode == 200 {
-		defer resp.Body.Close()
-		json.NewDecoder(resp.Body).Decode(&mlData)
+	// Prediksi dari job batch (user_predictions) hanya kalau dihitung dari statistik yang sama
+	// dengan sekarang; kalau belum ada, sudah basi (lebih tua dari 2x interval job, mis. job macet),
+	// atau user sudah review lagi sejak itu, hitung lewat mlclient (hasilnya di-cache)
+	var mlData mlclient.Prediction
+	var due gin.H
+	var computedAt *time.Time
+
+	var stored models.UserPrediction
+	freshSince := time.Now().Add(-2 * jobs.PredictionInterval())
+	err = db.Where("user_id = ? AND computed_at >= ?", userID, freshSince).First(&stored).Error
+	if err == nil && stored.TotalLearned == stats.TotalLearned && stored.IngatCount == stats.IngatCount &&
+		stored.RaguCount == stats.RaguCount && stored.LupaCount == stats.LupaCount {
+		mlData = mlclient.Prediction{
+			RetentionRate: stored.RetentionRate, Status: stored.Status, DecayRisk: stored.DecayRisk,
+			NextReviewHours: stored.NextReviewHours,
+		}
+		json.Unmarshal([]byte(stored.GraphData), &mlData.GraphData)
+		due = gin.H{"now": stored.DueNow, "next_24h": stored.Due24h, "next_7d": stored.Due7d}
+		computedAt = &stored.ComputedAt
 	} else {
-		// Fallback
-		mlData = MLResponse{
-			RetentionRate: 100, Status: "OFFLINE", DecayRisk: "UNKNOWN", NextReviewHours: 0,
-			GraphData: []float64{0, 0, 0, 0, 0, 0, 0},
+		mlData, err = mlclient.Default().PredictRetention(c.Request.Context(), mlclient.StatsInput{
+			TotalLearned: stats.TotalLearned,
+			IngatCount:   stats.IngatCount,
+			RaguCount:    stats.RaguCount,
+			LupaCount:    stats.LupaCount,
+		})
+		if err != nil {
+			log.Printf("[WARN] ML prediction unavailable: %v", err)
+			mlData = mlclient.OfflinePrediction()
 		}
 	}
 
+	xp, err := handlers.XPProgress(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	streakStatus, err := handlers.StreakStatus(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"total_learned":     stats.TotalLearned,
 		"ingat_count":       stats.IngatCount,
@@ -410,21 +574,34 @@ func GetStats(c *gin.Context) {
 		"decay_risk":        mlData.DecayRisk,
 		"next_review_hours": mlData.NextReviewHours,
 		"graph_data":        mlData.GraphData,
+		"due_forecast":      due,
+		"prediction_at":     computedAt,
+		"xp":                xp,
+		"streak":            streakStatus,
 	})
 }
 
 func GetExamQuestions(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 
+	// ?tag=: ujian khusus topik / jenis kata
+	tags, ok := handlers.TagFilter(c)
+	if !ok {
+		return
+	}
+
+	query := `
+		SELECT vocab_id FROM user_vocab_states
+		WHERE user_id = ? AND result = 2
+	`
+	args := []interface{}{userID}
+	if len(tags) > 0 {
+		query += ` AND ` + tagging.FilterSQL("vocab_id")
+		args = append(args, tags)
+	}
+
 	var masteredIDs []uint
-	err := db.Raw(`
-		SELECT vocab_id FROM (
-			SELECT DISTINCT ON (vocab_id) vocab_id, result
-			FROM review_logs 
-			WHERE user_id = ? 
-			ORDER BY vocab_id, reviewed_at DESC
-		) as latest WHERE result = 2
-	`, userID).Scan(&masteredIDs).Error
+	err := db.Raw(query, args...).Scan(&masteredIDs).Error
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
@@ -432,7 +609,19 @@ func GetExamQuestions(c *gin.Context) {
 	}
 
 	masteredCount := len(masteredIDs)
-	const MIN_REQ = 25
+	MIN_REQ := 25
+
+	// Topik kecil (mis. warna) tidak punya 25 kata: cukup kuasai semua kata bertag itu
+	if len(tags) > 0 {
+		var tagged int64
+		db.Model(&Vocabulary{}).Where("owner_id IS NULL").Where(tagging.FilterSQL("id"), tags).Count(&tagged)
+		if int(tagged) < MIN_REQ {
+			MIN_REQ = int(tagged)
+		}
+		if MIN_REQ < 1 {
+			MIN_REQ = 1
+		}
+	}
 
 	if masteredCount < MIN_REQ {
 		c.JSON(http.StatusForbidden, gin.H{
@@ -455,40 +644,24 @@ func GetExamQuestions(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data":           questions,
-		"total_mastered": masteredCount,
-		"exam_size":      examSize,
-	})
-}
-
-// Chat with Sensei Handler
-func ChatWithSensei(c *gin.Context) {
-	var input ChatRequest
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
-		return
+	// Sesi ujian: hasil dikirim ke /api/exam-results dengan session_id ini dan dinilai di server
+	questionIDs := make([]uint, 0, len(questions))
+	for _, q := range questions {
+		questionIDs = append(questionIDs, q.ID)
 	}
-
-	//Forward to Python Service
-	jsonData, _ := json.Marshal(input)
-	resp, err := httpClient.Post(mlChatURL, "application/json", bytes.NewBuffer(jsonData))
-
+	session, err := handlers.NewExamSession(userID.(uint), models.ExamShiren, questionIDs)
 	if err != nil {
-		log.Printf("[ERROR] Chat Service Error: %v", err)
-		c.JSON(http.StatusOK, gin.H{"reply": "Maaf Ronin, saya sedang meditasi (Service Unreachable)."})
-		return
-	}
-	defer resp.Body.Close()
-
-	var mlResp ChatResponse
-	if err := json.NewDecoder(resp.Body).Decode(&mlResp); err != nil {
-		log.Printf("[ERROR] ML Response Decode Error: %v", err)
-		c.JSON(http.StatusOK, gin.H{"reply": "Suara angin mengganggu pendengaranku (Parse Error)."})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate exam"})
 		return
 	}
 
-	c.JSON(http.StatusOK, mlResp)
+	c.JSON(http.StatusOK, gin.H{
+		"data":           questions,
+		"session_id":     session.ID,
+		"total_mastered": masteredCount,
+		"exam_size":      examSize,
+		"tags":           tags,
+	})
 }
 
 // --- MAIN ENTRYPOINT ---
@@ -507,17 +680,175 @@ func main() {
 	r.POST("/register", Register)
 	r.POST("/login", Login)
 
+	//OIDC / Social Login
+	r.GET("/auth/oidc/providers", handlers.ListOIDCProviders)
+	r.GET("/auth/oidc/:provider/login", handlers.OIDCLogin)
+	r.GET("/auth/oidc/:provider/callback", handlers.OIDCCallback)
+
 	//API Group
 	auth := r.Group("/api")
 	auth.Use(AuthMiddleware())
 	{
 		auth.GET("/flashcards", GetVocabularies)
+		auth.GET("/vocabularies", handlers.ListVocabularies)
+		auth.GET("/vocabularies/search", handlers.SearchVocabularies)
 		auth.GET("/stats", GetStats)
-		auth.POST("/review", SubmitReview)
+		auth.GET("/stats/prediction-history", handlers.GetPredictionHistory)
+		auth.POST("/review", handlers.SubmitReview)
 		auth.GET("/exam-questions", GetExamQuestions)
+		auth.GET("/tags", handlers.ListTags)
+		auth.GET("/profile", GetProfile)
 		auth.PUT("/profile", UpdateProfile)
-		auth.POST("/chat", ChatWithSensei) //Chat Endpoint
+		auth.GET("/xp", handlers.GetMyXP)
+		auth.POST("/kana-drills", handlers.SubmitKanaDrill)
+		auth.GET("/streak", handlers.GetStreak)
+		auth.PUT("/streak/goal", handlers.UpdateStreakGoal)
+		auth.GET("/achievements", handlers.GetAchievements)
+		auth.GET("/leaderboards/:board", handlers.GetLeaderboard)
+		auth.GET("/league", handlers.GetLeague)
+		auth.POST("/league/join", handlers.JoinCurrentLeague)
+		auth.GET("/privacy", handlers.GetPrivacy)
+		auth.PUT("/privacy", handlers.UpdatePrivacy)
+
+		// Social: teman, follow, blokir & feed aktivitas
+		auth.POST("/friends/requests", handlers.SendFriendRequest)
+		auth.GET("/friends/requests", handlers.ListFriendRequests)
+		auth.POST("/friends/requests/:id/accept", handlers.AcceptFriendRequest)
+		auth.POST("/friends/requests/:id/decline", handlers.DeclineFriendRequest)
+		auth.GET("/friends", handlers.ListFriends)
+		auth.DELETE("/friends/:user_id", handlers.RemoveFriend)
+		auth.GET("/follows", handlers.ListFollows)
+		auth.POST("/follows/:user_id", handlers.FollowUser)
+		auth.DELETE("/follows/:user_id", handlers.UnfollowUser)
+		auth.GET("/blocks", handlers.ListBlocks)
+		auth.POST("/blocks/:user_id", handlers.BlockUser)
+		auth.DELETE("/blocks/:user_id", handlers.UnblockUser)
+		auth.GET("/feed", handlers.GetFeed)
+
+		//Custom decks
+		auth.GET("/decks", handlers.ListMyDecks)
+		auth.GET("/decks/public", handlers.ListPublicDecks)
+		auth.POST("/decks", handlers.CreateDeck)
+		auth.GET("/decks/:id", handlers.GetDeck)
+		auth.PUT("/decks/:id", handlers.UpdateDeck)
+		auth.DELETE("/decks/:id", handlers.DeleteDeck)
+		auth.GET("/decks/:id/study", handlers.StudyDeck)
+		auth.GET("/decks/:id/changes", handlers.GetDeckChanges)
+		auth.POST("/decks/:id/publish", handlers.PublishDeck)
+		auth.POST("/decks/:id/unpublish", handlers.UnpublishDeck)
+		auth.POST("/decks/:id/subscribe", handlers.SubscribeDeck)
+		auth.DELETE("/decks/:id/subscribe", handlers.UnsubscribeDeck)
+		auth.POST("/decks/:id/cards", handlers.AddDeckCards)
+		auth.POST("/decks/:id/cards/private", handlers.CreatePrivateCard)
+		auth.PUT("/decks/:id/cards/:vocab_id", handlers.UpdatePrivateCard)
+		auth.DELETE("/decks/:id/cards/:vocab_id", handlers.RemoveDeckCard)
+
+		//Classrooms (siswa)
+		auth.GET("/classrooms", handlers.ListMyClassrooms)
+		auth.POST("/classrooms/join", handlers.JoinClassroom)
+		auth.GET("/classrooms/:id", handlers.GetClassroom)
+		auth.DELETE("/classrooms/:id/membership", handlers.LeaveClassroom)
+
+		//Classrooms (guru)
+		classrooms := auth.Group("/classrooms")
+		classrooms.Use(middleware.RequirePermission(middleware.PermClassroomManage))
+		{
+			classrooms.POST("", handlers.CreateClassroom)
+			classrooms.PUT("/:id", handlers.UpdateClassroom)
+			classrooms.DELETE("/:id", handlers.DeleteClassroom)
+			classrooms.POST("/:id/join-code", handlers.RegenerateJoinCode)
+			classrooms.GET("/:id/dashboard", handlers.GetClassroomDashboard)
+			classrooms.DELETE("/:id/students/:user_id", handlers.RemoveClassroomStudent)
+			classrooms.POST("/:id/assignments", handlers.CreateAssignment)
+			classrooms.PUT("/:id/assignments/:assignment_id", handlers.UpdateAssignment)
+			classrooms.DELETE("/:id/assignments/:assignment_id", handlers.DeleteAssignment)
+		}
+		auth.POST("/chat", handlers.ChatWithSensei) //Chat Endpoint
+		auth.POST("/chat/stream", handlers.ChatWithSenseiStream)
+		auth.GET("/conversations", handlers.ListConversations)
+		auth.PUT("/conversations/:id", handlers.RenameConversation)
+		auth.DELETE("/conversations/:id", handlers.DeleteConversation)
+		auth.GET("/conversations/:id/messages", handlers.ListMessages)
+		auth.POST("/conversations/:id/quiz", handlers.ProposeSenseiQuiz)
+		auth.GET("/sensei/context", handlers.GetSenseiContext)
+		auth.GET("/sensei/usage", handlers.GetMyChatUsage)
+		auth.GET("/study-queue", handlers.ListStudyQueue)
+		auth.POST("/study-queue", handlers.AddToStudyQueue)
+		auth.DELETE("/study-queue/:vocab_id", handlers.RemoveFromStudyQueue)
+		auth.GET("/scenarios", handlers.ListScenarios)
+		auth.POST("/scenarios/:slug/start", handlers.StartRoleplay)
+		auth.GET("/roleplay/sessions", handlers.ListRoleplaySessions)
+		auth.GET("/roleplay/sessions/:id", handlers.GetRoleplaySession)
+		auth.POST("/roleplay/sessions/:id/finish", handlers.FinishRoleplay)
+
+		//Account self-service
+		auth.PUT("/account/password", handlers.ChangePassword)
+		auth.PUT("/account/email", handlers.ChangeEmail)
+		auth.DELETE("/account", handlers.DeleteAccount)
+		auth.POST("/auth/oidc/link", handlers.LinkOIDCIdentity)
+
+		//Exam history
+		auth.POST("/exam-sessions", handlers.StartExamSession)
+		auth.POST("/exam-results", handlers.SubmitExamResult)
+		auth.GET("/exam-results", handlers.GetExamHistory)
+
+		//Data export
+		auth.GET("/me/export", handlers.ExportMyData)
+		auth.GET("/me/export/jobs/:id", handlers.GetExportJob)
+		auth.GET("/me/export/jobs/:id/download", handlers.DownloadExport)
+		auth.GET("/me/permissions", handlers.GetMyPermissions)
+
+		//Content management (teacher/admin)
+		content := auth.Group("/content")
+		content.Use(middleware.RequirePermission(middleware.PermContentWrite))
+		{
+			content.POST("/vocabularies", handlers.CreateVocabulary)
+			content.PUT("/vocabularies/:id", handlers.UpdateVocabulary)
+			content.DELETE("/vocabularies/:id", handlers.DeleteVocabulary)
+			content.POST("/tags", handlers.CreateTag)
+			content.PUT("/tags/:id", handlers.UpdateTag)
+			content.DELETE("/tags/:id", handlers.DeleteTag)
+		}
+
+		//Admin
+		admin := auth.Group("/admin")
+		admin.Use(middleware.RequireRole(middleware.RoleAdmin))
+		{
+			admin.GET("/roles", handlers.ListRoles)
+			admin.GET("/audit-logs", middleware.RequirePermission(middleware.PermAuditRead), handlers.ListAuditLogs)
+			admin.GET("/llm-usage", middleware.RequirePermission(middleware.PermAuditRead), handlers.AdminLLMUsage)
+			admin.GET("/chat-guard-events", middleware.RequirePermission(middleware.PermAuditRead), handlers.AdminChatGuardEvents)
+			admin.GET("/ml-metrics", middleware.RequirePermission(middleware.PermAuditRead), handlers.AdminMLMetrics)
+			admin.POST("/achievements/backfill", middleware.RequirePermission(middleware.PermUsersManage), handlers.AdminBackfillAchievements)
+			admin.GET("/achievements/backfill/:id", middleware.RequirePermission(middleware.PermUsersManage), handlers.AdminGetAchievementBackfill)
+			admin.PUT("/users/:id/role", middleware.RequirePermission(middleware.PermRolesAssign), handlers.AssignRole)
+
+			users := admin.Group("/users")
+			{
+				users.GET("", middleware.RequirePermission(middleware.PermUsersRead), handlers.AdminListUsers)
+				users.GET("/:id", middleware.RequirePermission(middleware.PermUsersRead), handlers.AdminGetUser)
+
+				manage := users.Group("/:id", middleware.RequirePermission(middleware.PermUsersManage))
+				manage.POST("/reset-progress", handlers.AdminResetProgress)
+				manage.POST("/lock", handlers.AdminLockUser)
+				manage.POST("/unlock", handlers.AdminUnlockUser)
+				manage.POST("/force-password-reset", handlers.AdminForcePasswordReset)
+				manage.DELETE("", handlers.AdminDeleteUser)
+				manage.POST("/restore", handlers.AdminRestoreUser)
+			}
+		}
+	}
+
+	//Background Jobs
+	if err := jobs.FailInterruptedExports(); err != nil {
+		log.Printf("[WARN] Interrupted export cleanup failed: %v", err)
 	}
+	jobs.Every("account-sweeper", time.Hour, jobs.PurgeDeletedAccounts)
+	jobs.Every("export-cleaner", time.Hour, jobs.PurgeExpiredExports)
+	jobs.Every("retention-predictor", jobs.PredictionInterval(), jobs.PredictRetention)
+	jobs.Every("leaderboard-snapshot", jobs.LeaderboardInterval(), jobs.RefreshLeaderboards)
+	jobs.Every("league-rollover", jobs.LeagueInterval(), jobs.RolloverLeagues)
+	handlers.ResumeAchievementBackfills()
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -0,0 +1,155 @@
+package achievement
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+)
+
+// Domain event yang memicu evaluasi achievement
+const (
+	EventReview    = "review"
+	EventExam      = "exam"
+	EventStreak    = "streak"
+	EventKanaDrill = "kana_drill"
+	EventRoleplay  = "roleplay"
+)
+
+// Metrik yang bisa dipakai rule (dihitung di handlers)
+const (
+	MetricReviews             = "reviews"               // total review
+	MetricIngatReviews        = "ingat_reviews"         // total jawaban Ingat
+	MetricMasteredWords       = "mastered_words"        // kata dengan status terakhir Ingat
+	MetricExamsPassed         = "exams_passed"          // params: exam_type (opsional)
+	MetricLongestStreak       = "longest_streak"        // hari
+	MetricKanaMasteredPercent = "kana_mastered_percent" // params: script
+	MetricRoleplaysCompleted  = "roleplays_completed"
+	MetricXP                  = "xp_total"
+)
+
+// Rule achievement, didefinisikan di seeds/achievements.json
+type Rule struct {
+	Code        string            `json:"code"`
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	Icon        string            `json:"icon"`
+	Events      []string          `json:"events"` // kosong = semua event
+	Metric      string            `json:"metric"`
+	Params      map[string]string `json:"params,omitempty"`
+	Threshold   int64             `json:"threshold"`
+}
+
+func (r Rule) Triggers(event string) bool {
+	if len(r.Events) == 0 {
+		return true
+	}
+	for _, e := range r.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+var knownMetrics = map[string]bool{
+	MetricReviews: true, MetricIngatReviews: true, MetricMasteredWords: true, MetricExamsPassed: true,
+	MetricLongestStreak: true, MetricKanaMasteredPercent: true, MetricRoleplaysCompleted: true, MetricXP: true,
+}
+
+var (
+	loadOnce sync.Once
+	rules    []Rule
+)
+
+// File rule: ACHIEVEMENTS_FILE (default seeds/achievements.json)
+func path() string {
+	if v := os.Getenv("ACHIEVEMENTS_FILE"); v != "" {
+		return v
+	}
+	return "seeds/achievements.json"
+}
+
+func Load(file string) ([]Rule, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var list []Rule
+	if err := json.Unmarshal(b, &list); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", file, err)
+	}
+
+	seen := map[string]bool{}
+	for _, r := range list {
+		if r.Code == "" || seen[r.Code] {
+			return nil, fmt.Errorf("achievement %q: code is required and must be unique", r.Title)
+		}
+		if !knownMetrics[r.Metric] || r.Threshold <= 0 {
+			return nil, fmt.Errorf("achievement %q: unknown metric %q or threshold <= 0", r.Code, r.Metric)
+		}
+		seen[r.Code] = true
+	}
+	return list, nil
+}
+
+func ensureLoaded() {
+	loadOnce.Do(func() {
+		var err error
+		if rules, err = Load(path()); err != nil {
+			log.Printf("[WARN] Achievements not loaded: %v", err)
+			rules = nil
+			return
+		}
+		log.Printf("[INFO] Loaded %d achievements", len(rules))
+	})
+}
+
+func All() []Rule {
+	ensureLoaded()
+	return rules
+}
+
+// ForEvents: rule yang perlu dievaluasi untuk event-event ini
+func ForEvents(events ...string) []Rule {
+	var out []Rule
+	for _, r := range All() {
+		for _, e := range events {
+			if r.Triggers(e) {
+				out = append(out, r)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// MetricFunc: nilai metrik untuk satu user
+type MetricFunc func(metric string, params map[string]string) (int64, error)
+
+// Evaluate: rule yang baru terpenuhi (belum ada di unlocked). Nilai metrik di-cache per (metric, params).
+func Evaluate(list []Rule, unlocked map[string]bool, value MetricFunc) ([]Rule, map[string]int64, error) {
+	var out []Rule
+	values := map[string]int64{}
+	for _, r := range list {
+		key := r.ValueKey()
+		v, ok := values[key]
+		if !ok {
+			var err error
+			if v, err = value(r.Metric, r.Params); err != nil {
+				return nil, nil, err
+			}
+			values[key] = v
+		}
+		if !unlocked[r.Code] && v >= r.Threshold {
+			out = append(out, r)
+		}
+	}
+	return out, values, nil
+}
+
+// ValueKey: kunci cache nilai metrik milik rule (lihat Evaluate)
+func (r Rule) ValueKey() string {
+	return r.Metric + fmt.Sprint(r.Params)
+}
@@ -0,0 +1,115 @@
+package audit
+
+import (
+	"encoding/json"
+	"kotoba-backend/internal/models"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Entry struct {
+	ActorID    uint // opsional, default user_id dari context
+	Action     string
+	TargetType string
+	TargetID   uint
+	Before     interface{}
+	After      interface{}
+}
+
+// Record: tulis audit log. Pakai tx yang sama dengan perubahan datanya
+// supaya audit ikut rollback kalau perubahan gagal.
+// Kalau Before & After sama-sama ada, yang disimpan hanya field yang berubah.
+func Record(tx *gorm.DB, c *gin.Context, e Entry) error {
+	before, after := diff(e.Before, e.After)
+
+	log := models.AuditLog{
+		ActorID:    e.ActorID,
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		Before:     before,
+		After:      after,
+		IP:         c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+	}
+	if id, ok := c.Get("user_id"); ok && log.ActorID == 0 {
+		log.ActorID, _ = id.(uint)
+	}
+	return tx.Create(&log).Error
+}
+
+// Migrate: pasang trigger supaya audit_logs tidak bisa di-UPDATE/DELETE/TRUNCATE
+func Migrate(db *gorm.DB) error {
+	statements := []string{
+		`CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'audit_logs is append-only';
+		END;
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS audit_logs_no_modify ON audit_logs`,
+		`CREATE TRIGGER audit_logs_no_modify BEFORE UPDATE OR DELETE ON audit_logs
+			FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only()`,
+		`DROP TRIGGER IF EXISTS audit_logs_no_truncate ON audit_logs`,
+		`CREATE TRIGGER audit_logs_no_truncate BEFORE TRUNCATE ON audit_logs
+			FOR EACH STATEMENT EXECUTE FUNCTION audit_logs_append_only()`,
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func diff(before, after interface{}) (models.JSONText, models.JSONText) {
+	if before == nil || after == nil {
+		return toJSON(before), toJSON(after)
+	}
+
+	b, okB := toMap(before)
+	a, okA := toMap(after)
+	if !okB || !okA {
+		return toJSON(before), toJSON(after)
+	}
+
+	changedBefore := map[string]interface{}{}
+	changedAfter := map[string]interface{}{}
+	for k, v := range b {
+		if !reflect.DeepEqual(v, a[k]) {
+			changedBefore[k] = v
+			changedAfter[k] = a[k]
+		}
+	}
+	for k, v := range a {
+		if _, ok := b[k]; !ok {
+			changedBefore[k] = nil
+			changedAfter[k] = v
+		}
+	}
+	return toJSON(changedBefore), toJSON(changedAfter)
+}
+
+func toMap(v interface{}) (map[string]interface{}, bool) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, false
+	}
+	return m, true
+}
+
+func toJSON(v interface{}) models.JSONText {
+	if v == nil {
+		return ""
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return models.JSONText(b)
+}
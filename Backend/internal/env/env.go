@@ -0,0 +1,23 @@
+// Package env: pembacaan konfigurasi angka dari environment variable
+package env
+
+import (
+	"os"
+	"strconv"
+)
+
+// Int: nilai >= 0 dari env, selain itu (kosong/invalid/negatif) fallback. 0 berarti "matikan".
+func Int(key string, fallback int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil && v >= 0 {
+		return v
+	}
+	return fallback
+}
+
+// PositiveInt: seperti Int, tapi 0 juga dianggap tidak diset (interval, ukuran batch)
+func PositiveInt(key string, fallback int) int {
+	if v := Int(key, fallback); v > 0 {
+		return v
+	}
+	return fallback
+}
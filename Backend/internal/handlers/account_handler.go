@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"kotoba-backend/internal/audit"
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/jobs"
+	"kotoba-backend/internal/middleware"
+	"kotoba-backend/internal/models"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Login OIDC dalam jendela ini berlaku sebagai re-auth pengganti password
+const oidcReauthWindow = 10 * time.Minute
+
+// Token sesi ini berasal dari login OIDC yang masih baru
+func freshOIDCAuth(c *gin.Context) bool {
+	at, ok := c.Get("oidc_auth_at")
+	if !ok {
+		return false
+	}
+	t, ok := at.(time.Time)
+	return ok && time.Since(t) < oidcReauthWindow
+}
+
+// Ambil user yang sedang login + verifikasi password saat ini.
+// Akun OIDC tanpa password: re-auth lewat login OIDC yang baru sebagai gantinya.
+func loadUserWithPassword(c *gin.Context, password string) (models.User, bool) {
+	var user models.User
+	if err := database.DB.First(&user, getUserID(c)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return user, false
+	}
+
+	if user.Password == "" {
+		if !freshOIDCAuth(c) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Re-authentication required", "reauth": "oidc"})
+			return user, false
+		}
+		return user, true
+	}
+	if !middleware.CheckPasswordHash(password, user.Password) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		return user, false
+	}
+	return user, true
+}
+
+// Naikkan token_version lalu terbitkan token baru untuk sesi ini saja
+func rotateSession(tx *gorm.DB, user *models.User) (string, error) {
+	user.TokenVersion++
+	if err := tx.Model(user).Update("token_version", user.TokenVersion).Error; err != nil {
+		return "", err
+	}
+	return middleware.IssueToken(user.ID, user.TokenVersion, user.Role)
+}
+
+// CHANGE PASSWORD
+func ChangePassword(c *gin.Context) {
+	var input struct {
+		CurrentPassword string `json:"current_password"` // kosong untuk akun OIDC, lihat loadUserWithPassword
+		NewPassword     string `json:"new_password" binding:"required,min=6"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	user, ok := loadUserWithPassword(c, input.CurrentPassword)
+	if !ok {
+		return
+	}
+
+	hash, err := middleware.HashPassword(input.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+		return
+	}
+
+	var token string
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{"password": hash, "must_reset_password": false}).Error; err != nil {
+			return err
+		}
+		if err := audit.Record(tx, c, audit.Entry{Action: "account.password_changed", TargetType: "user", TargetID: user.ID}); err != nil {
+			return err
+		}
+		var err error
+		token, err = rotateSession(tx, &user)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password updated", "token": token})
+}
+
+// CHANGE EMAIL
+func ChangeEmail(c *gin.Context) {
+	var input struct {
+		CurrentPassword string `json:"current_password"`
+		NewEmail        string `json:"new_email" binding:"required,email"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	user, ok := loadUserWithPassword(c, input.CurrentPassword)
+	if !ok {
+		return
+	}
+
+	newEmail := strings.TrimSpace(input.NewEmail)
+	if strings.EqualFold(newEmail, user.Email) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "New email is the same as current email"})
+		return
+	}
+
+	// Unscoped: email akun yang sedang masa tenggang hapus tetap terkunci
+	var count int64
+	database.DB.Unscoped().Model(&models.User{}).Where("LOWER(email) = LOWER(?)", newEmail).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Email already registered"})
+		return
+	}
+
+	oldEmail := user.Email
+	var token string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{"email": newEmail, "email_verified_at": nil}).Error; err != nil {
+			return err
+		}
+		err := audit.Record(tx, c, audit.Entry{
+			Action: "account.email_changed", TargetType: "user", TargetID: user.ID,
+			Before: gin.H{"email": oldEmail}, After: gin.H{"email": newEmail},
+		})
+		if err != nil {
+			return err
+		}
+		token, err = rotateSession(tx, &user)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email updated", "email": newEmail, "token": token})
+}
+
+// DELETE ACCOUNT (soft delete, dibersihkan permanen oleh jobs.PurgeDeletedAccounts)
+func DeleteAccount(c *gin.Context) {
+	var input struct {
+		Password string `json:"password"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	user, ok := loadUserWithPassword(c, input.Password)
+	if !ok {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("token_version", user.TokenVersion+1).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&user).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, audit.Entry{Action: "account.deleted", TargetType: "user", TargetID: user.ID})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Account scheduled for deletion",
+		"purge_after": time.Now().Add(jobs.DeletionGracePeriod()),
+	})
+}
@@ -0,0 +1,315 @@
+package handlers
+
+import (
+	"fmt"
+	"kotoba-backend/internal/achievement"
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/kana"
+	"kotoba-backend/internal/models"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+const achievementBackfillBatch = 200
+
+// Nilai metrik achievement untuk satu user
+func achievementMetric(userID uint) achievement.MetricFunc {
+	return func(metric string, params map[string]string) (int64, error) {
+		var v int64
+		var err error
+		switch metric {
+		case achievement.MetricReviews:
+			err = database.DB.Model(&models.ReviewLog{}).Where("user_id = ?", userID).Count(&v).Error
+		case achievement.MetricIngatReviews:
+			err = database.DB.Model(&models.ReviewLog{}).Where("user_id = ? AND result = 2", userID).Count(&v).Error
+		case achievement.MetricMasteredWords:
+			err = database.DB.Raw(latestReviewCTE+` SELECT COUNT(*) FROM latest WHERE result = 2`, userID).Scan(&v).Error
+		case achievement.MetricExamsPassed:
+			query := database.DB.Model(&models.ExamResult{}).Where("user_id = ? AND passed", userID)
+			if t := params["exam_type"]; t != "" {
+				query = query.Where("exam_type = ?", t)
+			}
+			err = query.Count(&v).Error
+		case achievement.MetricLongestStreak:
+			err = database.DB.Model(&models.UserStreak{}).Where("user_id = ?", userID).
+				Select("COALESCE(MAX(longest), 0)").Scan(&v).Error
+		case achievement.MetricKanaMasteredPercent:
+			chars := kana.Chars(params["script"])
+			if len(chars) == 0 {
+				return 0, nil
+			}
+			var mastered int64
+			err = database.DB.Model(&models.KanaProgress{}).
+				Where("user_id = ? AND kana IN ? AND correct_count >= ? AND last_correct", userID, chars, kana.MasteryCorrect).
+				Count(&mastered).Error
+			v = mastered * 100 / int64(len(chars))
+		case achievement.MetricRoleplaysCompleted:
+			err = database.DB.Model(&models.RoleplaySession{}).
+				Where("user_id = ? AND status = ?", userID, models.RoleplayCompleted).Count(&v).Error
+		case achievement.MetricXP:
+			v, err = totalXP(userID)
+		}
+		return v, err
+	}
+}
+
+// Kapan rule sebenarnya terpenuhi, dari data lama (backfill). nil = tidak bisa dilacak, pakai waktu sekarang.
+func achievementReachedAt(userID uint, r achievement.Rule) *time.Time {
+	var query string
+	args := []interface{}{userID}
+	switch r.Metric {
+	case achievement.MetricReviews:
+		query = `SELECT reviewed_at FROM review_logs WHERE user_id = ? ORDER BY reviewed_at, id`
+	case achievement.MetricIngatReviews:
+		query = `SELECT reviewed_at FROM review_logs WHERE user_id = ? AND result = 2 ORDER BY reviewed_at, id`
+	case achievement.MetricExamsPassed:
+		query = `SELECT taken_at FROM exam_results WHERE user_id = ? AND passed`
+		if t := r.Params["exam_type"]; t != "" {
+			query += ` AND exam_type = ?`
+			args = append(args, t)
+		}
+		query += ` ORDER BY taken_at, id`
+	case achievement.MetricRoleplaysCompleted:
+		query = `SELECT ended_at FROM roleplay_sessions WHERE user_id = ? AND ended_at IS NOT NULL ORDER BY ended_at, id`
+	case achievement.MetricXP:
+		query = `
+			SELECT created_at FROM (
+				SELECT created_at, SUM(amount) OVER (ORDER BY created_at, id) AS running
+				FROM xp_events WHERE user_id = ?
+			) x WHERE running >= ? ORDER BY created_at LIMIT 1`
+		args = append(args, r.Threshold)
+	default:
+		return nil
+	}
+
+	if r.Metric != achievement.MetricXP {
+		// Event ke-N (threshold) dalam urutan waktu
+		query += ` OFFSET ? LIMIT 1`
+		args = append(args, r.Threshold-1)
+	}
+	var times []time.Time
+	if err := database.DB.Raw(query, args...).Scan(&times).Error; err != nil || len(times) == 0 {
+		return nil
+	}
+	return &times[0]
+}
+
+func unlockedAchievements(userID uint) (map[string]bool, error) {
+	var codes []string
+	if err := database.DB.Model(&models.UserAchievement{}).Where("user_id = ?", userID).Pluck("code", &codes).Error; err != nil {
+		return nil, err
+	}
+	unlocked := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		unlocked[code] = true
+	}
+	return unlocked, nil
+}
+
+// Evaluasi rule untuk user dan simpan yang baru terbuka.
+// backfill = evaluasi ulang data lama; waktu unlock diambil dari kapan rule sebenarnya terpenuhi.
+func unlockAchievements(userID uint, rules []achievement.Rule, backfill bool) ([]achievement.Rule, map[string]int64, error) {
+	unlocked, err := unlockedAchievements(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reached, values, err := achievement.Evaluate(rules, unlocked, achievementMetric(userID))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var out []achievement.Rule
+	for _, r := range reached {
+		row := models.UserAchievement{UserID: userID, Code: r.Code, UnlockedAt: time.Now(), Backfilled: backfill}
+		if backfill {
+			if at := achievementReachedAt(userID, r); at != nil {
+				row.UnlockedAt = *at
+			}
+		}
+		result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&row)
+		if result.Error != nil {
+			return nil, nil, result.Error
+		}
+		if result.RowsAffected > 0 {
+			out = append(out, r)
+		}
+	}
+	return out, values, nil
+}
+
+// Terima domain event (setelah transaksi commit) dan kembalikan achievement yang baru terbuka.
+// Gagal evaluasi tidak menggagalkan request asal.
+func dispatchAchievementEvents(userID uint, events ...string) []achievement.Rule {
+	done, err := unlockedAchievements(userID)
+	if err != nil {
+		log.Printf("[ERROR] Achievement evaluation failed (user %d, events %v): %v", userID, events, err)
+		return nil
+	}
+	// Yang sudah terbuka tidak perlu dihitung ulang
+	var rules []achievement.Rule
+	for _, r := range achievement.ForEvents(events...) {
+		if !done[r.Code] {
+			rules = append(rules, r)
+		}
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	unlocked, _, err := unlockAchievements(userID, rules, false)
+	if err != nil {
+		log.Printf("[ERROR] Achievement evaluation failed (user %d, events %v): %v", userID, events, err)
+		return nil
+	}
+	for _, r := range unlocked {
+		log.Printf("[INFO] User %d unlocked achievement %s", userID, r.Code)
+		recordActivityLater(userID, models.ActivityAchievement, gin.H{"code": r.Code, "title": r.Title, "icon": r.Icon})
+	}
+	return unlocked
+}
+
+type achievementView struct {
+	achievement.Rule
+	Unlocked   bool       `json:"unlocked"`
+	UnlockedAt *time.Time `json:"unlocked_at"`
+	Progress   int64      `json:"progress"`
+}
+
+// GET ACHIEVEMENTS: semua achievement + status user. Read-only: unlock hanya lewat
+// dispatchAchievementEvents dan backfill admin.
+func GetAchievements(c *gin.Context) {
+	userID := getUserID(c)
+	rules := achievement.All()
+
+	unlocked, err := unlockedAchievements(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	_, values, err := achievement.Evaluate(rules, unlocked, achievementMetric(userID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+
+	var rows []models.UserAchievement
+	if err := database.DB.Where("user_id = ?", userID).Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	unlockedAt := make(map[string]time.Time, len(rows))
+	for _, row := range rows {
+		unlockedAt[row.Code] = row.UnlockedAt
+	}
+
+	out := make([]achievementView, 0, len(rules))
+	count := 0
+	for _, r := range rules {
+		v := achievementView{Rule: r, Progress: values[r.ValueKey()]}
+		if v.Progress > r.Threshold {
+			v.Progress = r.Threshold
+		}
+		if at, ok := unlockedAt[r.Code]; ok {
+			v.Unlocked = true
+			v.UnlockedAt = &at
+			v.Progress = r.Threshold
+			count++
+		}
+		out = append(out, v)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": out, "unlocked": count, "total": len(rules)})
+}
+
+// BACKFILL ACHIEVEMENTS (admin): evaluasi semua rule untuk semua user dari data lama.
+// Jalan di background per batch user (cursor id); status dipantau lewat GET .../backfill/:id.
+func AdminBackfillAchievements(c *gin.Context) {
+	// Satu job sekaligus
+	var job models.AchievementBackfillJob
+	if err := database.DB.Where("status = ?", models.BackfillRunning).First(&job).Error; err == nil {
+		c.JSON(http.StatusAccepted, gin.H{"data": job})
+		return
+	}
+
+	job = models.AchievementBackfillJob{Status: models.BackfillRunning, StartedBy: getUserID(c)}
+	if err := database.DB.Create(&job).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start backfill"})
+		return
+	}
+	go runAchievementBackfill(job)
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"data":       job,
+		"status_url": fmt.Sprintf("/api/admin/achievements/backfill/%d", job.ID),
+	})
+}
+
+// GET BACKFILL JOB (admin)
+func AdminGetAchievementBackfill(c *gin.Context) {
+	var job models.AchievementBackfillJob
+	if err := database.DB.First(&job, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Backfill job not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": job})
+}
+
+func runAchievementBackfill(job models.AchievementBackfillJob) {
+	rules := achievement.All()
+	fail := func(err error) {
+		log.Printf("[ERROR] Achievement backfill %d failed: %v", job.ID, err)
+		database.DB.Model(&job).Updates(map[string]interface{}{
+			"status": models.BackfillFailed, "error": "Backfill failed", "completed_at": time.Now(),
+		})
+	}
+
+	for {
+		var ids []uint
+		if err := database.DB.Model(&models.User{}).Where("id > ?", job.LastUserID).Order("id").
+			Limit(achievementBackfillBatch).Pluck("id", &ids).Error; err != nil {
+			fail(err)
+			return
+		}
+		if len(ids) == 0 {
+			break
+		}
+		for _, id := range ids {
+			got, _, err := unlockAchievements(id, rules, true)
+			if err != nil {
+				log.Printf("[ERROR] Achievement backfill failed for user %d: %v", id, err)
+				continue
+			}
+			job.Users++
+			job.Unlocked += len(got)
+		}
+		// Progres disimpan per batch
+		job.LastUserID = ids[len(ids)-1]
+		if err := database.DB.Model(&job).Updates(map[string]interface{}{
+			"last_user_id": job.LastUserID, "users": job.Users, "unlocked": job.Unlocked,
+		}).Error; err != nil {
+			fail(err)
+			return
+		}
+	}
+
+	database.DB.Model(&job).Updates(map[string]interface{}{"status": models.BackfillDone, "completed_at": time.Now()})
+	log.Printf("[INFO] Achievement backfill %d: %d users, %d unlocks", job.ID, job.Users, job.Unlocked)
+}
+
+// Lanjutkan job backfill yang terputus karena restart, mulai dari cursor terakhir
+func ResumeAchievementBackfills() {
+	var jobs []models.AchievementBackfillJob
+	if err := database.DB.Where("status = ?", models.BackfillRunning).Find(&jobs).Error; err != nil {
+		log.Printf("[ERROR] Load achievement backfill jobs failed: %v", err)
+		return
+	}
+	for _, job := range jobs {
+		log.Printf("[INFO] Resuming achievement backfill %d from user %d", job.ID, job.LastUserID)
+		go runAchievementBackfill(job)
+	}
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/models"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Streak yang dianggap milestone untuk feed (setelah 365: tiap 100 hari)
+var streakMilestones = map[int]bool{3: true, 7: true, 14: true, 30: true, 50: true, 100: true, 200: true, 365: true}
+
+func isStreakMilestone(days int) bool {
+	return streakMilestones[days] || (days > 365 && days%100 == 0)
+}
+
+// Catat aktivitas untuk feed. Siapa yang boleh melihat ditentukan saat dibaca (privacy_settings).
+func recordActivity(tx *gorm.DB, userID uint, kind string, payload interface{}) error {
+	event := models.ActivityEvent{UserID: userID, Kind: kind, Payload: toJSONText(payload)}
+	return tx.Create(&event).Error
+}
+
+// Sama seperti recordActivity, untuk pemanggil di luar transaksi: gagal hanya di-log
+func recordActivityLater(userID uint, kind string, payload interface{}) {
+	if err := recordActivity(database.DB, userID, kind, payload); err != nil {
+		log.Printf("[ERROR] Save activity %s failed (user %d): %v", kind, userID, err)
+	}
+}
+
+// Level privasi aktivitas a (alias tabel activity_events) dari privacy_settings p
+const activityVisibilitySQL = `
+	CASE a.kind
+		WHEN 'exam_passed' THEN COALESCE(p.feed_exams, 'friends')
+		WHEN 'achievement_unlocked' THEN COALESCE(p.feed_achievements, 'friends')
+		WHEN 'streak_milestone' THEN COALESCE(p.feed_streaks, 'friends')
+		ELSE 'private'
+	END`
+
+// Feed: aktivitas sendiri, teman (public/friends) dan yang di-follow (public). Blokir dua arah disaring.
+// Argumen: cursor, cursor, lalu userID tujuh kali, lalu limit.
+const feedQuery = `
+	SELECT a.id, a.user_id, a.kind, a.payload, a.created_at, u.username, u.avatar
+	FROM activity_events a
+	JOIN users u ON u.id = a.user_id AND u.deleted_at IS NULL
+	LEFT JOIN privacy_settings p ON p.user_id = a.user_id
+	WHERE (? = 0 OR a.id < ?)
+		AND (
+			a.user_id = ?
+			OR (
+				NOT EXISTS (SELECT 1 FROM blocks b WHERE (b.blocker_id = ? AND b.blocked_id = a.user_id) OR (b.blocker_id = a.user_id AND b.blocked_id = ?))
+				AND (
+					(a.user_id IN (` + friendIDsSQL + `) AND ` + activityVisibilitySQL + ` IN ('public', 'friends'))
+					OR (a.user_id IN (SELECT followee_id FROM follows WHERE follower_id = ?) AND ` + activityVisibilitySQL + ` = 'public')
+				)
+			)
+		)
+	ORDER BY a.id DESC
+	LIMIT ?`
+
+type feedItem struct {
+	ID        uint            `json:"id"`
+	UserID    uint            `json:"user_id"`
+	Username  string          `json:"username"`
+	Avatar    string          `json:"avatar"`
+	Kind      string          `json:"kind"`
+	Payload   models.JSONText `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// GET FEED: ?cursor=<next_cursor>&limit=20. Cursor = id aktivitas terakhir yang sudah diterima.
+func GetFeed(c *gin.Context) {
+	userID := getUserID(c)
+	_, limit := pagination(c)
+
+	var cursor uint64
+	if v := c.Query("cursor"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		cursor = n
+	}
+
+	var items []feedItem
+	err := database.DB.Raw(feedQuery, cursor, cursor,
+		userID, userID, userID, userID, userID, userID, userID, limit+1).Scan(&items).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+
+	var next *string
+	if len(items) > limit {
+		items = items[:limit]
+		v := strconv.FormatUint(uint64(items[len(items)-1].ID), 10)
+		next = &v
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items, "next_cursor": next, "limit": limit})
+}
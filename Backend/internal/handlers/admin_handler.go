@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"kotoba-backend/internal/audit"
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Tampilan user untuk admin (DeletedAt ikut ditampilkan)
+type adminUserView struct {
+	ID                uint       `json:"id"`
+	Username          string     `json:"username"`
+	Email             string     `json:"email"`
+	Role              string     `json:"role"`
+	Avatar            string     `json:"avatar"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+	LockedAt          *time.Time `json:"locked_at"`
+	MustResetPassword bool       `json:"must_reset_password"`
+	DeletedAt         *time.Time `json:"deleted_at"`
+}
+
+func toAdminUserView(u models.User) adminUserView {
+	v := adminUserView{
+		ID: u.ID, Username: u.Username, Email: u.Email, Role: u.Role, Avatar: u.Avatar,
+		CreatedAt: u.CreatedAt, UpdatedAt: u.UpdatedAt,
+		LockedAt: u.LockedAt, MustResetPassword: u.MustResetPassword,
+	}
+	if u.DeletedAt.Valid {
+		v.DeletedAt = &u.DeletedAt.Time
+	}
+	return v
+}
+
+// Parse ?page=&limit= (default 1 & 20, limit maks 100)
+func pagination(c *gin.Context) (page, limit int) {
+	page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ = strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	return page, limit
+}
+
+// Cari target user (termasuk yang sudah soft delete)
+func findTargetUser(c *gin.Context) (models.User, bool) {
+	var user models.User
+	if err := database.DB.Unscoped().First(&user, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return user, false
+	}
+	return user, true
+}
+
+// Admin tidak boleh mengunci/menghapus akunnya sendiri
+func rejectSelfTarget(c *gin.Context, user models.User) bool {
+	if user.ID == getUserID(c) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot perform this action on your own account"})
+		return true
+	}
+	return false
+}
+
+// LIST USERS (admin): ?q=&status=active|locked|deleted&page=&limit=
+func AdminListUsers(c *gin.Context) {
+	page, limit := pagination(c)
+
+	query := database.DB.Unscoped().Model(&models.User{})
+	if q := c.Query("q"); q != "" {
+		like := "%" + q + "%"
+		query = query.Where("username ILIKE ? OR email ILIKE ?", like, like)
+	}
+	switch c.Query("status") {
+	case "active":
+		query = query.Where("deleted_at IS NULL AND locked_at IS NULL")
+	case "locked":
+		query = query.Where("deleted_at IS NULL AND locked_at IS NOT NULL")
+	case "deleted":
+		query = query.Where("deleted_at IS NOT NULL")
+	}
+	if role := c.Query("role"); role != "" {
+		query = query.Where("role = ?", role)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var users []models.User
+	if err := query.Order("id").Offset((page - 1) * limit).Limit(limit).Find(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+
+	data := make([]adminUserView, 0, len(users))
+	for _, u := range users {
+		data = append(data, toAdminUserView(u))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": data, "page": page, "limit": limit, "total": total})
+}
+
+// USER DETAIL (admin): profil + stats + review_logs terbaru
+func AdminGetUser(c *gin.Context) {
+	user, ok := findTargetUser(c)
+	if !ok {
+		return
+	}
+
+	stats, err := calculateUserStats(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+
+	var recent []exportReviewRow
+	database.DB.Raw(`
+		SELECT r.id, r.vocab_id, v.kanji, v.kana, v.romaji, v.meaning, v.difficulty_level, r.result, r.reviewed_at
+		FROM review_logs r
+		LEFT JOIN vocabularies v ON v.id = r.vocab_id
+		WHERE r.user_id = ?
+		ORDER BY r.reviewed_at DESC
+		LIMIT 50`, user.ID).Scan(&recent)
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":           toAdminUserView(user),
+		"stats":          stats,
+		"recent_reviews": recent,
+	})
+}
+
+// RESET PROGRESS (admin): hapus semua review_logs user
+func AdminResetProgress(c *gin.Context) {
+	user, ok := findTargetUser(c)
+	if !ok {
+		return
+	}
+
+	var deleted int64
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ?", user.ID).Delete(&models.ReviewLog{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = result.RowsAffected
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.UserVocabState{}).Error; err != nil {
+			return err
+		}
+		// Prediksi retensi dihitung dari review yang baru dihapus
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.UserPrediction{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.UserPredictionHistory{}).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, audit.Entry{
+			Action: "user.progress_reset", TargetType: "user", TargetID: user.ID,
+			Before: gin.H{"review_logs": deleted},
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset progress"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Progress reset", "deleted_reviews": deleted})
+}
+
+// Update kolom akun + catat audit dalam satu transaksi
+func adminUpdateUser(c *gin.Context, user models.User, action string, updates map[string]interface{}) error {
+	before := toAdminUserView(user)
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&user).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().First(&user, user.ID).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, audit.Entry{
+			Action: action, TargetType: "user", TargetID: user.ID,
+			Before: before, After: toAdminUserView(user),
+		})
+	})
+}
+
+// LOCK USER (admin): semua sesi langsung berakhir
+func AdminLockUser(c *gin.Context) {
+	user, ok := findTargetUser(c)
+	if !ok || rejectSelfTarget(c, user) {
+		return
+	}
+	if user.LockedAt != nil {
+		c.JSON(http.StatusOK, gin.H{"message": "User already locked"})
+		return
+	}
+
+	err := adminUpdateUser(c, user, "user.locked", map[string]interface{}{
+		"locked_at":     time.Now(),
+		"token_version": user.TokenVersion + 1,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lock user"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User locked"})
+}
+
+// UNLOCK USER (admin)
+func AdminUnlockUser(c *gin.Context) {
+	user, ok := findTargetUser(c)
+	if !ok {
+		return
+	}
+	if user.LockedAt == nil {
+		c.JSON(http.StatusOK, gin.H{"message": "User is not locked"})
+		return
+	}
+
+	if err := adminUpdateUser(c, user, "user.unlocked", map[string]interface{}{"locked_at": nil}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock user"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User unlocked"})
+}
+
+// FORCE PASSWORD RESET (admin): sesi lama berakhir, login berikutnya wajib ganti password
+func AdminForcePasswordReset(c *gin.Context) {
+	user, ok := findTargetUser(c)
+	if !ok || rejectSelfTarget(c, user) {
+		return
+	}
+
+	err := adminUpdateUser(c, user, "user.password_reset_forced", map[string]interface{}{
+		"must_reset_password": true,
+		"token_version":       user.TokenVersion + 1,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to force password reset"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset required on next login"})
+}
+
+// SOFT DELETE USER (admin): ikut masa tenggang yang sama dengan hapus akun mandiri
+func AdminDeleteUser(c *gin.Context) {
+	user, ok := findTargetUser(c)
+	if !ok || rejectSelfTarget(c, user) {
+		return
+	}
+	if user.DeletedAt.Valid {
+		c.JSON(http.StatusOK, gin.H{"message": "User already deleted"})
+		return
+	}
+
+	err := adminUpdateUser(c, user, "user.deleted", map[string]interface{}{
+		"deleted_at":    time.Now(),
+		"token_version": user.TokenVersion + 1,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User deleted"})
+}
+
+// RESTORE USER (admin): hanya selama belum di-purge sweeper
+func AdminRestoreUser(c *gin.Context) {
+	user, ok := findTargetUser(c)
+	if !ok {
+		return
+	}
+	if !user.DeletedAt.Valid {
+		c.JSON(http.StatusOK, gin.H{"message": "User is not deleted"})
+		return
+	}
+
+	if err := adminUpdateUser(c, user, "user.restored", map[string]interface{}{"deleted_at": nil}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore user"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User restored"})
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Filter: actor_id, action, target_type, target_id, from, to (RFC3339 / YYYY-MM-DD)
+func auditLogQuery(c *gin.Context) (*gorm.DB, bool) {
+	query := database.DB.Model(&models.AuditLog{})
+
+	if v := c.Query("actor_id"); v != "" {
+		query = query.Where("actor_id = ?", v)
+	}
+	if v := c.Query("action"); v != "" {
+		query = query.Where("action = ?", v)
+	}
+	if v := c.Query("target_type"); v != "" {
+		query = query.Where("target_type = ?", v)
+	}
+	if v := c.Query("target_id"); v != "" {
+		query = query.Where("target_id = ?", v)
+	}
+	for param, op := range map[string]string{"from": ">=", "to": "<"} {
+		v := c.Query(param)
+		if v == "" {
+			continue
+		}
+		t, err := parseAuditTime(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param})
+			return nil, false
+		}
+		query = query.Where("created_at "+op+" ?", t)
+	}
+	return query, true
+}
+
+func parseAuditTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", v)
+}
+
+// LIST AUDIT LOGS (admin), ?format=csv untuk export
+func ListAuditLogs(c *gin.Context) {
+	query, ok := auditLogQuery(c)
+	if !ok {
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		exportAuditCSV(c, query)
+		return
+	}
+
+	page, limit := pagination(c)
+
+	var total int64
+	query.Count(&total)
+
+	var logs []models.AuditLog
+	if err := query.Order("id DESC").Offset((page - 1) * limit).Limit(limit).Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": logs, "page": page, "limit": limit, "total": total})
+}
+
+func exportAuditCSV(c *gin.Context, query *gorm.DB) {
+	rows, err := query.Order("id").Rows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	defer rows.Close()
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", `attachment; filename="audit-logs.csv"`)
+
+	out := csv.NewWriter(c.Writer)
+	out.Write([]string{"id", "created_at", "actor_id", "action", "target_type", "target_id", "before", "after", "ip", "user_agent"})
+	for rows.Next() {
+		var l models.AuditLog
+		if err := database.DB.ScanRows(rows, &l); err != nil {
+			break
+		}
+		out.Write([]string{
+			strconv.Itoa(int(l.ID)), l.CreatedAt.Format(time.RFC3339), strconv.Itoa(int(l.ActorID)),
+			l.Action, l.TargetType, strconv.Itoa(int(l.TargetID)),
+			string(l.Before), string(l.After), l.IP, l.UserAgent,
+		})
+	}
+	out.Flush()
+}
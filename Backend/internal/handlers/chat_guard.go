@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"errors"
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/models"
+	"kotoba-backend/internal/sensei"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const guardExcerptLength = 200
+
+// Awal bulan berjalan (UTC), periode budget
+func monthStart(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+type usageTotals struct {
+	Calls   int64   `json:"calls"`
+	Tokens  int64   `json:"tokens"`
+	CostUSD float64 `json:"cost_usd"`
+}
+
+// Pemakaian bulan ini. userID 0 = seluruh deployment.
+func monthlyUsage(userID uint, since time.Time) (usageTotals, error) {
+	var t usageTotals
+	query := database.DB.Model(&models.LLMUsage{}).
+		Select("COALESCE(SUM(calls), 0) AS calls, COALESCE(SUM(prompt_tokens + completion_tokens), 0) AS tokens, COALESCE(SUM(cost_usd), 0) AS cost_usd").
+		Where("created_at >= ?", since)
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	err := query.Scan(&t).Error
+	return t, err
+}
+
+func recordGuardEvent(userID, conversationID uint, kind, text string) {
+	if runes := []rune(text); len(runes) > guardExcerptLength {
+		text = string(runes[:guardExcerptLength])
+	}
+	event := models.ChatGuardEvent{UserID: userID, ConversationID: conversationID, Kind: kind, Excerpt: text}
+	if err := database.DB.Create(&event).Error; err != nil {
+		log.Printf("[ERROR] Save guard event failed: %v", err)
+	}
+	log.Printf("[WARN] Chat guard %s (user %d)", kind, userID)
+}
+
+// Bersihkan & periksa pesan user sebelum disimpan / dikirim ke LLM.
+// false = response sudah ditulis (JSON, atau event SSE "blocked" untuk stream).
+func guardChatInput(c *gin.Context, message *string, conversationID uint, stream bool) bool {
+	*message = sensei.CleanInput(*message)
+	err := sensei.CheckInput(*message)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, sensei.ErrEmptyInput):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+	case errors.Is(err, sensei.ErrInputTooLong):
+		recordGuardEvent(getUserID(c), conversationID, "too_long", *message)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Message too long", "max_chars": sensei.MaxInputChars()})
+	case errors.Is(err, sensei.ErrPromptInjection):
+		// Tetap dalam karakter; pesan tidak disimpan dan LLM tidak dipanggil
+		recordGuardEvent(getUserID(c), conversationID, "prompt_injection", *message)
+		if stream {
+			c.Header("Content-Type", "text/event-stream")
+			c.SSEvent("blocked", gin.H{"reason": "prompt_injection", "reply": sensei.InjectionReply})
+			return false
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"reply":           sensei.InjectionReply,
+			"conversation_id": conversationID,
+			"blocked":         "prompt_injection",
+		})
+	}
+	return false
+}
+
+// Reservasi budget satu giliran. Perkiraan sudah ditambahkan ke counter bulan ini,
+// lalu dikoreksi dengan pemakaian sebenarnya di recordLLMUsage (atau dilepas dengan release).
+type budgetReservation struct {
+	userID uint
+	period time.Time
+	usage  sensei.Usage
+	cost   float64
+}
+
+var errBudgetExceeded = errors.New("monthly budget exceeded")
+
+// Baris counter bulan ini; baris baru diisi dari llm_usages supaya pemakaian sebelumnya ikut terhitung.
+// userID 0 = seluruh deployment.
+func ensureBudgetCounter(tx *gorm.DB, userID uint, period time.Time) error {
+	return tx.Exec(`
+		INSERT INTO llm_budget_counters (user_id, period_start, tokens, cost_usd)
+		SELECT CAST(? AS bigint), CAST(? AS timestamptz), COALESCE(SUM(prompt_tokens + completion_tokens), 0), COALESCE(SUM(cost_usd), 0)
+		FROM llm_usages
+		WHERE created_at >= ? AND (? = 0 OR user_id = ?)
+		ON CONFLICT (user_id, period_start) DO NOTHING`, userID, period, period, userID, userID).Error
+}
+
+// Reservasi perkiraan pemakaian di budget user & deployment. UPDATE bersyarat membuat cek + tambah
+// atomik, jadi giliran paralel tidak bisa sama-sama lolos. Mengembalikan scope yang terlampaui.
+func reserveBudget(userID uint, next sensei.Usage) (*budgetReservation, string, error) {
+	limits := sensei.GetLimits()
+	res := &budgetReservation{userID: userID, period: monthStart(time.Now()), usage: next, cost: limits.Cost(next)}
+
+	scopes := []struct {
+		name      string
+		userID    uint
+		maxTokens int64
+		maxUSD    float64
+	}{
+		{"user", userID, limits.UserTokens, limits.UserUSD},
+		{"deployment", 0, limits.DeploymentTokens, limits.DeploymentUSD},
+	}
+	exceeded := ""
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, s := range scopes {
+			if err := ensureBudgetCounter(tx, s.userID, res.period); err != nil {
+				return err
+			}
+			// Batas 0 = tanpa batas, tetap dihitung
+			result := tx.Exec(`
+				UPDATE llm_budget_counters SET tokens = tokens + ?, cost_usd = cost_usd + ?
+				WHERE user_id = ? AND period_start = ?
+					AND (? = 0 OR tokens + ? <= ?)
+					AND (? = 0 OR cost_usd + ? <= ?)`,
+				next.Total(), res.cost, s.userID, res.period,
+				s.maxTokens, next.Total(), s.maxTokens,
+				s.maxUSD, res.cost, s.maxUSD)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				exceeded = s.name
+				return errBudgetExceeded
+			}
+		}
+		return nil
+	})
+	if exceeded != "" {
+		return nil, exceeded, nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return res, "", nil
+}
+
+// Ganti perkiraan di counter dengan pemakaian sebenarnya
+func (r *budgetReservation) settle(tx *gorm.DB, actual sensei.Usage) error {
+	tokens := actual.Total() - r.usage.Total()
+	cost := sensei.GetLimits().Cost(actual) - r.cost
+	return tx.Exec(`
+		UPDATE llm_budget_counters SET tokens = tokens + ?, cost_usd = cost_usd + ?
+		WHERE period_start = ? AND user_id IN (?, 0)`, tokens, cost, r.period, r.userID).Error
+}
+
+// Lepas reservasi kalau LLM tidak jadi dipanggil
+func (r *budgetReservation) release() {
+	if err := r.settle(database.DB, sensei.Usage{}); err != nil {
+		log.Printf("[ERROR] Release chat budget failed: %v", err)
+	}
+}
+
+// Reservasi budget bulanan user & deployment sebelum memanggil LLM
+func checkChatBudget(c *gin.Context, userID, conversationID uint, next sensei.Usage) (*budgetReservation, bool) {
+	budget, scope, err := reserveBudget(userID, next)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return nil, false
+	}
+	if scope == "" {
+		return budget, true
+	}
+
+	recordGuardEvent(userID, conversationID, "budget_exceeded", scope)
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error": "Monthly chat budget exceeded",
+		"scope": scope,
+		"reply": "Maaf Ronin, persediaan teh bulan ini sudah habis. Kita lanjutkan latihan di bulan baru.",
+	})
+	return nil, false
+}
+
+// Perkiraan satu giliran chat untuk reservasi budget: pesan + riwayat kasar + balasan maksimal.
+// Dengan tool calling, satu giliran bisa MaxToolRounds putaran + jawaban akhir.
+func estimateChatTurn(message string) sensei.Usage {
+	_, cfg := sensei.Default()
+	calls := 1
+	if cfg.Tools {
+		calls = sensei.MaxToolRounds + 1
+	}
+	prompt := sensei.EstimateTokens(sensei.SystemPrompt) + sensei.EstimateTokens(message)*(chatHistoryLimit/2+1)
+	return sensei.Usage{Calls: calls, PromptTokens: prompt * calls, CompletionTokens: cfg.MaxTokens * calls}
+}
+
+// Simpan pemakaian sebenarnya dan koreksi reservasi budget
+func recordLLMUsage(budget *budgetReservation, conversationID uint, kind string, m *sensei.MeteredProvider) {
+	u := m.Usage()
+	_, cfg := sensei.Default()
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := budget.settle(tx, u); err != nil {
+			return err
+		}
+		if u.Calls == 0 {
+			return nil
+		}
+		row := models.LLMUsage{
+			UserID: budget.userID, ConversationID: conversationID, Kind: kind,
+			Provider: m.Name(), Model: cfg.Model, Calls: u.Calls,
+			PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens,
+			CostUSD: sensei.GetLimits().Cost(u),
+		}
+		return tx.Create(&row).Error
+	})
+	if err != nil {
+		log.Printf("[ERROR] Save LLM usage failed: %v", err)
+	}
+}
+
+// Saring balasan Sensei sebelum dikirim/disimpan
+func filterSenseiReply(conv models.Conversation, reply string) (string, bool) {
+	filtered, changed := sensei.FilterOutput(reply)
+	if changed {
+		recordGuardEvent(conv.UserID, conv.ID, "output_filtered", reply)
+	}
+	return filtered, changed
+}
+
+// MY USAGE: pemakaian Sensei bulan ini + batasnya
+func GetMyChatUsage(c *gin.Context) {
+	since := monthStart(time.Now())
+	used, err := monthlyUsage(getUserID(c), since)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+
+	limits := sensei.GetLimits()
+	resp := gin.H{
+		"period_start":       since,
+		"usage":              used,
+		"monthly_tokens":     limits.UserTokens,
+		"monthly_budget_usd": limits.UserUSD,
+		"remaining_tokens":   nil,
+	}
+	if limits.UserTokens > 0 {
+		remaining := limits.UserTokens - used.Tokens
+		if remaining < 0 {
+			remaining = 0
+		}
+		resp["remaining_tokens"] = remaining
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+type userUsageRow struct {
+	UserID   uint    `json:"user_id"`
+	Username string  `json:"username"`
+	Calls    int64   `json:"calls"`
+	Tokens   int64   `json:"tokens"`
+	CostUSD  float64 `json:"cost_usd"`
+}
+
+// LLM USAGE (admin): ?month=YYYY-MM (default bulan ini), total deployment + per user
+func AdminLLMUsage(c *gin.Context) {
+	since := monthStart(time.Now())
+	if v := c.Query("month"); v != "" {
+		t, err := time.Parse("2006-01", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid month"})
+			return
+		}
+		since = t
+	}
+	until := since.AddDate(0, 1, 0)
+	page, limit := pagination(c)
+
+	var total usageTotals
+	if err := database.DB.Model(&models.LLMUsage{}).Where("created_at >= ? AND created_at < ?", since, until).
+		Select("COALESCE(SUM(calls), 0) AS calls, COALESCE(SUM(prompt_tokens + completion_tokens), 0) AS tokens, COALESCE(SUM(cost_usd), 0) AS cost_usd").
+		Scan(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+
+	var rows []userUsageRow
+	err := database.DB.Raw(`
+		SELECT u.user_id, COALESCE(us.username, '') AS username, u.calls, u.tokens, u.cost_usd
+		FROM (
+			SELECT user_id, SUM(calls) AS calls, SUM(prompt_tokens + completion_tokens) AS tokens, SUM(cost_usd) AS cost_usd
+			FROM llm_usages
+			WHERE created_at >= ? AND created_at < ?
+			GROUP BY user_id
+		) u
+		LEFT JOIN users us ON us.id = u.user_id
+		ORDER BY u.tokens DESC
+		OFFSET ? LIMIT ?`, since, until, (page-1)*limit, limit).Scan(&rows).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"period_start": since,
+		"total":        total,
+		"limits":       sensei.GetLimits(),
+		"data":         rows,
+		"page":         page,
+		"limit":        limit,
+	})
+}
+
+// GUARD EVENTS (admin): ?kind=&user_id=
+func AdminChatGuardEvents(c *gin.Context) {
+	page, limit := pagination(c)
+
+	query := database.DB.Model(&models.ChatGuardEvent{})
+	if v := c.Query("kind"); v != "" {
+		query = query.Where("kind = ?", v)
+	}
+	if v := c.Query("user_id"); v != "" {
+		query = query.Where("user_id = ?", v)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var events []models.ChatGuardEvent
+	if err := query.Order("id DESC").Offset((page - 1) * limit).Limit(limit).Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": events, "page": page, "limit": limit, "total": total})
+}
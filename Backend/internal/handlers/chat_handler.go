@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/models"
+	"kotoba-backend/internal/sensei"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Jumlah pesan terakhir yang dikirim sebagai konteks ke Sensei
+const chatHistoryLimit = 10
+
+// Cari percakapan milik user yang sedang login
+func findConversation(c *gin.Context, id interface{}) (models.Conversation, bool) {
+	var conv models.Conversation
+	if err := database.DB.Where("id = ? AND user_id = ?", id, getUserID(c)).First(&conv).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+		return conv, false
+	}
+	return conv, true
+}
+
+func conversationTitle(message string) string {
+	runes := []rune(message)
+	if len(runes) > 40 {
+		return string(runes[:40]) + "..."
+	}
+	return message
+}
+
+// Riwayat terakhir dari DB, urut kronologis
+func loadChatHistory(conversationID uint, limit int) ([]sensei.Message, error) {
+	var msgs []models.ChatMessage
+	if err := database.DB.Where("conversation_id = ?", conversationID).
+		Order("id DESC").Limit(limit).Find(&msgs).Error; err != nil {
+		return nil, err
+	}
+
+	history := make([]sensei.Message, 0, len(msgs))
+	for i := len(msgs) - 1; i >= 0; i-- {
+		history = append(history, sensei.Message{Role: msgs[i].Role, Content: msgs[i].Content})
+	}
+	return history, nil
+}
+
+// Siapkan satu giliran chat: cari/buat percakapan, ambil riwayat, simpan pesan user.
+// Pesan user dikembalikan supaya bisa dibuang lagi kalau Sensei gagal menjawab.
+func startChatTurn(c *gin.Context, message string, conversationID uint) (models.Conversation, []sensei.Message, models.ChatMessage, bool) {
+	var conv models.Conversation
+	var userMsg models.ChatMessage
+	if conversationID != 0 {
+		var ok bool
+		if conv, ok = findConversation(c, conversationID); !ok {
+			return conv, nil, userMsg, false
+		}
+	} else {
+		conv = models.Conversation{UserID: getUserID(c), Title: conversationTitle(message)}
+		if err := database.DB.Create(&conv).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create conversation"})
+			return conv, nil, userMsg, false
+		}
+	}
+
+	history, err := loadChatHistory(conv.ID, chatHistoryLimit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return conv, nil, userMsg, false
+	}
+
+	userMsg = models.ChatMessage{ConversationID: conv.ID, Role: models.ChatRoleUser, Content: message}
+	if err := database.DB.Create(&userMsg).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save message"})
+		return conv, nil, userMsg, false
+	}
+	return conv, history, userMsg, true
+}
+
+// Sensei gagal/dibatalkan: pesan user dihapus supaya riwayat tidak berisi giliran tanpa balasan
+func dropUserTurn(userMsg models.ChatMessage) {
+	if err := database.DB.Delete(&userMsg).Error; err != nil {
+		log.Printf("[ERROR] Drop unanswered chat message %d failed: %v", userMsg.ID, err)
+	}
+}
+
+// Balasan Sensei + hasil kuis giliran ini disimpan bersama
+func saveSenseiReply(conv models.Conversation, reply string, quiz *quizOutcome) (models.ChatMessage, error) {
+	msg := models.ChatMessage{ConversationID: conv.ID, Role: models.ChatRoleAssistant, Content: reply}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&msg).Error; err != nil {
+			return err
+		}
+		if err := recordQuizOutcome(tx, quiz); err != nil {
+			return err
+		}
+		return tx.Model(&conv).Update("updated_at", time.Now()).Error
+	})
+	if err == nil {
+		quiz.dispatch()
+	}
+	return msg, err
+}
+
+// Konteks satu giliran chat
+type senseiTurn struct {
+	SystemPrompt string
+	Quiz         *quizOutcome
+	Roleplay     gin.H // info sesi role-play, nil kalau chat biasa
+}
+
+// Role-play: Sensei memerankan lawan bicara (tanpa kuis & tool). Selain itu persona biasa.
+func prepareSenseiTurn(conv models.Conversation, message string) senseiTurn {
+	if session, sc, ok := activeRoleplay(conv); ok {
+		return senseiTurn{SystemPrompt: sc.SystemPrompt(), Roleplay: roleplayTurnInfo(session, sc)}
+	}
+	prompt, quiz := senseiSystemPrompt(conv, message)
+	return senseiTurn{SystemPrompt: prompt, Quiz: quiz}
+}
+
+// Chat with Sensei Handler
+// Riwayat dibangun ulang dari DB, kedua giliran (user & sensei) disimpan.
+// Guard: panjang & prompt injection dicek dulu, budget bulanan sebelum LLM dipanggil, balasan disaring.
+func ChatWithSensei(c *gin.Context) {
+	var input struct {
+		Message        string `json:"message" binding:"required"`
+		ConversationID uint   `json:"conversation_id"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if !guardChatInput(c, &input.Message, input.ConversationID, false) {
+		return
+	}
+	budget, ok := checkChatBudget(c, getUserID(c), input.ConversationID, estimateChatTurn(input.Message))
+	if !ok {
+		return
+	}
+
+	conv, history, userMsg, ok := startChatTurn(c, input.Message, input.ConversationID)
+	if !ok {
+		budget.release()
+		return
+	}
+
+	turn := prepareSenseiTurn(conv, input.Message)
+
+	ctx := c.Request.Context()
+	base, _ := sensei.Default()
+	provider := sensei.Meter(base)
+	defer recordLLMUsage(budget, conv.ID, models.UsageChat, provider)
+
+	req := sensei.NewRequest(sensei.BuildMessages(turn.SystemPrompt, history, input.Message))
+
+	var reply string
+	var tools []sensei.ToolResult
+	if turn.Roleplay == nil {
+		req, reply, tools = resolveSenseiTools(ctx, provider, req, conv.UserID)
+	}
+
+	var err error
+	if reply == "" {
+		reply, err = provider.Complete(ctx, req)
+	}
+	if err != nil {
+		// Balasan fallback tidak disimpan & pesan user dibuang supaya tidak mengotori riwayat
+		log.Printf("[ERROR] Chat Service Error: %v", err)
+		dropUserTurn(userMsg)
+		c.JSON(http.StatusOK, gin.H{
+			"reply":           "Maaf Ronin, saya sedang meditasi (Service Unreachable).",
+			"conversation_id": conv.ID,
+			"tool_calls":      tools,
+			"roleplay":        turn.Roleplay,
+		})
+		return
+	}
+
+	reply, filtered := filterSenseiReply(conv, reply)
+	var quiz gin.H
+	if _, err := saveSenseiReply(conv, reply, turn.Quiz); err != nil {
+		log.Printf("[ERROR] Save chat reply failed: %v", err)
+		dropUserTurn(userMsg)
+	} else {
+		quiz = maybeProposeQuiz(conv, turn)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reply":           reply,
+		"filtered":        filtered,
+		"conversation_id": conv.ID,
+		"quiz_result":     turn.Quiz.payload(),
+		"quiz":            quiz,
+		"tool_calls":      tools,
+		"roleplay":        turn.Roleplay,
+	})
+}
+
+// LIST CONVERSATIONS
+func ListConversations(c *gin.Context) {
+	page, limit := pagination(c)
+
+	query := database.DB.Model(&models.Conversation{}).Where("user_id = ?", getUserID(c))
+
+	var total int64
+	query.Count(&total)
+
+	var convs []models.Conversation
+	if err := query.Order("updated_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&convs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": convs, "page": page, "limit": limit, "total": total})
+}
+
+// RENAME CONVERSATION
+func RenameConversation(c *gin.Context) {
+	var input struct {
+		Title string `json:"title" binding:"required,max=100"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	conv, ok := findConversation(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	if err := database.DB.Model(&conv).Update("title", input.Title).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rename"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": conv})
+}
+
+// DELETE CONVERSATION (beserta semua pesan & kuisnya)
+func DeleteConversation(c *gin.Context) {
+	conv, ok := findConversation(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("conversation_id = ?", conv.ID).Delete(&models.ChatMessage{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("conversation_id = ?", conv.ID).Delete(&models.SenseiQuiz{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&conv).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Deleted"})
+}
+
+// LIST MESSAGES: cursor ?before_id= (pesan lebih lama), hasil urut kronologis
+func ListMessages(c *gin.Context) {
+	conv, ok := findConversation(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	_, limit := pagination(c)
+	query := database.DB.Where("conversation_id = ?", conv.ID)
+	if before, err := strconv.Atoi(c.Query("before_id")); err == nil && before > 0 {
+		query = query.Where("id < ?", before)
+	}
+
+	var msgs []models.ChatMessage
+	if err := query.Order("id DESC").Limit(limit).Find(&msgs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+
+	for i, j := 0, len(msgs)-1; i < j; i, j = i+1, j-1 {
+		msgs[i], msgs[j] = msgs[j], msgs[i]
+	}
+
+	resp := gin.H{"data": msgs, "next_before_id": nil}
+	if len(msgs) == limit {
+		resp["next_before_id"] = msgs[0].ID
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"kotoba-backend/internal/models"
+	"kotoba-backend/internal/sensei"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Chat Stream Handler (SSE)
+// Event: start {conversation_id, quiz_result, roleplay} -> (token {delta}... | tool {tool_calls})... -> replace {content}? -> quiz {data, message}? -> done {conversation_id, message_id} | error {error}
+// Ditolak guard: blocked {reason, reply}
+func ChatWithSenseiStream(c *gin.Context) {
+	var input struct {
+		Message        string `json:"message" binding:"required"`
+		ConversationID uint   `json:"conversation_id"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if !guardChatInput(c, &input.Message, input.ConversationID, true) {
+		return
+	}
+	budget, ok := checkChatBudget(c, getUserID(c), input.ConversationID, estimateChatTurn(input.Message))
+	if !ok {
+		return
+	}
+
+	conv, history, userMsg, ok := startChatTurn(c, input.Message, input.ConversationID)
+	if !ok {
+		budget.release()
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	turn := prepareSenseiTurn(conv, input.Message)
+
+	c.SSEvent("start", gin.H{"conversation_id": conv.ID, "quiz_result": turn.Quiz.payload(), "roleplay": turn.Roleplay})
+	c.Writer.Flush()
+
+	// Context request batal saat client disconnect -> request ke LLM ikut berhenti
+	ctx := c.Request.Context()
+	base, _ := sensei.Default()
+	provider := sensei.Meter(base)
+	defer recordLLMUsage(budget, conv.ID, models.UsageChatStream, provider)
+	req := sensei.NewRequest(sensei.BuildMessages(turn.SystemPrompt, history, input.Message))
+
+	// Output guard jalan selama stream: ujung balasan ditahan sampai lolos filter
+	guard := sensei.NewStreamFilter(func(delta string) error {
+		c.SSEvent("token", gin.H{"delta": delta})
+		c.Writer.Flush()
+		return nil
+	})
+
+	// Putaran tool ikut di-stream; hasil tool dikirim sebagai event "tool" per putaran
+	var reply string
+	var err error
+	if turn.Roleplay == nil {
+		reply, err = streamSenseiReply(ctx, provider, req, conv.UserID, guard.Write, func(tools []sensei.ToolResult) {
+			c.SSEvent("tool", gin.H{"tool_calls": tools})
+			c.Writer.Flush()
+		})
+	} else {
+		reply, err = provider.Stream(ctx, req, guard.Write)
+	}
+	if ctx.Err() != nil {
+		log.Printf("[INFO] Chat stream cancelled by client (conversation %d)", conv.ID)
+		dropUserTurn(userMsg)
+		return
+	}
+	if err != nil {
+		log.Printf("[ERROR] Chat Stream Error: %v", err)
+		dropUserTurn(userMsg)
+		c.SSEvent("error", gin.H{"error": "Maaf Ronin, saya sedang meditasi (Service Unreachable)."})
+		return
+	}
+
+	// Bagian yang sudah terkirim lolos filter; kalau balasan lengkap disaring, client mengganti isinya
+	if filtered, changed := filterSenseiReply(conv, reply); changed {
+		reply = filtered
+		c.SSEvent("replace", gin.H{"content": reply})
+	} else {
+		guard.Flush()
+	}
+
+	msg, err := saveSenseiReply(conv, reply, turn.Quiz)
+	if err != nil {
+		// Giliran tidak tersimpan: client tidak boleh menganggapnya masuk riwayat
+		log.Printf("[ERROR] Save chat reply failed: %v", err)
+		dropUserTurn(userMsg)
+		c.SSEvent("error", gin.H{"error": "Failed to save reply"})
+		return
+	}
+	if quiz := maybeProposeQuiz(conv, turn); quiz != nil {
+		c.SSEvent("quiz", quiz)
+	}
+	c.SSEvent("done", gin.H{"conversation_id": conv.ID, "message_id": msg.ID})
+}
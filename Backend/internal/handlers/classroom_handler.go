@@ -0,0 +1,653 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"kotoba-backend/internal/audit"
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/middleware"
+	"kotoba-backend/internal/models"
+	"math/big"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultAtRiskRetention = 50.0 // sama dengan batas "HIGH" decay risk di ml_service
+	defaultInactiveDays    = 7
+)
+
+// Huruf kode kelas, tanpa karakter yang mirip (0/O, 1/I/L)
+const joinCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+func classroomAtRiskRetention() float64 {
+	v, err := strconv.ParseFloat(os.Getenv("CLASSROOM_AT_RISK_RETENTION"), 64)
+	if err != nil || v <= 0 {
+		return defaultAtRiskRetention
+	}
+	return v
+}
+
+func classroomInactiveDays() int {
+	n, err := strconv.Atoi(os.Getenv("CLASSROOM_INACTIVE_DAYS"))
+	if err != nil || n < 1 {
+		return defaultInactiveDays
+	}
+	return n
+}
+
+func newJoinCode() string {
+	b := make([]byte, 8)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(joinCodeAlphabet))))
+		if err != nil {
+			panic(err)
+		}
+		b[i] = joinCodeAlphabet[n.Int64()]
+	}
+	return string(b)
+}
+
+// Kode unik; bentrok sangat jarang, cukup dicoba ulang beberapa kali
+func uniqueJoinCode(tx *gorm.DB) (string, error) {
+	for i := 0; i < 5; i++ {
+		code := newJoinCode()
+		var n int64
+		if err := tx.Model(&models.Classroom{}).Where("join_code = ?", code).Count(&n).Error; err != nil {
+			return "", err
+		}
+		if n == 0 {
+			return code, nil
+		}
+	}
+	return "", gorm.ErrDuplicatedKey
+}
+
+// Kelas milik guru yang login (admin boleh semua kelas). Selain itu 404, supaya guru lain tidak bisa menebak id.
+func ownedClassroom(c *gin.Context) (models.Classroom, bool) {
+	var room models.Classroom
+	query := database.DB.Where("id = ?", c.Param("id"))
+	if c.GetString("role") != middleware.RoleAdmin {
+		query = query.Where("teacher_id = ?", getUserID(c))
+	}
+	if err := query.First(&room).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Classroom not found"})
+		return room, false
+	}
+	return room, true
+}
+
+func isClassroomMember(classroomID, userID uint) bool {
+	var n int64
+	database.DB.Model(&models.ClassroomMember{}).Where("classroom_id = ? AND user_id = ?", classroomID, userID).Count(&n)
+	return n > 0
+}
+
+// --- ASSIGNMENT PROGRESS ---
+
+// Subquery id kata yang termasuk dalam tugas
+func assignmentWordsSQL(a models.ClassroomAssignment) (string, []interface{}) {
+	switch a.Kind {
+	case models.AssignmentLevel:
+		return `SELECT id FROM vocabularies WHERE deleted_at IS NULL AND owner_id IS NULL AND difficulty_level = ?`, []interface{}{a.Level}
+	case models.AssignmentDeck:
+		return `SELECT dc.vocab_id FROM deck_cards dc
+			JOIN decks d ON d.id = dc.deck_id AND d.deleted_at IS NULL
+			JOIN vocabularies v ON v.id = dc.vocab_id AND v.deleted_at IS NULL
+			WHERE dc.deck_id = ?`, []interface{}{a.DeckID}
+	}
+	return `SELECT NULL::bigint WHERE false`, nil
+}
+
+type assignmentProgress struct {
+	AssignmentID uint    `json:"assignment_id"`
+	Total        int64   `json:"total"`
+	Studied      int64   `json:"studied"`
+	Mastered     int64   `json:"mastered"` // jawaban terakhir "Ingat"
+	Percent      float64 `json:"percent"`
+	Completed    bool    `json:"completed"`
+	Overdue      bool    `json:"overdue"`
+}
+
+// Progres tugas untuk sekumpulan user sekaligus (satu query per tugas)
+func assignmentProgressFor(a models.ClassroomAssignment, userIDs []uint, now time.Time) (map[uint]assignmentProgress, error) {
+	words, args := assignmentWordsSQL(a)
+
+	var total int64
+	if err := database.DB.Raw(`SELECT COUNT(*) FROM (`+words+`) w`, args...).Scan(&total).Error; err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		UserID   uint
+		Studied  int64
+		Mastered int64
+	}
+	if len(userIDs) > 0 {
+		err := database.DB.Raw(`
+			SELECT s.user_id, COUNT(*) AS studied, COUNT(*) FILTER (WHERE s.result = 2) AS mastered
+			FROM user_vocab_states s
+			WHERE s.user_id IN ? AND s.vocab_id IN (`+words+`)
+			GROUP BY s.user_id`, append([]interface{}{userIDs}, args...)...).Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	out := make(map[uint]assignmentProgress, len(userIDs))
+	for _, id := range userIDs {
+		out[id] = finishProgress(a, assignmentProgress{AssignmentID: a.ID, Total: total}, now)
+	}
+	for _, r := range rows {
+		out[r.UserID] = finishProgress(a, assignmentProgress{AssignmentID: a.ID, Total: total, Studied: r.Studied, Mastered: r.Mastered}, now)
+	}
+	return out, nil
+}
+
+func finishProgress(a models.ClassroomAssignment, p assignmentProgress, now time.Time) assignmentProgress {
+	if p.Total > 0 {
+		p.Percent = float64(p.Mastered) / float64(p.Total) * 100
+	}
+	p.Completed = p.Total > 0 && p.Mastered >= p.Total
+	p.Overdue = !p.Completed && a.DueAt != nil && now.After(*a.DueAt)
+	return p
+}
+
+// --- STUDENT ---
+
+type classroomSummary struct {
+	models.Classroom
+	TeacherName string `json:"teacher_name"`
+	Students    int64  `json:"students"`
+	Role        string `json:"role"` // teacher | student
+}
+
+// LIST MY CLASSROOMS: kelas yang diajar + kelas yang diikuti
+func ListMyClassrooms(c *gin.Context) {
+	userID := getUserID(c)
+	var rows []classroomSummary
+	err := database.DB.Raw(`
+		SELECT c.*, u.username AS teacher_name,
+			(SELECT COUNT(*) FROM classroom_members m WHERE m.classroom_id = c.id) AS students,
+			CASE WHEN c.teacher_id = ? THEN 'teacher' ELSE 'student' END AS role
+		FROM classrooms c
+		JOIN users u ON u.id = c.teacher_id
+		WHERE c.teacher_id = ? OR c.id IN (SELECT classroom_id FROM classroom_members WHERE user_id = ?)
+		ORDER BY c.created_at DESC`, userID, userID, userID).Scan(&rows).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	// Kode kelas hanya untuk guru
+	for i := range rows {
+		if rows[i].Role != "teacher" {
+			rows[i].JoinCode = ""
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rows})
+}
+
+// JOIN CLASSROOM: {"code": "ABCD2345"}
+func JoinClassroom(c *gin.Context) {
+	var input struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	userID := getUserID(c)
+	var room models.Classroom
+	if err := database.DB.Where("join_code = ?", strings.ToUpper(strings.TrimSpace(input.Code))).First(&room).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Classroom not found"})
+		return
+	}
+	if room.TeacherID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You teach this classroom"})
+		return
+	}
+
+	member := models.ClassroomMember{ClassroomID: room.ID, UserID: userID}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&member)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected > 0 {
+			if err := audit.Record(tx, c, audit.Entry{
+				Action: "classroom.joined", TargetType: "classroom", TargetID: room.ID, After: member,
+			}); err != nil {
+				return err
+			}
+		}
+		return tx.Where("classroom_id = ? AND user_id = ?", room.ID, userID).First(&member).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"id": room.ID, "name": room.Name, "description": room.Description}, "member": member})
+}
+
+// LEAVE CLASSROOM
+func LeaveClassroom(c *gin.Context) {
+	if err := database.DB.Where("classroom_id = ? AND user_id = ?", c.Param("id"), getUserID(c)).
+		Delete(&models.ClassroomMember{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Left classroom"})
+}
+
+// GET CLASSROOM: tugas + progres user sendiri (siswa) atau ringkasan (guru)
+func GetClassroom(c *gin.Context) {
+	userID := getUserID(c)
+	var room models.Classroom
+	if err := database.DB.First(&room, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Classroom not found"})
+		return
+	}
+	isTeacher := room.TeacherID == userID
+	if !isTeacher && !isClassroomMember(room.ID, userID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Classroom not found"})
+		return
+	}
+	if !isTeacher {
+		room.JoinCode = ""
+	}
+
+	var assignments []models.ClassroomAssignment
+	if err := database.DB.Where("classroom_id = ?", room.ID).Order("due_at NULLS LAST, id").Find(&assignments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+
+	type assignmentView struct {
+		models.ClassroomAssignment
+		Progress *assignmentProgress `json:"progress,omitempty"`
+	}
+	now := time.Now()
+	views := make([]assignmentView, 0, len(assignments))
+	for _, a := range assignments {
+		view := assignmentView{ClassroomAssignment: a}
+		if !isTeacher {
+			progress, err := assignmentProgressFor(a, []uint{userID}, now)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+				return
+			}
+			p := progress[userID]
+			view.Progress = &p
+		}
+		views = append(views, view)
+	}
+
+	var teacher socialUser
+	database.DB.Model(&models.User{}).Select("id, username, avatar").Where("id = ?", room.TeacherID).Take(&teacher)
+	c.JSON(http.StatusOK, gin.H{"data": room, "teacher": teacher, "assignments": views})
+}
+
+// --- TEACHER ---
+
+type classroomInput struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description" binding:"max=500"`
+}
+
+func CreateClassroom(c *gin.Context) {
+	var input classroomInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	room := models.Classroom{TeacherID: getUserID(c), Name: input.Name, Description: input.Description}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		code, err := uniqueJoinCode(tx)
+		if err != nil {
+			return err
+		}
+		room.JoinCode = code
+		if err := tx.Create(&room).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, audit.Entry{
+			Action: "classroom.created", TargetType: "classroom", TargetID: room.ID, After: room,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": room})
+}
+
+func UpdateClassroom(c *gin.Context) {
+	var input classroomInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	room, ok := ownedClassroom(c)
+	if !ok {
+		return
+	}
+	room.Name, room.Description = input.Name, input.Description
+	if err := database.DB.Save(&room).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": room})
+}
+
+// REGENERATE JOIN CODE: kode lama tidak berlaku lagi, siswa yang sudah masuk tetap
+func RegenerateJoinCode(c *gin.Context) {
+	room, ok := ownedClassroom(c)
+	if !ok {
+		return
+	}
+	code, err := uniqueJoinCode(database.DB)
+	if err == nil {
+		err = database.DB.Model(&room).Update("join_code", code).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": room})
+}
+
+func DeleteClassroom(c *gin.Context) {
+	room, ok := ownedClassroom(c)
+	if !ok {
+		return
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("classroom_id = ?", room.ID).Delete(&models.ClassroomAssignment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("classroom_id = ?", room.ID).Delete(&models.ClassroomMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&room).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, audit.Entry{
+			Action: "classroom.deleted", TargetType: "classroom", TargetID: room.ID, Before: room,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Classroom deleted"})
+}
+
+func RemoveClassroomStudent(c *gin.Context) {
+	room, ok := ownedClassroom(c)
+	if !ok {
+		return
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("classroom_id = ? AND user_id = ?", room.ID, c.Param("user_id")).Delete(&models.ClassroomMember{})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		return audit.Record(tx, c, audit.Entry{
+			Action: "classroom.student_removed", TargetType: "classroom", TargetID: room.ID,
+			Before: gin.H{"user_id": c.Param("user_id")},
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Student removed"})
+}
+
+type assignmentInput struct {
+	Title  string     `json:"title" binding:"required,max=100"`
+	Kind   string     `json:"kind" binding:"required,oneof=level deck"`
+	Level  int        `json:"level"`
+	DeckID uint       `json:"deck_id"`
+	DueAt  *time.Time `json:"due_at"`
+}
+
+// Deck yang boleh ditugaskan: milik guru sendiri atau sudah dipublikasi
+func (in assignmentInput) apply(a *models.ClassroomAssignment, teacherID uint) bool {
+	a.Title, a.Kind, a.DueAt = in.Title, in.Kind, in.DueAt
+	a.Level, a.DeckID = 0, nil
+	switch in.Kind {
+	case models.AssignmentLevel:
+		if in.Level < 1 || in.Level > 5 {
+			return false
+		}
+		a.Level = in.Level
+	case models.AssignmentDeck:
+		var n int64
+		database.DB.Model(&models.Deck{}).Where("id = ? AND (owner_id = ? OR published)", in.DeckID, teacherID).Count(&n)
+		if n == 0 {
+			return false
+		}
+		deckID := in.DeckID
+		a.DeckID = &deckID
+	}
+	return true
+}
+
+func CreateAssignment(c *gin.Context) {
+	var input assignmentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	room, ok := ownedClassroom(c)
+	if !ok {
+		return
+	}
+	a := models.ClassroomAssignment{ClassroomID: room.ID}
+	if !input.apply(&a, room.TeacherID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := database.DB.Create(&a).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": a})
+}
+
+func UpdateAssignment(c *gin.Context) {
+	var input assignmentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	room, ok := ownedClassroom(c)
+	if !ok {
+		return
+	}
+	var a models.ClassroomAssignment
+	if err := database.DB.Where("id = ? AND classroom_id = ?", c.Param("assignment_id"), room.ID).First(&a).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+		return
+	}
+	if !input.apply(&a, room.TeacherID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if err := database.DB.Save(&a).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": a})
+}
+
+func DeleteAssignment(c *gin.Context) {
+	room, ok := ownedClassroom(c)
+	if !ok {
+		return
+	}
+	if err := database.DB.Where("id = ? AND classroom_id = ?", c.Param("assignment_id"), room.ID).
+		Delete(&models.ClassroomAssignment{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Assignment deleted"})
+}
+
+// --- DASHBOARD ---
+
+// Ringkasan per siswa: review, ujian dan prediksi retensi terakhir (user_predictions)
+const classroomStudentsQuery = `
+	SELECT m.user_id, u.username, u.avatar, m.joined_at,
+		COALESCE(rv.reviews, 0) AS reviews, COALESCE(rv.reviews_recent, 0) AS reviews_recent,
+		COALESCE(rv.ingat_recent, 0) AS ingat_recent, rv.last_review_at,
+		COALESCE(ex.exams, 0) AS exams, COALESCE(ex.exams_passed, 0) AS exams_passed,
+		ex.avg_score, ex.best_score, ex.last_exam_at,
+		p.retention_rate, p.decay_risk, p.due_now, p.computed_at AS predicted_at
+	FROM classroom_members m
+	JOIN users u ON u.id = m.user_id AND u.deleted_at IS NULL
+	LEFT JOIN LATERAL (
+		SELECT COUNT(*) AS reviews,
+			COUNT(*) FILTER (WHERE reviewed_at >= ?) AS reviews_recent,
+			COUNT(*) FILTER (WHERE reviewed_at >= ? AND result = 2) AS ingat_recent,
+			MAX(reviewed_at) AS last_review_at
+		FROM review_logs WHERE user_id = m.user_id
+	) rv ON true
+	LEFT JOIN LATERAL (
+		SELECT COUNT(*) AS exams, COUNT(*) FILTER (WHERE passed) AS exams_passed,
+			AVG(score * 100.0 / NULLIF(total, 0)) AS avg_score,
+			MAX(score * 100.0 / NULLIF(total, 0)) AS best_score,
+			MAX(taken_at) AS last_exam_at
+		FROM exam_results WHERE user_id = m.user_id
+	) ex ON true
+	LEFT JOIN user_predictions p ON p.user_id = m.user_id
+	WHERE m.classroom_id = ?
+	ORDER BY u.username`
+
+type classroomStudent struct {
+	UserID        uint       `json:"user_id"`
+	Username      string     `json:"username"`
+	Avatar        string     `json:"avatar"`
+	JoinedAt      time.Time  `json:"joined_at"`
+	Reviews       int64      `json:"reviews"`
+	ReviewsRecent int64      `json:"reviews_recent"` // dalam CLASSROOM_INACTIVE_DAYS terakhir
+	IngatRecent   int64      `json:"ingat_recent"`
+	LastReviewAt  *time.Time `json:"last_review_at"`
+	Exams         int64      `json:"exams"`
+	ExamsPassed   int64      `json:"exams_passed"`
+	AvgScore      *float64   `json:"avg_score"`  // persen
+	BestScore     *float64   `json:"best_score"` // persen
+	LastExamAt    *time.Time `json:"last_exam_at"`
+	RetentionRate *float64   `json:"retention_rate"`
+	DecayRisk     *string    `json:"decay_risk"`
+	DueNow        *int       `json:"due_now"`
+	PredictedAt   *time.Time `json:"predicted_at"`
+
+	Assignments []assignmentProgress `json:"assignments" gorm:"-"`
+	AtRisk      bool                 `json:"at_risk" gorm:"-"`
+	RiskReasons []string             `json:"risk_reasons" gorm:"-"`
+}
+
+type assignmentSummary struct {
+	models.ClassroomAssignment
+	TotalWords     int64   `json:"total_words"`
+	AvgPercent     float64 `json:"avg_percent"`
+	CompletedCount int     `json:"completed_count"`
+	OverdueCount   int     `json:"overdue_count"`
+}
+
+// TEACHER DASHBOARD: hanya siswa di kelas milik guru ini
+func GetClassroomDashboard(c *gin.Context) {
+	room, ok := ownedClassroom(c)
+	if !ok {
+		return
+	}
+	now := time.Now()
+	inactiveDays := classroomInactiveDays()
+	since := now.AddDate(0, 0, -inactiveDays)
+
+	var students []classroomStudent
+	if err := database.DB.Raw(classroomStudentsQuery, since, since, room.ID).Scan(&students).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	ids := make([]uint, 0, len(students))
+	for _, s := range students {
+		ids = append(ids, s.UserID)
+	}
+
+	var assignments []models.ClassroomAssignment
+	if err := database.DB.Where("classroom_id = ?", room.ID).Order("due_at NULLS LAST, id").Find(&assignments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+
+	summaries := make([]assignmentSummary, 0, len(assignments))
+	for _, a := range assignments {
+		progress, err := assignmentProgressFor(a, ids, now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+			return
+		}
+		sum := assignmentSummary{ClassroomAssignment: a}
+		for i := range students {
+			p := progress[students[i].UserID]
+			sum.TotalWords = p.Total
+			sum.AvgPercent += p.Percent
+			if p.Completed {
+				sum.CompletedCount++
+			}
+			if p.Overdue {
+				sum.OverdueCount++
+			}
+			students[i].Assignments = append(students[i].Assignments, p)
+		}
+		if len(students) > 0 {
+			sum.AvgPercent /= float64(len(students))
+		}
+		summaries = append(summaries, sum)
+	}
+
+	threshold := classroomAtRiskRetention()
+	var atRisk []uint
+	for i := range students {
+		s := &students[i]
+		s.RiskReasons = []string{}
+		if s.RetentionRate != nil && *s.RetentionRate < threshold {
+			s.RiskReasons = append(s.RiskReasons, "low_retention")
+		}
+		if s.ReviewsRecent == 0 {
+			s.RiskReasons = append(s.RiskReasons, "inactive")
+		}
+		for _, p := range s.Assignments {
+			if p.Overdue {
+				s.RiskReasons = append(s.RiskReasons, "overdue_assignment")
+				break
+			}
+		}
+		if s.Assignments == nil {
+			s.Assignments = []assignmentProgress{}
+		}
+		s.AtRisk = len(s.RiskReasons) > 0
+		if s.AtRisk {
+			atRisk = append(atRisk, s.UserID)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"classroom":   room,
+		"assignments": summaries,
+		"students":    students,
+		"at_risk":     atRisk,
+		"thresholds":  gin.H{"retention": threshold, "inactive_days": inactiveDays},
+	})
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+	"kotoba-backend/internal/audit"
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type vocabularyInput struct {
+	Kanji           string   `json:"kanji"`
+	Kana            string   `json:"kana" binding:"required"`
+	Romaji          string   `json:"romaji" binding:"required"`
+	Meaning         string   `json:"meaning" binding:"required"`
+	ExampleSentence string   `json:"example_sentence"`
+	DifficultyLevel int      `json:"difficulty_level" binding:"required,gte=1,lte=5"`
+	Tags            []string `json:"tags"` // slug tag; nil = tag tidak diubah
+}
+
+func (in vocabularyInput) apply(v *models.Vocabulary) {
+	v.Kanji = in.Kanji
+	v.Kana = in.Kana
+	v.Romaji = in.Romaji
+	v.Meaning = in.Meaning
+	v.ExampleSentence = in.ExampleSentence
+	v.DifficultyLevel = in.DifficultyLevel
+}
+
+// Set tag kata (kalau dikirim) lalu muat ulang untuk response
+func saveVocabularyTags(tx *gorm.DB, vocab *models.Vocabulary, slugs []string) error {
+	if slugs == nil {
+		return nil
+	}
+	if err := setVocabularyTags(tx, vocab.ID, slugs); err != nil {
+		return err
+	}
+	return tx.Model(vocab).Association("Tags").Find(&vocab.Tags)
+}
+
+// CREATE VOCABULARY
+func CreateVocabulary(c *gin.Context) {
+	var input vocabularyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	var vocab models.Vocabulary
+	input.apply(&vocab)
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&vocab).Error; err != nil {
+			return err
+		}
+		if err := saveVocabularyTags(tx, &vocab, input.Tags); err != nil {
+			return err
+		}
+		return audit.Record(tx, c, audit.Entry{
+			Action: "vocabulary.created", TargetType: "vocabulary", TargetID: vocab.ID, After: vocab,
+		})
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown tag"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": vocab})
+}
+
+// UPDATE VOCABULARY
+func UpdateVocabulary(c *gin.Context) {
+	var input vocabularyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	var vocab models.Vocabulary
+	if err := database.DB.Where("owner_id IS NULL").First(&vocab, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Vocabulary not found"})
+		return
+	}
+
+	before := vocab
+	input.apply(&vocab)
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&vocab).Error; err != nil {
+			return err
+		}
+		if err := saveVocabularyTags(tx, &vocab, input.Tags); err != nil {
+			return err
+		}
+		return audit.Record(tx, c, audit.Entry{
+			Action: "vocabulary.updated", TargetType: "vocabulary", TargetID: vocab.ID, Before: before, After: vocab,
+		})
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown tag"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": vocab})
+}
+
+// DELETE VOCABULARY (soft delete, review_logs tetap utuh)
+func DeleteVocabulary(c *gin.Context) {
+	var vocab models.Vocabulary
+	if err := database.DB.Where("owner_id IS NULL").First(&vocab, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Vocabulary not found"})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&vocab).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, audit.Entry{
+			Action: "vocabulary.deleted", TargetType: "vocabulary", TargetID: vocab.ID, Before: vocab,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Deleted"})
+}
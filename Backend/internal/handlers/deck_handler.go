@@ -0,0 +1,661 @@
+package handlers
+
+import (
+	"errors"
+	"kotoba-backend/internal/audit"
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Batas kartu per deck
+const maxDeckCards = 1000
+
+var (
+	errDeckFull     = errors.New("deck is full")
+	errCardNotFound = errors.New("card not found")
+)
+
+// Deck yang boleh dibuka user: miliknya, sudah dipublikasi, sudah di-subscribe
+// (subscriber tetap bisa belajar walaupun penulis membatalkan publikasi), atau ditugaskan di kelasnya.
+// Argumen: userID tiga kali.
+const readableDeckSQL = `(decks.owner_id = ? OR decks.published
+	OR EXISTS (SELECT 1 FROM deck_subscriptions s WHERE s.deck_id = decks.id AND s.user_id = ?)
+	OR EXISTS (
+		SELECT 1 FROM classroom_assignments a
+		JOIN classroom_members m ON m.classroom_id = a.classroom_id
+		WHERE a.deck_id = decks.id AND m.user_id = ?
+	))`
+
+// ReadableVocabSQL: kata yang boleh dipakai user (table = nama/alias tabel vocabularies):
+// kosakata umum, kartu privat sendiri, atau kartu privat di deck yang bisa dibuka.
+// Argumen: userID empat kali.
+func ReadableVocabSQL(table string) string {
+	return `(` + table + `.owner_id IS NULL OR ` + table + `.owner_id = ? OR ` + table + `.id IN (
+		SELECT dc.vocab_id FROM deck_cards dc
+		JOIN decks ON decks.id = dc.deck_id AND decks.deleted_at IS NULL
+		WHERE ` + readableDeckSQL + `))`
+}
+
+// Kata yang boleh direview / diantrikan user; selain itu errVocabNotFound
+func findReadableVocab(userID, vocabID uint) (models.Vocabulary, error) {
+	var vocab models.Vocabulary
+	err := database.DB.Where("vocabularies.id = ? AND "+ReadableVocabSQL("vocabularies"), vocabID, userID, userID, userID, userID).
+		First(&vocab).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return vocab, errVocabNotFound
+	}
+	return vocab, err
+}
+
+// Deck yang boleh dibuka user; selain itu 404
+func findReadableDeck(c *gin.Context, userID uint) (models.Deck, bool) {
+	var deck models.Deck
+	err := database.DB.Where("id = ? AND "+readableDeckSQL, c.Param("id"), userID, userID, userID).First(&deck).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Deck not found"})
+		return deck, false
+	}
+	return deck, true
+}
+
+// Deck milik user yang login; selain itu 404
+func findOwnedDeck(c *gin.Context, userID uint) (models.Deck, bool) {
+	var deck models.Deck
+	if err := database.DB.Where("id = ? AND owner_id = ?", c.Param("id"), userID).First(&deck).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Deck not found"})
+		return deck, false
+	}
+	return deck, true
+}
+
+// Naikkan versi deck dan catat perubahan, supaya subscriber tahu ada yang baru
+func bumpDeck(tx *gorm.DB, deck *models.Deck, action string, vocabIDs ...uint) error {
+	if len(vocabIDs) == 0 {
+		return nil
+	}
+	if err := tx.Model(deck).UpdateColumn("version", gorm.Expr("version + 1")).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(deck).Select("version").Take(deck).Error; err != nil {
+		return err
+	}
+	changes := make([]models.DeckChange, 0, len(vocabIDs))
+	for _, id := range vocabIDs {
+		changes = append(changes, models.DeckChange{DeckID: deck.ID, Version: deck.Version, Action: action, VocabID: id})
+	}
+	return tx.Create(&changes).Error
+}
+
+// Kartu privat yang sudah tidak ada di deck mana pun di-soft delete (review_logs tetap utuh)
+func pruneOrphanCards(tx *gorm.DB, ownerID uint) error {
+	return tx.Exec(`
+		UPDATE vocabularies SET deleted_at = ?
+		WHERE owner_id = ? AND deleted_at IS NULL
+			AND id NOT IN (
+				SELECT dc.vocab_id FROM deck_cards dc
+				JOIN decks d ON d.id = dc.deck_id AND d.deleted_at IS NULL
+			)`, time.Now(), ownerID).Error
+}
+
+// Tambah kata ke deck. Hanya kosakata umum atau kartu privat milik penulis deck.
+func addDeckCards(tx *gorm.DB, deck *models.Deck, vocabIDs []uint) ([]uint, error) {
+	var valid []uint
+	if err := tx.Model(&models.Vocabulary{}).
+		Where("id IN ? AND (owner_id IS NULL OR owner_id = ?)", vocabIDs, deck.OwnerID).
+		Pluck("id", &valid).Error; err != nil {
+		return nil, err
+	}
+	if len(valid) != len(uniqueIDs(vocabIDs)) {
+		return nil, errCardNotFound
+	}
+
+	// Kunci baris deck supaya tambah kartu paralel tidak melewati batas / bentrok posisi
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.Deck{}, deck.ID).Error; err != nil {
+		return nil, err
+	}
+	var count int64
+	if err := tx.Model(&models.DeckCard{}).Where("deck_id = ?", deck.ID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	var maxPos int
+	if err := tx.Model(&models.DeckCard{}).Where("deck_id = ?", deck.ID).Select("COALESCE(MAX(position), 0)").Scan(&maxPos).Error; err != nil {
+		return nil, err
+	}
+
+	var added []uint
+	for _, id := range valid {
+		if count >= maxDeckCards {
+			return nil, errDeckFull
+		}
+		maxPos++
+		card := models.DeckCard{DeckID: deck.ID, VocabID: id, Position: maxPos}
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&card)
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected > 0 {
+			added = append(added, id)
+			count++
+		}
+	}
+	return added, bumpDeck(tx, deck, models.DeckCardAdded, added...)
+}
+
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	out := ids[:0:0]
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+func deckCardError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errCardNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Vocabulary not found"})
+	case errors.Is(err, errDeckFull):
+		c.JSON(http.StatusConflict, gin.H{"error": "Deck is full", "max": maxDeckCards})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save"})
+	}
+}
+
+// --- DECKS ---
+
+type deckSummary struct {
+	models.Deck
+	OwnerName     string `json:"owner_name"`
+	CardCount     int64  `json:"card_count"`
+	Subscribers   int64  `json:"subscribers"`
+	Role          string `json:"role,omitempty"` // owner | subscriber
+	SyncedVersion *int   `json:"synced_version,omitempty"`
+	HasUpdates    bool   `json:"has_updates"`
+}
+
+const deckSummaryColumns = `d.*, u.username AS owner_name,
+	(SELECT COUNT(*) FROM deck_cards dc WHERE dc.deck_id = d.id) AS card_count,
+	(SELECT COUNT(*) FROM deck_subscriptions s WHERE s.deck_id = d.id) AS subscribers`
+
+// LIST MY DECKS: deck buatan sendiri + yang di-subscribe
+func ListMyDecks(c *gin.Context) {
+	userID := getUserID(c)
+	var rows []deckSummary
+	err := database.DB.Raw(`
+		SELECT `+deckSummaryColumns+`,
+			CASE WHEN d.owner_id = ? THEN 'owner' ELSE 'subscriber' END AS role,
+			ms.synced_version,
+			(ms.synced_version IS NOT NULL AND ms.synced_version < d.version) AS has_updates
+		FROM decks d
+		JOIN users u ON u.id = d.owner_id
+		LEFT JOIN deck_subscriptions ms ON ms.deck_id = d.id AND ms.user_id = ?
+		WHERE d.deleted_at IS NULL AND (d.owner_id = ? OR ms.id IS NOT NULL)
+		ORDER BY d.updated_at DESC`, userID, userID, userID).Scan(&rows).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rows})
+}
+
+// BROWSE PUBLIC DECKS: ?q=&page=&limit=
+func ListPublicDecks(c *gin.Context) {
+	page, limit := pagination(c)
+	where := `d.deleted_at IS NULL AND d.published`
+	var args []interface{}
+	if q := c.Query("q"); q != "" {
+		where += ` AND (d.title ILIKE ? OR d.description ILIKE ?)`
+		args = append(args, "%"+q+"%", "%"+q+"%")
+	}
+
+	var total int64
+	if err := database.DB.Raw(`SELECT COUNT(*) FROM decks d WHERE `+where, args...).Scan(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	var rows []deckSummary
+	err := database.DB.Raw(`
+		SELECT `+deckSummaryColumns+`
+		FROM decks d
+		JOIN users u ON u.id = d.owner_id AND u.deleted_at IS NULL
+		WHERE `+where+`
+		ORDER BY subscribers DESC, d.published_at DESC
+		OFFSET ? LIMIT ?`, append(args, (page-1)*limit, limit)...).Scan(&rows).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rows, "page": page, "limit": limit, "total": total})
+}
+
+type deckInput struct {
+	Title       string `json:"title" binding:"required,max=100"`
+	Description string `json:"description" binding:"max=1000"`
+}
+
+func CreateDeck(c *gin.Context) {
+	var input struct {
+		deckInput
+		VocabIDs []uint `json:"vocab_ids"` // opsional: langsung isi dengan kosakata yang ada
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	deck := models.Deck{OwnerID: getUserID(c), Title: input.Title, Description: input.Description}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&deck).Error; err != nil {
+			return err
+		}
+		if len(input.VocabIDs) > 0 {
+			if _, err := addDeckCards(tx, &deck, input.VocabIDs); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		deckCardError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": deck})
+}
+
+func UpdateDeck(c *gin.Context) {
+	var input deckInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	deck, ok := findOwnedDeck(c, getUserID(c))
+	if !ok {
+		return
+	}
+	deck.Title, deck.Description = input.Title, input.Description
+	if err := database.DB.Save(&deck).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": deck})
+}
+
+// DELETE DECK (soft delete). Progres subscriber tetap ada di review_logs.
+func DeleteDeck(c *gin.Context) {
+	userID := getUserID(c)
+	deck, ok := findOwnedDeck(c, userID)
+	if !ok {
+		return
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&deck).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("deck_id = ?", deck.ID).Delete(&models.DeckSubscription{}).Error; err != nil {
+			return err
+		}
+		// Tugas kelas yang memakai deck ini ikut dihapus
+		if err := tx.Where("deck_id = ?", deck.ID).Delete(&models.ClassroomAssignment{}).Error; err != nil {
+			return err
+		}
+		return pruneOrphanCards(tx, userID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Deleted"})
+}
+
+func setDeckPublished(c *gin.Context, published bool) {
+	deck, ok := findOwnedDeck(c, getUserID(c))
+	if !ok {
+		return
+	}
+	if deck.Published != published {
+		deck.Published = published
+		if published {
+			now := time.Now()
+			deck.PublishedAt = &now
+		}
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Save(&deck).Error; err != nil {
+				return err
+			}
+			action := "deck.unpublished"
+			if published {
+				action = "deck.published"
+			}
+			return audit.Record(tx, c, audit.Entry{Action: action, TargetType: "deck", TargetID: deck.ID, After: deck})
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save"})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": deck})
+}
+
+func PublishDeck(c *gin.Context)   { setDeckPublished(c, true) }
+func UnpublishDeck(c *gin.Context) { setDeckPublished(c, false) }
+
+// --- CARDS ---
+
+type deckCardRow struct {
+	VocabID         uint       `json:"vocab_id"`
+	Position        int        `json:"position"`
+	Kanji           string     `json:"kanji"`
+	Kana            string     `json:"kana"`
+	Romaji          string     `json:"romaji"`
+	Meaning         string     `json:"meaning"`
+	ExampleSentence string     `json:"example_sentence"`
+	DifficultyLevel int        `json:"difficulty_level"`
+	Private         bool       `json:"private"`
+	LastResult      *int       `json:"last_result"` // nil = belum pernah direview
+	LastReviewedAt  *time.Time `json:"last_reviewed_at"`
+	DueAt           *time.Time `json:"due_at"`
+}
+
+// Kartu deck + status review user. Argumen: userID, deckID.
+const deckCardsQuery = latestReviewCTE + `
+	SELECT dc.vocab_id, dc.position, v.kanji, v.kana, v.romaji, v.meaning, v.example_sentence, v.difficulty_level,
+		v.owner_id IS NOT NULL AS private,
+		l.result AS last_result, l.reviewed_at AS last_reviewed_at, l.due_at
+	FROM deck_cards dc
+	JOIN vocabularies v ON v.id = dc.vocab_id AND v.deleted_at IS NULL
+	LEFT JOIN latest l ON l.vocab_id = dc.vocab_id
+	WHERE dc.deck_id = ?`
+
+// GET DECK: detail + kartu (paginated) beserta progres user
+func GetDeck(c *gin.Context) {
+	userID := getUserID(c)
+	deck, ok := findReadableDeck(c, userID)
+	if !ok {
+		return
+	}
+	page, limit := pagination(c)
+
+	var total int64
+	database.DB.Model(&models.DeckCard{}).Where("deck_id = ?", deck.ID).Count(&total)
+
+	var cards []deckCardRow
+	if err := database.DB.Raw(deckCardsQuery+` ORDER BY dc.position, dc.id OFFSET ? LIMIT ?`,
+		userID, deck.ID, (page-1)*limit, limit).Scan(&cards).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+
+	var sub models.DeckSubscription
+	subscribed := database.DB.Where("deck_id = ? AND user_id = ?", deck.ID, userID).First(&sub).Error == nil
+	c.JSON(http.StatusOK, gin.H{
+		"data": deck, "cards": cards, "page": page, "limit": limit, "total": total,
+		"is_owner": deck.OwnerID == userID, "subscribed": subscribed,
+		"has_updates": subscribed && sub.SyncedVersion < deck.Version,
+	})
+}
+
+// STUDY DECK: kartu yang jatuh tempo dulu, lalu kartu baru. ?limit=20
+func StudyDeck(c *gin.Context) {
+	userID := getUserID(c)
+	deck, ok := findReadableDeck(c, userID)
+	if !ok {
+		return
+	}
+	_, limit := pagination(c)
+
+	var cards []deckCardRow
+	if err := database.DB.Raw(deckCardsQuery+` AND (l.due_at IS NULL OR l.due_at <= ?)
+		ORDER BY l.due_at NULLS LAST, dc.position
+		LIMIT ?`, userID, deck.ID, time.Now(), limit).Scan(&cards).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": cards, "deck_id": deck.ID})
+}
+
+// ADD CARDS: {"vocab_ids": [1, 2, 3]} dari kosakata umum atau kartu privat sendiri
+func AddDeckCards(c *gin.Context) {
+	var input struct {
+		VocabIDs []uint `json:"vocab_ids" binding:"required,min=1,max=200"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	deck, ok := findOwnedDeck(c, getUserID(c))
+	if !ok {
+		return
+	}
+
+	var added []uint
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		added, err = addDeckCards(tx, &deck, input.VocabIDs)
+		return err
+	})
+	if err != nil {
+		deckCardError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"added": added, "version": deck.Version})
+}
+
+// CREATE PRIVATE CARD: kartu baru milik penulis, langsung masuk ke deck
+func CreatePrivateCard(c *gin.Context) {
+	var input vocabularyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	userID := getUserID(c)
+	deck, ok := findOwnedDeck(c, userID)
+	if !ok {
+		return
+	}
+
+	vocab := models.Vocabulary{OwnerID: &userID}
+	input.apply(&vocab)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&vocab).Error; err != nil {
+			return err
+		}
+		if err := saveVocabularyTags(tx, &vocab, input.Tags); err != nil {
+			return err
+		}
+		_, err := addDeckCards(tx, &deck, []uint{vocab.ID})
+		return err
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown tag"})
+		return
+	}
+	if err != nil {
+		deckCardError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": vocab, "version": deck.Version})
+}
+
+// UPDATE PRIVATE CARD: id kartu tetap, jadi progres subscriber tidak hilang.
+// Semua deck penulis yang berisi kartu ini ikut naik versi.
+func UpdatePrivateCard(c *gin.Context) {
+	var input vocabularyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	userID := getUserID(c)
+	deck, ok := findOwnedDeck(c, userID)
+	if !ok {
+		return
+	}
+
+	var vocab models.Vocabulary
+	if err := database.DB.Where("id = ? AND owner_id = ? AND id IN (SELECT vocab_id FROM deck_cards WHERE deck_id = ?)",
+		c.Param("vocab_id"), userID, deck.ID).First(&vocab).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Card not found"})
+		return
+	}
+	input.apply(&vocab)
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&vocab).Error; err != nil {
+			return err
+		}
+		if err := saveVocabularyTags(tx, &vocab, input.Tags); err != nil {
+			return err
+		}
+		var decks []models.Deck
+		if err := tx.Where("owner_id = ? AND id IN (SELECT deck_id FROM deck_cards WHERE vocab_id = ?)", userID, vocab.ID).
+			Find(&decks).Error; err != nil {
+			return err
+		}
+		for i := range decks {
+			if err := bumpDeck(tx, &decks[i], models.DeckCardUpdated, vocab.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown tag"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": vocab})
+}
+
+// REMOVE CARD dari deck. Kartu privat yang tidak dipakai deck lain di-soft delete.
+func RemoveDeckCard(c *gin.Context) {
+	userID := getUserID(c)
+	deck, ok := findOwnedDeck(c, userID)
+	if !ok {
+		return
+	}
+	var card models.DeckCard
+	if err := database.DB.Where("deck_id = ? AND vocab_id = ?", deck.ID, c.Param("vocab_id")).First(&card).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Card not found"})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&card).Error; err != nil {
+			return err
+		}
+		if err := bumpDeck(tx, &deck, models.DeckCardRemoved, card.VocabID); err != nil {
+			return err
+		}
+		return pruneOrphanCards(tx, userID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Removed", "version": deck.Version})
+}
+
+// --- SUBSCRIPTIONS ---
+
+func SubscribeDeck(c *gin.Context) {
+	userID := getUserID(c)
+	var deck models.Deck
+	if err := database.DB.Where("id = ? AND published AND owner_id <> ?", c.Param("id"), userID).First(&deck).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Deck not found"})
+		return
+	}
+	sub := models.DeckSubscription{DeckID: deck.ID, UserID: userID, SyncedVersion: deck.Version}
+	if err := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&sub).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Subscribed", "data": deck})
+}
+
+// UNSUBSCRIBE: progres review tetap disimpan, bisa subscribe lagi kapan saja
+func UnsubscribeDeck(c *gin.Context) {
+	if err := database.DB.Where("deck_id = ? AND user_id = ?", c.Param("id"), getUserID(c)).
+		Delete(&models.DeckSubscription{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Unsubscribed"})
+}
+
+type deckChangeRow struct {
+	models.DeckChange
+	Kanji   string `json:"kanji"`
+	Kana    string `json:"kana"`
+	Meaning string `json:"meaning"`
+}
+
+// Baris perubahan per halaman; satu versi tidak pernah dipotong di tengah
+const deckChangePageSize = 500
+
+// DECK CHANGES: perubahan sejak versi terakhir yang dilihat subscriber (atau ?since=N),
+// lalu tandai sinkron sampai versi terakhir yang dikirim. has_more -> ulangi dengan ?since=next_since.
+func GetDeckChanges(c *gin.Context) {
+	userID := getUserID(c)
+	deck, ok := findReadableDeck(c, userID)
+	if !ok {
+		return
+	}
+
+	var sub models.DeckSubscription
+	subscribed := database.DB.Where("deck_id = ? AND user_id = ?", deck.ID, userID).First(&sub).Error == nil
+	since := sub.SyncedVersion
+	if v, err := strconv.Atoi(c.Query("since")); err == nil && v >= 0 {
+		since = v
+	}
+
+	changesQuery := func() *gorm.DB {
+		return database.DB.Table("deck_changes dc").Select("dc.*, v.kanji, v.kana, v.meaning").
+			Joins("LEFT JOIN vocabularies v ON v.id = dc.vocab_id").
+			Where("dc.deck_id = ?", deck.ID).Order("dc.version, dc.id")
+	}
+
+	var changes []deckChangeRow
+	if err := changesQuery().Where("dc.version > ?", since).Limit(deckChangePageSize + 1).Scan(&changes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+
+	next := deck.Version
+	hasMore := len(changes) > deckChangePageSize
+	if hasMore {
+		// Versi terakhir yang belum lengkap ditunda ke halaman berikutnya
+		cut := changes[deckChangePageSize].Version
+		n := deckChangePageSize
+		for n > 0 && changes[n-1].Version == cut {
+			n--
+		}
+		// Satu versi lebih besar dari satu halaman: dikirim utuh
+		if n > 0 {
+			changes = changes[:n]
+		} else if err := changesQuery().Where("dc.version = ?", cut).Scan(&changes).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+			return
+		}
+		next = changes[len(changes)-1].Version
+	}
+
+	if subscribed && sub.SyncedVersion < next {
+		database.DB.Model(&sub).Update("synced_version", next)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": changes, "since": since, "version": deck.Version,
+		"next_since": next, "has_more": hasMore,
+	})
+}
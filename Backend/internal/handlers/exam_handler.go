@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"kotoba-backend/internal/achievement"
+	"kotoba-backend/internal/audit"
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/models"
+	"kotoba-backend/internal/progression"
+	"kotoba-backend/internal/streak"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Batas lulus, sama dengan yang dipakai di frontend
+var examPassPercent = map[string]int{
+	models.ExamShiren: 70,
+	models.ExamKaiwa:  80,
+}
+
+// Hasil ujian harus dikirim dalam batas ini sejak soal dikeluarkan
+const examSessionTTL = 2 * time.Hour
+
+// Ujian kaiwa: soal ada di frontend (data/kaiwa.ts), 10 soal + 5 bonus
+const kaiwaMaxQuestions = 15
+
+var errExamSubmitted = errors.New("exam already submitted")
+
+// NewExamSession: dibuat saat soal dikeluarkan. Soal shiren disimpan untuk dinilai di server.
+func NewExamSession(userID uint, examType string, vocabIDs []uint) (models.ExamSession, error) {
+	session := models.ExamSession{UserID: userID, ExamType: examType, Size: kaiwaMaxQuestions}
+	if examType == models.ExamShiren {
+		session.Size = len(vocabIDs)
+		session.VocabIDs = toJSONText(vocabIDs)
+	}
+	err := database.DB.Create(&session).Error
+	return session, err
+}
+
+// START EXAM SESSION: {"exam_type": "kaiwa"}. Ujian shiren mendapat sesi dari /api/exam-questions.
+func StartExamSession(c *gin.Context) {
+	var input struct {
+		ExamType string `json:"exam_type" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || input.ExamType != models.ExamKaiwa {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	session, err := NewExamSession(getUserID(c), input.ExamType, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": session})
+}
+
+// Nilai ujian shiren di server: jawaban romaji harus sama (tanpa beda huruf besar/kecil).
+// Soal yang tidak dijawab dihitung salah.
+func gradeShirenExam(session models.ExamSession, answers map[uint]string) (int, error) {
+	var ids []uint
+	if err := json.Unmarshal([]byte(session.VocabIDs), &ids); err != nil {
+		return 0, err
+	}
+	var vocabs []models.Vocabulary
+	if err := database.DB.Unscoped().Where("id IN ?", ids).Find(&vocabs).Error; err != nil {
+		return 0, err
+	}
+	score := 0
+	for _, v := range vocabs {
+		if answer, ok := answers[v.ID]; ok && strings.EqualFold(strings.TrimSpace(answer), strings.TrimSpace(v.Romaji)) {
+			score++
+		}
+	}
+	return score, nil
+}
+
+// SUBMIT EXAM RESULT: {"session_id": 1, "answers": [{"vocab_id": 3, "answer": "neko"}]} untuk shiren,
+// {"session_id": 2, "score": 8, "total": 10} untuk kaiwa. Lama ujian dihitung dari waktu sesi dibuat.
+func SubmitExamResult(c *gin.Context) {
+	var input struct {
+		SessionID uint `json:"session_id" binding:"required"`
+		Answers   []struct {
+			VocabID uint   `json:"vocab_id" binding:"required"`
+			Answer  string `json:"answer" binding:"max=100"`
+		} `json:"answers" binding:"max=1000,dive"`
+		Score int `json:"score" binding:"gte=0"`
+		Total int `json:"total" binding:"gte=0"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	userID := getUserID(c)
+	var session models.ExamSession
+	if err := database.DB.Where("id = ? AND user_id = ?", input.SessionID, userID).First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Exam session not found"})
+		return
+	}
+	if session.SubmittedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Exam already submitted"})
+		return
+	}
+	elapsed := time.Since(session.CreatedAt)
+	if elapsed > examSessionTTL {
+		c.JSON(http.StatusGone, gin.H{"error": "Exam session expired"})
+		return
+	}
+
+	result := models.ExamResult{UserID: userID, ExamType: session.ExamType, SessionID: &session.ID}
+	switch session.ExamType {
+	case models.ExamShiren:
+		answers := make(map[uint]string, len(input.Answers))
+		for _, a := range input.Answers {
+			answers[a.VocabID] = a.Answer
+		}
+		score, err := gradeShirenExam(session, answers)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+			return
+		}
+		result.Score, result.Total = score, session.Size
+	default:
+		if input.Total == 0 || input.Total > session.Size || input.Score > input.Total {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "max_total": session.Size})
+			return
+		}
+		result.Score, result.Total = input.Score, input.Total
+	}
+	result.Passed = result.Total > 0 && result.Score*100 >= result.Total*examPassPercent[result.ExamType]
+
+	gained := 0
+	var streakUpdate *streak.Update
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Klaim sesi; request paralel untuk sesi yang sama hanya satu yang lolos
+		claim := tx.Model(&models.ExamSession{}).Where("id = ? AND submitted_at IS NULL", session.ID).
+			Update("submitted_at", time.Now())
+		if claim.Error != nil {
+			return claim.Error
+		}
+		if claim.RowsAffected == 0 {
+			return errExamSubmitted
+		}
+		if err := tx.Create(&result).Error; err != nil {
+			return err
+		}
+		var err error
+		gained, err = awardXPKey(tx, result.UserID, progression.SourceExam, fmt.Sprintf("session:%d", session.ID),
+			progression.ExamXP(result.Score, result.Passed))
+		if err != nil {
+			return err
+		}
+		if streakUpdate, err = recordStudyActivity(tx, result.UserID, 0, streak.CapSeconds(int(elapsed.Seconds()), result.Total)); err != nil {
+			return err
+		}
+		if result.Passed {
+			payload := gin.H{"exam_result_id": result.ID, "exam_type": result.ExamType, "score": result.Score, "total": result.Total}
+			if err := recordActivity(tx, result.UserID, models.ActivityExamPassed, payload); err != nil {
+				return err
+			}
+		}
+		return audit.Record(tx, c, audit.Entry{
+			Action: "exam.submitted", TargetType: "exam_result", TargetID: result.ID, After: result,
+		})
+	})
+	if errors.Is(err, errExamSubmitted) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Exam already submitted"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save"})
+		return
+	}
+
+	unlocked := dispatchAchievementEvents(result.UserID, studyEvents(streakUpdate, achievement.EventExam)...)
+
+	resp := gin.H{"data": result, "exp_gained": gained, "streak": streakUpdate, "achievements": unlocked}
+	if progress, err := XPProgress(result.UserID); err == nil {
+		resp["xp"] = progress
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// GET EXAM HISTORY
+func GetExamHistory(c *gin.Context) {
+	var results []models.ExamResult
+	if err := database.DB.Where("user_id = ?", getUserID(c)).Order("taken_at DESC").Find(&results).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": results})
+}
@@ -0,0 +1,390 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/jobs"
+	"kotoba-backend/internal/models"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultExportSyncMaxReviews = 5000
+
+// Maksimal 2 export async berjalan bersamaan
+var exportSlots = make(chan struct{}, 2)
+
+type exportReviewRow struct {
+	ID              uint      `json:"id"`
+	VocabID         uint      `json:"vocab_id"`
+	Kanji           string    `json:"kanji"`
+	Kana            string    `json:"kana"`
+	Romaji          string    `json:"romaji"`
+	Meaning         string    `json:"meaning"`
+	DifficultyLevel int       `json:"difficulty_level"`
+	Result          int       `json:"result"`
+	ReviewedAt      time.Time `json:"reviewed_at"`
+}
+
+func exportSyncMaxReviews() int64 {
+	n, err := strconv.ParseInt(os.Getenv("EXPORT_SYNC_MAX_REVIEWS"), 10, 64)
+	if err != nil || n < 0 {
+		return defaultExportSyncMaxReviews
+	}
+	return n
+}
+
+// EXPORT DATA USER
+// Akun kecil langsung dapat file zip, akun besar (atau ?async=1) dibuatkan job.
+func ExportMyData(c *gin.Context) {
+	userID := getUserID(c)
+
+	var reviewCount int64
+	database.DB.Model(&models.ReviewLog{}).Where("user_id = ?", userID).Count(&reviewCount)
+
+	if c.Query("async") == "" && reviewCount <= exportSyncMaxReviews() {
+		// Dibangun dulu di memori (akun kecil), supaya bagian yang gagal tidak menghasilkan zip setengah jadi dengan 200
+		var buf bytes.Buffer
+		if err := writeExportArchive(&buf, userID); err != nil {
+			log.Printf("[ERROR] Export user %d failed: %v", userID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Export failed"})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="kaiwa-rift-export-%d.zip"`, userID))
+		c.Data(http.StatusOK, "application/zip", buf.Bytes())
+		return
+	}
+
+	// Pakai job yang masih berjalan kalau ada
+	var job models.ExportJob
+	err := database.DB.Where("user_id = ? AND status IN ?", userID, []string{models.ExportPending, models.ExportRunning}).
+		First(&job).Error
+	if err != nil {
+		job = models.ExportJob{UserID: userID, Status: models.ExportPending}
+		if err := database.DB.Create(&job).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start export"})
+			return
+		}
+		go runExportJob(job)
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"data":       job,
+		"status_url": fmt.Sprintf("/api/me/export/jobs/%d", job.ID),
+	})
+}
+
+// GET EXPORT JOB STATUS
+func GetExportJob(c *gin.Context) {
+	job, ok := findExportJob(c)
+	if !ok {
+		return
+	}
+
+	resp := gin.H{"data": job}
+	if job.Status == models.ExportDone {
+		resp["download_url"] = fmt.Sprintf("/api/me/export/jobs/%d/download", job.ID)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// DOWNLOAD EXPORT RESULT
+func DownloadExport(c *gin.Context) {
+	job, ok := findExportJob(c)
+	if !ok {
+		return
+	}
+
+	if job.Status != models.ExportDone {
+		c.JSON(http.StatusConflict, gin.H{"error": "Export not ready", "status": job.Status})
+		return
+	}
+
+	c.FileAttachment(job.FilePath, fmt.Sprintf("kaiwa-rift-export-%d.zip", job.UserID))
+}
+
+func findExportJob(c *gin.Context) (models.ExportJob, bool) {
+	var job models.ExportJob
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), getUserID(c)).First(&job).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Export not found"})
+		return job, false
+	}
+	return job, true
+}
+
+func runExportJob(job models.ExportJob) {
+	exportSlots <- struct{}{}
+	defer func() { <-exportSlots }()
+
+	// Hanya job yang masih pending yang dijalankan (bisa sudah ditandai gagal saat antre)
+	claim := database.DB.Model(&job).Where("status = ?", models.ExportPending).Update("status", models.ExportRunning)
+	if claim.Error != nil || claim.RowsAffected == 0 {
+		return
+	}
+
+	path := filepath.Join(jobs.ExportDir(), fmt.Sprintf("export-%d-%d.zip", job.UserID, job.ID))
+	err := writeExportFile(path, job.UserID)
+
+	now := time.Now()
+	if err != nil {
+		log.Printf("[ERROR] Export job %d failed: %v", job.ID, err)
+		os.Remove(path)
+		database.DB.Model(&job).Where("status = ?", models.ExportRunning).Updates(map[string]interface{}{
+			"status": models.ExportFailed, "error": "Export failed", "completed_at": now,
+		})
+		return
+	}
+
+	expires := now.Add(jobs.ExportRetention)
+	done := database.DB.Model(&job).Where("status = ?", models.ExportRunning).Updates(map[string]interface{}{
+		"status": models.ExportDone, "file_path": path, "completed_at": now, "expires_at": expires,
+	})
+	if done.Error != nil || done.RowsAffected == 0 {
+		// Job sudah tidak running (mis. dihapus bersama akunnya): file tidak dipakai
+		os.Remove(path)
+	}
+}
+
+func writeExportFile(path string, userID uint) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := writeExportArchive(f, userID); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Isi arsip: profile, stats, review_logs (+vocab), riwayat ujian, chat Sensei, laporan role-play, ledger XP, achievement. JSON & CSV.
+func writeExportArchive(w io.Writer, userID uint) error {
+	zw := zip.NewWriter(w)
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return err
+	}
+	profile := gin.H{
+		"id":         user.ID,
+		"username":   user.Username,
+		"email":      user.Email,
+		"role":       user.Role,
+		"avatar":     user.Avatar,
+		"created_at": user.CreatedAt,
+	}
+	if err := writeJSONEntry(zw, "profile.json", profile); err != nil {
+		return err
+	}
+
+	stats, err := calculateUserStats(userID)
+	if err != nil {
+		return err
+	}
+	if err := writeJSONEntry(zw, "stats.json", stats); err != nil {
+		return err
+	}
+
+	if err := writeReviewEntries(zw, userID); err != nil {
+		return err
+	}
+
+	var exams []models.ExamResult
+	if err := database.DB.Where("user_id = ?", userID).Order("taken_at").Find(&exams).Error; err != nil {
+		return err
+	}
+	if err := writeJSONEntry(zw, "exams.json", exams); err != nil {
+		return err
+	}
+	examRows := make([][]string, 0, len(exams))
+	for _, e := range exams {
+		examRows = append(examRows, []string{
+			strconv.Itoa(int(e.ID)), e.ExamType, strconv.Itoa(e.Score), strconv.Itoa(e.Total),
+			strconv.FormatBool(e.Passed), e.TakenAt.Format(time.RFC3339),
+		})
+	}
+	if err := writeCSVEntry(zw, "exams.csv", []string{"id", "exam_type", "score", "total", "passed", "taken_at"}, examRows); err != nil {
+		return err
+	}
+
+	if err := writeChatEntries(zw, userID); err != nil {
+		return err
+	}
+
+	var roleplays []models.RoleplayReport
+	if err := database.DB.Where("user_id = ?", userID).Order("id").Find(&roleplays).Error; err != nil {
+		return err
+	}
+	if err := writeJSONEntry(zw, "roleplay_reports.json", roleplays); err != nil {
+		return err
+	}
+
+	var xpEvents []models.XPEvent
+	if err := database.DB.Where("user_id = ?", userID).Order("id").Find(&xpEvents).Error; err != nil {
+		return err
+	}
+	if err := writeJSONEntry(zw, "xp_events.json", xpEvents); err != nil {
+		return err
+	}
+
+	var achievements []models.UserAchievement
+	if err := database.DB.Where("user_id = ?", userID).Order("unlocked_at").Find(&achievements).Error; err != nil {
+		return err
+	}
+	if err := writeJSONEntry(zw, "achievements.json", achievements); err != nil {
+		return err
+	}
+
+	var activity []models.ActivityEvent
+	if err := database.DB.Where("user_id = ?", userID).Order("id").Find(&activity).Error; err != nil {
+		return err
+	}
+	if err := writeJSONEntry(zw, "activity.json", activity); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+const exportReviewQuery = `
+	SELECT r.id, r.vocab_id, v.kanji, v.kana, v.romaji, v.meaning, v.difficulty_level, r.result, r.reviewed_at
+	FROM review_logs r
+	LEFT JOIN vocabularies v ON v.id = r.vocab_id
+	WHERE r.user_id = ?
+	ORDER BY r.reviewed_at, r.id`
+
+// review_logs bisa besar, jadi di-stream baris per baris
+func writeReviewEntries(zw *zip.Writer, userID uint) error {
+	jw, err := zw.Create("reviews.json")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(jw, "["); err != nil {
+		return err
+	}
+	first := true
+	err = eachReviewRow(userID, func(row exportReviewRow) error {
+		if !first {
+			if _, err := io.WriteString(jw, ","); err != nil {
+				return err
+			}
+		}
+		first = false
+		b, err := json.Marshal(row)
+		if err != nil {
+			return err
+		}
+		_, err = jw.Write(b)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(jw, "]"); err != nil {
+		return err
+	}
+
+	cw, err := zw.Create("reviews.csv")
+	if err != nil {
+		return err
+	}
+	out := csv.NewWriter(cw)
+	out.Write([]string{"id", "vocab_id", "kanji", "kana", "romaji", "meaning", "difficulty_level", "result", "reviewed_at"})
+	err = eachReviewRow(userID, func(row exportReviewRow) error {
+		return out.Write([]string{
+			strconv.Itoa(int(row.ID)), strconv.Itoa(int(row.VocabID)), row.Kanji, row.Kana, row.Romaji, row.Meaning,
+			strconv.Itoa(row.DifficultyLevel), strconv.Itoa(row.Result), row.ReviewedAt.Format(time.RFC3339),
+		})
+	})
+	if err != nil {
+		return err
+	}
+	out.Flush()
+	return out.Error()
+}
+
+func eachReviewRow(userID uint, fn func(exportReviewRow) error) error {
+	rows, err := database.DB.Raw(exportReviewQuery, userID).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row exportReviewRow
+		if err := database.DB.ScanRows(rows, &row); err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+type exportConversation struct {
+	models.Conversation
+	Messages []models.ChatMessage `json:"messages"`
+}
+
+func writeChatEntries(zw *zip.Writer, userID uint) error {
+	var convs []models.Conversation
+	if err := database.DB.Where("user_id = ?", userID).Order("id").Find(&convs).Error; err != nil {
+		return err
+	}
+
+	out := make([]exportConversation, 0, len(convs))
+	rows := [][]string{}
+	for _, conv := range convs {
+		var msgs []models.ChatMessage
+		if err := database.DB.Where("conversation_id = ?", conv.ID).Order("id").Find(&msgs).Error; err != nil {
+			return err
+		}
+		out = append(out, exportConversation{Conversation: conv, Messages: msgs})
+		for _, m := range msgs {
+			rows = append(rows, []string{
+				strconv.Itoa(int(conv.ID)), conv.Title, strconv.Itoa(int(m.ID)), m.Role, m.Content, m.CreatedAt.Format(time.RFC3339),
+			})
+		}
+	}
+
+	if err := writeJSONEntry(zw, "chats.json", out); err != nil {
+		return err
+	}
+	return writeCSVEntry(zw, "chats.csv", []string{"conversation_id", "title", "message_id", "role", "content", "created_at"}, rows)
+}
+
+func writeJSONEntry(zw *zip.Writer, name string, v interface{}) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func writeCSVEntry(zw *zip.Writer, name string, header []string, rows [][]string) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	out := csv.NewWriter(w)
+	out.Write(header)
+	out.WriteAll(rows)
+	return out.Error()
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/jobs"
+	"kotoba-backend/internal/league"
+	"kotoba-backend/internal/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Nama pengganti untuk user yang menyembunyikan diri dari papan peringkat
+const hiddenLeaderboardName = "Ronin tanpa nama"
+
+// Scope papan peringkat: filter SQL tambahan atas e.user_id. ok=false = scope tidak bisa dipakai user ini.
+type leaderboardScope func(c *gin.Context, userID uint) (clause string, args []interface{}, ok bool)
+
+var leaderboardScopes = map[string]leaderboardScope{
+	"global": func(c *gin.Context, userID uint) (string, []interface{}, bool) {
+		return "", nil, true
+	},
+	"friends": func(c *gin.Context, userID uint) (string, []interface{}, bool) {
+		return ` AND (e.user_id = ? OR e.user_id IN (` + friendIDsSQL + `))`,
+			[]interface{}{userID, userID, userID, userID}, true
+	},
+	// ?scope=classroom&classroom_id=N: siswa + guru kelas itu, hanya untuk anggota/guru kelas
+	"classroom": func(c *gin.Context, userID uint) (string, []interface{}, bool) {
+		var room models.Classroom
+		if err := database.DB.First(&room, c.Query("classroom_id")).Error; err != nil ||
+			(room.TeacherID != userID && !isClassroomMember(room.ID, userID)) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Classroom not found"})
+			return "", nil, false
+		}
+		return ` AND (e.user_id = ? OR e.user_id IN (SELECT user_id FROM classroom_members WHERE classroom_id = ?))`,
+			[]interface{}{room.TeacherID, room.ID}, true
+	},
+}
+
+const leaderboardRankedCTE = `
+	WITH ranked AS (
+		SELECT e.user_id, e.score, e.computed_at, RANK() OVER (ORDER BY e.score DESC) AS rank
+		FROM leaderboard_entries e
+		WHERE e.board = ?
+			AND NOT EXISTS (SELECT 1 FROM privacy_settings p WHERE p.user_id = e.user_id AND p.hide_from_leaderboards)
+			AND EXISTS (SELECT 1 FROM users u WHERE u.id = e.user_id AND u.deleted_at IS NULL AND u.locked_at IS NULL)`
+
+type leaderboardRow struct {
+	Rank     int    `json:"rank"`
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	Avatar   string `json:"avatar"`
+	Score    int64  `json:"score"`
+}
+
+// GET LEADERBOARD: /api/leaderboards/:board?scope=global|friends|classroom (weekly_xp | mastered | streak)
+func GetLeaderboard(c *gin.Context) {
+	board := c.Param("board")
+	if !league.ValidBoard(board) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Leaderboard not found"})
+		return
+	}
+	scopeName := c.DefaultQuery("scope", "global")
+	scope, ok := leaderboardScopes[scopeName]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scope"})
+		return
+	}
+
+	userID := getUserID(c)
+	filter, filterArgs, ok := scope(c, userID)
+	if !ok {
+		return
+	}
+	page, limit := pagination(c)
+
+	cte := leaderboardRankedCTE + filter + `
+	)`
+	args := append([]interface{}{board}, filterArgs...)
+
+	var total int64
+	if err := database.DB.Raw(cte+` SELECT COUNT(*) FROM ranked`, args...).Scan(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+
+	var rows []leaderboardRow
+	err := database.DB.Raw(cte+`
+		SELECT r.rank, r.user_id, u.username, u.avatar, r.score
+		FROM ranked r
+		JOIN users u ON u.id = r.user_id
+		ORDER BY r.rank, r.user_id
+		OFFSET ? LIMIT ?`, append(args, (page-1)*limit, limit)...).Scan(&rows).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+
+	var me []leaderboardRow
+	database.DB.Raw(cte+`
+		SELECT r.rank, r.user_id, u.username, u.avatar, r.score
+		FROM ranked r
+		JOIN users u ON u.id = r.user_id
+		WHERE r.user_id = ?`, append(args, userID)...).Scan(&me)
+
+	var computedAt *time.Time
+	var times []time.Time
+	database.DB.Model(&models.LeaderboardEntry{}).Where("board = ?", board).Limit(1).Pluck("computed_at", &times)
+	if len(times) > 0 {
+		computedAt = &times[0]
+	}
+
+	resp := gin.H{
+		"board": board, "scope": scopeName, "computed_at": computedAt,
+		"data": rows, "page": page, "limit": limit, "total": total, "me": nil,
+	}
+	if len(me) > 0 {
+		resp["me"] = me[0]
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+type leagueRow struct {
+	Rank     int    `json:"rank"`
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	Avatar   string `json:"avatar"`
+	XP       int64  `json:"xp"`
+	Zone     string `json:"zone"` // promotion | demotion | ""
+}
+
+// JOIN LEAGUE: masuk grup liga minggu ini (user aktif juga didaftarkan otomatis oleh job rollover)
+func JoinCurrentLeague(c *gin.Context) {
+	userID := getUserID(c)
+	if _, err := jobs.JoinLeague(userID, league.WeekKey(time.Now())); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	GetLeague(c)
+}
+
+// GET LEAGUE: grup liga minggu ini + hasil minggu lalu. Belum bergabung -> joined=false.
+func GetLeague(c *gin.Context) {
+	userID := getUserID(c)
+	now := time.Now()
+	week := league.WeekKey(now)
+
+	var last models.LeagueMembership
+	lastErr := database.DB.Where("user_id = ? AND closed_at IS NOT NULL", userID).Order("week DESC").First(&last).Error
+
+	var m models.LeagueMembership
+	err := database.DB.Where("week = ? AND user_id = ?", week, userID).First(&m).Error
+	if err == gorm.ErrRecordNotFound {
+		resp := gin.H{"week": week, "ends_at": league.WeekStart(now).AddDate(0, 0, 7), "joined": false, "data": []leagueRow{}, "last_week": nil}
+		if lastErr == nil {
+			resp["last_week"] = last
+		}
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+
+	standings, err := jobs.GroupStandings(database.DB, week, m.Tier, m.GroupNo)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+
+	ids := make([]uint, 0, len(standings))
+	for _, s := range standings {
+		ids = append(ids, s.UserID)
+	}
+	var users []struct {
+		ID       uint
+		Username string
+		Avatar   string
+		Hidden   bool
+	}
+	database.DB.Raw(`
+		SELECT u.id, u.username, u.avatar, COALESCE(p.hide_from_leaderboards, false) AS hidden
+		FROM users u LEFT JOIN privacy_settings p ON p.user_id = u.id
+		WHERE u.id IN ?`, ids).Scan(&users)
+	names := make(map[uint][2]string, len(users))
+	for _, u := range users {
+		if u.Hidden && u.ID != userID {
+			names[u.ID] = [2]string{hiddenLeaderboardName, "default"}
+		} else {
+			names[u.ID] = [2]string{u.Username, u.Avatar}
+		}
+	}
+
+	rows := make([]leagueRow, 0, len(standings))
+	for i, s := range standings {
+		row := leagueRow{Rank: i + 1, UserID: s.UserID, Username: names[s.UserID][0], Avatar: names[s.UserID][1], XP: s.XP}
+		switch outcome, _ := league.Outcome(i+1, len(standings), m.Tier, s.XP); outcome {
+		case league.OutcomePromoted:
+			row.Zone = "promotion"
+		case league.OutcomeDemoted:
+			row.Zone = "demotion"
+		}
+		rows = append(rows, row)
+	}
+
+	resp := gin.H{
+		"week":      week,
+		"ends_at":   league.WeekStart(now).AddDate(0, 0, 7),
+		"joined":    true,
+		"tier":      m.Tier,
+		"tier_name": league.TierName(m.Tier),
+		"group":     m.GroupNo,
+		"data":      rows,
+		"last_week": nil,
+	}
+	if lastErr == nil {
+		resp["last_week"] = last
+	}
+	c.JSON(http.StatusOK, resp)
+}
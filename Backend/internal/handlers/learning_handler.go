@@ -1,8 +1,14 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"kotoba-backend/internal/achievement"
 	"kotoba-backend/internal/database"
 	"kotoba-backend/internal/models"
+	"kotoba-backend/internal/progression"
+	"kotoba-backend/internal/streak"
+	"log"
 	"net/http"
 	"time"
 
@@ -11,9 +17,17 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
 )
 
 func getUserID(c *gin.Context) uint {
+	// Sudah di-set oleh AuthMiddleware
+	if id, ok := c.Get("user_id"); ok {
+		if uid, ok := id.(uint); ok {
+			return uid
+		}
+	}
+
 	authHeader := c.GetHeader("Authorization")
 	if authHeader == "" {
 		return 0
@@ -48,42 +62,92 @@ func GetFlashcards(c *gin.Context) {
 		database.DB.Raw(`
 			SELECT v.* FROM vocabularies v
 			LEFT JOIN review_logs r ON v.id = r.vocab_id AND r.user_id = ?
-			WHERE v.difficulty_level IN (1, 2) 
+			WHERE v.difficulty_level IN (1, 2) AND v.owner_id IS NULL
 			ORDER BY r.result ASC NULLS FIRST, RANDOM() LIMIT 10`, userID).Scan(&vocabs)
 	} else {
 		// MODE FOKUS N5: Prioritaskn yang belum hafal
 		database.DB.Raw(`
 			SELECT v.* FROM vocabularies v
 			LEFT JOIN review_logs r ON v.id = r.vocab_id AND r.user_id = ?
-			WHERE v.difficulty_level = 1
+			WHERE v.difficulty_level = 1 AND v.owner_id IS NULL
 			ORDER BY r.result ASC NULLS FIRST, RANDOM() LIMIT 10`, userID).Scan(&vocabs)
 	}
 
 	c.JSON(http.StatusOK, gin.H{"data": vocabs, "mastery_mode": mastery >= 90})
 }
 
-// SUBMIT REVIEW (nyimpen hasil belajar)
+// XP review: satu kata hanya memberi XP sekali per hari (UTC), jadi retry/replay tidak dobel
+func reviewXPKey(review models.ReviewLog) string {
+	return fmt.Sprintf("%d:%s", review.VocabID, review.ReviewedAt.UTC().Format("2006-01-02"))
+}
+
+// SUBMIT REVIEW (nyimpen hasil belajar + XP)
 func SubmitReview(c *gin.Context) {
 	userID := getUserID(c)
 	var body struct {
-		VocabID uint `json:"vocab_id"`
-		Result  int  `json:"result"`
+		VocabID         uint `json:"vocab_id" binding:"required"`
+		Result          int  `json:"result" binding:"gte=0,lte=2"`
+		DurationSeconds int  `json:"duration_seconds" binding:"gte=0"` // lama kartu dibuka, untuk target menit
 	}
 
-	if c.BindJSON(&body) != nil {
+	if err := c.ShouldBindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Data invalid"})
 		return
 	}
 
-	// Simpan Log
-	log := models.ReviewLog{
+	if _, err := findReadableVocab(userID, body.VocabID); err != nil {
+		if errors.Is(err, errVocabNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Vocabulary not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		}
+		return
+	}
+
+	review := models.ReviewLog{
 		UserID:     userID,
 		VocabID:    body.VocabID,
 		Result:     body.Result,
 		ReviewedAt: time.Now(),
 	}
 
-	database.DB.Create(&log)
+	seconds := body.DurationSeconds
+	if seconds > streak.MaxReviewSeconds {
+		seconds = streak.MaxReviewSeconds
+	}
+
+	gained := 0
+	var streakUpdate *streak.Update
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&review).Error; err != nil {
+			return err
+		}
+		if err := recordVocabState(tx, review); err != nil {
+			return err
+		}
+		// Sudah direview -> keluar dari antrian belajar
+		if err := tx.Where("user_id = ? AND vocab_id = ?", userID, review.VocabID).Delete(&models.StudyQueueItem{}).Error; err != nil {
+			return err
+		}
+		var err error
+		gained, err = awardXPKey(tx, userID, progression.SourceReview, reviewXPKey(review), progression.ReviewXP(review.Result))
+		if err != nil {
+			return err
+		}
+		streakUpdate, err = recordStudyActivity(tx, userID, 1, seconds)
+		return err
+	})
+	if err != nil {
+		log.Printf("[ERROR] Save log failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save"})
+		return
+	}
+
+	unlocked := dispatchAchievementEvents(userID, studyEvents(streakUpdate, achievement.EventReview)...)
 
-	c.JSON(http.StatusOK, gin.H{"message": "Progress disimpan", "exp_gained": 10})
+	resp := gin.H{"message": "Progress disimpan", "exp_gained": gained, "streak": streakUpdate, "achievements": unlocked}
+	if progress, err := XPProgress(userID); err == nil {
+		resp["xp"] = progress
+	}
+	c.JSON(http.StatusOK, resp)
 }
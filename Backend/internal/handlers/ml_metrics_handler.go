@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"kotoba-backend/internal/mlclient"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ML METRICS (admin): latency, failure rate, cache & circuit breaker
+func AdminMLMetrics(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": mlclient.Default().Metrics()})
+}
@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"kotoba-backend/internal/audit"
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/middleware"
+	"kotoba-backend/internal/models"
+	"kotoba-backend/internal/oidc"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Cookie yang mengikat state OIDC ke browser yang memulai login (mencegah login CSRF)
+const (
+	oidcBindingCookie = "oidc_binding"
+	oidcStateTTL      = 10 * time.Minute
+)
+
+var (
+	errEmailNotVerified = errors.New("email not verified by provider")
+	errMissingEmail     = errors.New("provider did not return an email")
+	errLinkRequired     = errors.New("existing account requires explicit linking")
+
+	usernameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+)
+
+func oidcFrontendRedirect(values url.Values) string {
+	target := os.Getenv("OIDC_FRONTEND_REDIRECT")
+	if target == "" {
+		target = "http://localhost:5173/login"
+	}
+	// Token dikirim lewat fragment supaya tidak masuk log server/referer
+	return target + "#" + values.Encode()
+}
+
+func hashBinding(v string) string {
+	sum := sha256.Sum256([]byte(v))
+	return hex.EncodeToString(sum[:])
+}
+
+func setBindingCookie(c *gin.Context, value string, maxAge int) {
+	secure := c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+	c.SetSameSite(http.SameSiteLaxMode) // Lax: cookie tetap terkirim saat redirect balik dari IdP
+	c.SetCookie(oidcBindingCookie, value, maxAge, "/auth/oidc", "", secure, true)
+}
+
+// LIST OIDC PROVIDERS
+func ListOIDCProviders(c *gin.Context) {
+	names := []string{}
+	for name := range oidc.Providers() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	c.JSON(http.StatusOK, gin.H{"data": names})
+}
+
+// OIDC LOGIN: redirect ke IdP
+func OIDCLogin(c *gin.Context) {
+	provider, ok := oidc.Providers()[c.Param("provider")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown provider"})
+		return
+	}
+
+	binding := oidc.RandomString()
+	state := models.OIDCLoginState{
+		State:       oidc.RandomString(),
+		Provider:    provider.Name,
+		Verifier:    oidc.RandomString(),
+		Nonce:       oidc.RandomString(),
+		ExpiresAt:   time.Now().Add(oidcStateTTL),
+		BindingHash: hashBinding(binding),
+	}
+
+	authURL, err := provider.AuthCodeURL(c.Request.Context(), state.State, state.Nonce, state.Verifier)
+	if err != nil {
+		log.Printf("[ERROR] OIDC %s: %v", provider.Name, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Identity provider unavailable"})
+		return
+	}
+
+	database.DB.Where("expires_at < ?", time.Now()).Delete(&models.OIDCLoginState{})
+	if err := database.DB.Create(&state).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+		return
+	}
+
+	setBindingCookie(c, binding, int(oidcStateTTL.Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDC CALLBACK: tukar code, link/buat user, redirect ke frontend dengan token
+func OIDCCallback(c *gin.Context) {
+	fail := func(msg string) {
+		c.Redirect(http.StatusFound, oidcFrontendRedirect(url.Values{"error": {msg}}))
+	}
+
+	provider, ok := oidc.Providers()[c.Param("provider")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown provider"})
+		return
+	}
+
+	if idpErr := c.Query("error"); idpErr != "" {
+		fail(idpErr)
+		return
+	}
+
+	// State hanya bisa dipakai sekali: dihapus + dibaca dalam satu statement
+	var states []models.OIDCLoginState
+	consumed := database.DB.Clauses(clause.Returning{}).Where("state = ?", c.Query("state")).Delete(&states)
+	binding, _ := c.Cookie(oidcBindingCookie)
+	setBindingCookie(c, "", -1)
+	if consumed.Error != nil || len(states) != 1 {
+		fail("invalid_state")
+		return
+	}
+	state := states[0]
+
+	if state.Provider != provider.Name || time.Now().After(state.ExpiresAt) ||
+		binding == "" || subtle.ConstantTimeCompare([]byte(hashBinding(binding)), []byte(state.BindingHash)) != 1 {
+		fail("invalid_state")
+		return
+	}
+
+	claims, err := provider.Exchange(c.Request.Context(), c.Query("code"), state.Verifier, state.Nonce)
+	if err != nil {
+		log.Printf("[ERROR] OIDC %s callback: %v", provider.Name, err)
+		fail("login_failed")
+		return
+	}
+
+	user, err := resolveOIDCUser(c, provider.Name, claims)
+	if err == errLinkRequired {
+		// Akun lokal dengan email ini belum terverifikasi: pemiliknya harus login lalu
+		// mengonfirmasi link dengan password (POST /api/auth/oidc/link)
+		link := models.OIDCPendingLink{
+			Token: oidc.RandomString(), UserID: user.ID, Provider: provider.Name,
+			Subject: claims.Subject, Email: claims.Email, ExpiresAt: time.Now().Add(oidcStateTTL),
+		}
+		database.DB.Where("expires_at < ?", time.Now()).Delete(&models.OIDCPendingLink{})
+		if err := database.DB.Create(&link).Error; err != nil {
+			fail("login_failed")
+			return
+		}
+		c.Redirect(http.StatusFound, oidcFrontendRedirect(url.Values{
+			"error": {"link_required"}, "link_token": {link.Token}, "email": {claims.Email},
+		}))
+		return
+	}
+	if err != nil {
+		log.Printf("[WARN] OIDC %s user resolve: %v", provider.Name, err)
+		switch err {
+		case errEmailNotVerified:
+			fail("email_not_verified")
+		case errMissingEmail:
+			fail("email_required")
+		default:
+			fail("login_failed")
+		}
+		return
+	}
+
+	if user.LockedAt != nil {
+		fail("account_locked")
+		return
+	}
+
+	token, err := middleware.IssueOIDCToken(user.ID, user.TokenVersion, user.Role)
+	if err != nil {
+		fail("login_failed")
+		return
+	}
+
+	c.Redirect(http.StatusFound, oidcFrontendRedirect(url.Values{
+		"token":    {token},
+		"username": {user.Username},
+	}))
+}
+
+// Urutan: identity yang sudah terhubung -> link otomatis kalau email terverifikasi di IdP
+// dan di akun lokal -> buat user baru (JIT). Akun lokal yang email-nya belum terverifikasi
+// tidak di-link otomatis (errLinkRequired), supaya akun yang didaftarkan duluan oleh orang lain
+// dengan email korban tidak ikut terbuka.
+func resolveOIDCUser(c *gin.Context, provider string, claims *oidc.Claims) (models.User, error) {
+	var user models.User
+
+	var identity models.UserIdentity
+	err := database.DB.Where("provider = ? AND subject = ?", provider, claims.Subject).First(&identity).Error
+	if err == nil {
+		err = database.DB.First(&user, identity.UserID).Error
+		return user, err
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, err
+	}
+
+	if claims.Email == "" {
+		return user, errMissingEmail
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// Unscoped: akun yang sedang masa tenggang hapus tidak boleh di-link/diduplikasi
+		err := tx.Unscoped().Where("LOWER(email) = LOWER(?)", claims.Email).First(&user).Error
+		switch {
+		case err == nil:
+			if user.DeletedAt.Valid {
+				return errors.New("account pending deletion")
+			}
+			if !claims.EmailVerified {
+				return errEmailNotVerified
+			}
+			if user.EmailVerifiedAt == nil {
+				return errLinkRequired
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if !claims.EmailVerified {
+				return errEmailNotVerified
+			}
+			username, err := uniqueUsername(tx, claims)
+			if err != nil {
+				return err
+			}
+			// Tanpa password: tidak bisa login pakai password sampai user set password
+			// sendiri (PUT /api/account/password setelah login OIDC ulang)
+			now := time.Now()
+			user = models.User{Username: username, Email: claims.Email, EmailVerifiedAt: &now}
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+			log.Printf("[INFO] OIDC %s: provisioned user %d", provider, user.ID)
+		default:
+			return err
+		}
+
+		identity := models.UserIdentity{
+			UserID:   user.ID,
+			Provider: provider,
+			Subject:  claims.Subject,
+			Email:    claims.Email,
+		}
+		if err := tx.Create(&identity).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, audit.Entry{
+			ActorID: user.ID, Action: "identity.linked", TargetType: "user", TargetID: user.ID,
+			After: gin.H{"provider": provider, "email": claims.Email},
+		})
+	})
+	return user, err
+}
+
+func uniqueUsername(tx *gorm.DB, claims *oidc.Claims) (string, error) {
+	base := claims.PreferredUsername
+	if base == "" || strings.Contains(base, "@") {
+		base = strings.SplitN(claims.Email, "@", 2)[0]
+	}
+	base = usernameInvalidChars.ReplaceAllString(base, "")
+	if len(base) > 30 {
+		base = base[:30]
+	}
+	if base == "" {
+		base = "ronin"
+	}
+
+	candidate := base
+	for i := 0; i < 10; i++ {
+		var count int64
+		if err := tx.Unscoped().Model(&models.User{}).Where("username = ?", candidate).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s%04d", base, rand.Intn(10000))
+	}
+	return "", errors.New("could not generate unique username")
+}
+
+// LINK OIDC IDENTITY: {"link_token": "...", "password": "..."} dari redirect link_required.
+// Harus login sebagai akun yang dituju dan memasukkan password akun itu.
+func LinkOIDCIdentity(c *gin.Context) {
+	var input struct {
+		LinkToken string `json:"link_token" binding:"required"`
+		Password  string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	user, ok := loadUserWithPassword(c, input.Password)
+	if !ok {
+		return
+	}
+
+	var links []models.OIDCPendingLink
+	consumed := database.DB.Clauses(clause.Returning{}).
+		Where("token = ? AND user_id = ? AND expires_at > ?", input.LinkToken, user.ID, time.Now()).
+		Delete(&links)
+	if consumed.Error != nil || len(links) != 1 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Link request not found or expired"})
+		return
+	}
+	link := links[0]
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		identity := models.UserIdentity{UserID: user.ID, Provider: link.Provider, Subject: link.Subject, Email: link.Email}
+		if err := tx.Create(&identity).Error; err != nil {
+			return err
+		}
+		// IdP sudah memverifikasi email yang sama -> email akun dianggap terverifikasi
+		if strings.EqualFold(link.Email, user.Email) {
+			if err := tx.Model(&user).Update("email_verified_at", time.Now()).Error; err != nil {
+				return err
+			}
+		}
+		return audit.Record(tx, c, audit.Entry{
+			Action: "identity.linked", TargetType: "user", TargetID: user.ID,
+			After: gin.H{"provider": link.Provider, "email": link.Email},
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Failed to link identity"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Identity linked", "provider": link.Provider})
+}
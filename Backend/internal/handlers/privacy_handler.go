@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func validVisibility(v string) bool {
+	return v == models.VisibilityPublic || v == models.VisibilityFriends || v == models.VisibilityPrivate
+}
+
+// Pengaturan privasi user; belum ada baris = default (tampil di leaderboard, feed untuk teman)
+func loadPrivacy(userID uint) (models.PrivacySettings, error) {
+	settings := models.PrivacySettings{
+		UserID:           userID,
+		FeedExams:        models.VisibilityFriends,
+		FeedAchievements: models.VisibilityFriends,
+		FeedStreaks:      models.VisibilityFriends,
+	}
+	err := database.DB.Where("user_id = ?", userID).Limit(1).Find(&settings).Error
+	return settings, err
+}
+
+// GET PRIVACY
+func GetPrivacy(c *gin.Context) {
+	settings, err := loadPrivacy(getUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": settings})
+}
+
+// UPDATE PRIVACY: {"hide_from_leaderboards": true, "feed_exams": "public|friends|private", ...}
+func UpdatePrivacy(c *gin.Context) {
+	var input struct {
+		HideFromLeaderboards *bool  `json:"hide_from_leaderboards"`
+		FeedExams            string `json:"feed_exams"`
+		FeedAchievements     string `json:"feed_achievements"`
+		FeedStreaks          string `json:"feed_streaks"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	for _, v := range []string{input.FeedExams, input.FeedAchievements, input.FeedStreaks} {
+		if v != "" && !validVisibility(v) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid visibility"})
+			return
+		}
+	}
+
+	settings, err := loadPrivacy(getUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	if input.HideFromLeaderboards != nil {
+		settings.HideFromLeaderboards = *input.HideFromLeaderboards
+	}
+	if input.FeedExams != "" {
+		settings.FeedExams = input.FeedExams
+	}
+	if input.FeedAchievements != "" {
+		settings.FeedAchievements = input.FeedAchievements
+	}
+	if input.FeedStreaks != "" {
+		settings.FeedStreaks = input.FeedStreaks
+	}
+	if err := database.DB.Save(&settings).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": settings})
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"kotoba-backend/internal/audit"
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/middleware"
+	"kotoba-backend/internal/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GET MY ROLE & PERMISSIONS
+func GetMyPermissions(c *gin.Context) {
+	role := c.GetString("role")
+	c.JSON(http.StatusOK, gin.H{
+		"role":        role,
+		"permissions": middleware.PermissionsFor(role),
+	})
+}
+
+// LIST ROLES (admin)
+func ListRoles(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": middleware.Roles()})
+}
+
+// ASSIGN ROLE (admin)
+func AssignRole(c *gin.Context) {
+	var input struct {
+		Role string `json:"role" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil || !middleware.IsValidRole(input.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role"})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	oldRole := user.Role
+	if oldRole == input.Role {
+		c.JSON(http.StatusOK, gin.H{"message": "Role unchanged", "role": oldRole})
+		return
+	}
+
+	// Jangan sampai tidak ada admin sama sekali
+	if oldRole == middleware.RoleAdmin {
+		var admins int64
+		database.DB.Model(&models.User{}).Where("role = ?", middleware.RoleAdmin).Count(&admins)
+		if admins <= 1 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Cannot remove the last admin"})
+			return
+		}
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("role", input.Role).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, audit.Entry{
+			Action:     "user.role_changed",
+			TargetType: "user",
+			TargetID:   user.ID,
+			Before:     gin.H{"role": oldRole},
+			After:      gin.H{"role": input.Role},
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign role"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role updated", "user_id": user.ID, "role": input.Role})
+}
@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"kotoba-backend/internal/achievement"
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/models"
+	"kotoba-backend/internal/progression"
+	"kotoba-backend/internal/scenario"
+	"kotoba-backend/internal/sensei"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Sesi role-play aktif yang terikat ke percakapan ini (kalau ada)
+func activeRoleplay(conv models.Conversation) (*models.RoleplaySession, scenario.Scenario, bool) {
+	var session models.RoleplaySession
+	if err := database.DB.Where("conversation_id = ? AND status = ?", conv.ID, models.RoleplayActive).
+		First(&session).Error; err != nil {
+		return nil, scenario.Scenario{}, false
+	}
+	sc, ok := scenario.Get(session.ScenarioSlug)
+	if !ok {
+		return nil, sc, false
+	}
+	return &session, sc, true
+}
+
+// Info giliran untuk client: sisa giliran sebelum adegan sebaiknya diakhiri
+func roleplayTurnInfo(session *models.RoleplaySession, sc scenario.Scenario) gin.H {
+	if session == nil {
+		return nil
+	}
+	var turns int64
+	database.DB.Model(&models.ChatMessage{}).
+		Where("conversation_id = ? AND role = ?", session.ConversationID, models.ChatRoleUser).Count(&turns)
+
+	left := sc.MaxTurns - int(turns)
+	if left < 0 {
+		left = 0
+	}
+	return gin.H{"session_id": session.ID, "scenario": sc.Slug, "turns_left": left}
+}
+
+// LIST SCENARIOS
+func ListScenarios(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": scenario.All()})
+}
+
+// START ROLE-PLAY: buat percakapan baru, lawan bicara membuka adegan
+func StartRoleplay(c *gin.Context) {
+	sc, ok := scenario.Get(c.Param("slug"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Scenario not found"})
+		return
+	}
+
+	userID := getUserID(c)
+	conv := models.Conversation{UserID: userID, Title: "Role-play: " + sc.Title}
+	var session models.RoleplaySession
+	var opening models.ChatMessage
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&conv).Error; err != nil {
+			return err
+		}
+		session = models.RoleplaySession{
+			UserID: userID, ScenarioSlug: sc.Slug, ScenarioTitle: sc.Title,
+			ConversationID: conv.ID, Status: models.RoleplayActive,
+		}
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+		opening = models.ChatMessage{ConversationID: conv.ID, Role: models.ChatRoleAssistant, Content: sc.OpeningLine}
+		return tx.Create(&opening).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start scenario"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"session":      session,
+		"scenario":     sc,
+		"conversation": conv,
+		"message":      opening,
+	})
+}
+
+func findRoleplaySession(c *gin.Context) (models.RoleplaySession, bool) {
+	var session models.RoleplaySession
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), getUserID(c)).First(&session).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return session, false
+	}
+	return session, true
+}
+
+// Penilaian yang nyangkut (mis. server restart) boleh diklaim ulang setelah ini
+const roleplayFinishTimeout = 5 * time.Minute
+
+// FINISH ROLE-PLAY: nilai percakapan, simpan laporan. Idempotent.
+// Sesi diklaim dulu (active -> finishing), jadi request paralel tidak memanggil evaluator dua kali.
+func FinishRoleplay(c *gin.Context) {
+	session, ok := findRoleplaySession(c)
+	if !ok {
+		return
+	}
+
+	if session.Status == models.RoleplayCompleted {
+		var report models.RoleplayReport
+		if err := database.DB.Where("session_id = ?", session.ID).First(&report).Error; err == nil {
+			c.JSON(http.StatusOK, gin.H{"session": session, "report": report})
+			return
+		}
+	}
+
+	sc, ok := scenario.Get(session.ScenarioSlug)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Scenario not found"})
+		return
+	}
+
+	var msgs []models.ChatMessage
+	if err := database.DB.Where("conversation_id = ?", session.ConversationID).Order("id").Find(&msgs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+
+	now := time.Now()
+	claim := database.DB.Model(&models.RoleplaySession{}).
+		Where("id = ? AND (status = ? OR (status = ? AND ended_at < ?))",
+			session.ID, models.RoleplayActive, models.RoleplayFinishing, now.Add(-roleplayFinishTimeout)).
+		Updates(map[string]interface{}{"status": models.RoleplayFinishing, "ended_at": now})
+	if claim.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	if claim.RowsAffected == 0 {
+		// Request lain baru saja selesai menilai -> laporannya; masih menilai -> 409
+		var report models.RoleplayReport
+		if err := database.DB.Where("session_id = ?", session.ID).First(&report).Error; err == nil {
+			database.DB.First(&session, session.ID)
+			c.JSON(http.StatusOK, gin.H{"session": session, "report": report})
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": "Role-play is already being graded"})
+		return
+	}
+	// Gagal sebelum laporan tersimpan -> sesi bisa dinilai ulang
+	release := func() {
+		database.DB.Model(&models.RoleplaySession{}).Where("id = ? AND status = ?", session.ID, models.RoleplayFinishing).
+			Updates(map[string]interface{}{"status": models.RoleplayActive, "ended_at": nil})
+	}
+
+	var userLines []string
+	var transcript strings.Builder
+	for _, m := range msgs {
+		speaker := "LAWAN BICARA"
+		if m.Role == models.ChatRoleUser {
+			speaker = "MURID"
+			userLines = append(userLines, m.Content)
+		}
+		fmt.Fprintf(&transcript, "%s: %s\n", speaker, m.Content)
+	}
+	if len(userLines) == 0 {
+		release()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to grade yet"})
+		return
+	}
+
+	usage := sc.TargetUsage(userLines)
+	fb := evaluateRoleplay(c, sc, transcript.String(), usage)
+
+	report := models.RoleplayReport{
+		SessionID:     session.ID,
+		UserID:        session.UserID,
+		Score:         scenario.Score(usage, fb),
+		GoalAchieved:  fb.GoalAchieved,
+		TargetsUsed:   toJSONText(usage.Used),
+		TargetsMissed: toJSONText(usage.Missed),
+		Mistakes:      toJSONText(fb.Mistakes),
+		Summary:       fb.Summary,
+	}
+
+	gained := 0
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&report).Error; err != nil {
+			return err
+		}
+		session.Status = models.RoleplayCompleted
+		session.EndedAt = &now
+		if err := tx.Save(&session).Error; err != nil {
+			return err
+		}
+		var err error
+		gained, err = awardXP(tx, session.UserID, progression.SourceRoleplay, session.ID,
+			progression.RoleplayXP(report.Score, report.GoalAchieved))
+		return err
+	})
+	if err != nil {
+		release()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save report"})
+		return
+	}
+
+	unlocked := dispatchAchievementEvents(session.UserID, achievement.EventRoleplay)
+
+	resp := gin.H{"session": session, "report": report, "exp_gained": gained, "achievements": unlocked}
+	if progress, err := XPProgress(session.UserID); err == nil {
+		resp["xp"] = progress
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Penilaian kualitatif oleh LLM; kalau gagal, pakai penilaian dari target saja
+func evaluateRoleplay(c *gin.Context, sc scenario.Scenario, transcript string, usage scenario.Usage) scenario.Feedback {
+	req := sensei.NewRequest([]sensei.Message{
+		{Role: sensei.RoleSystem, Content: sc.EvaluationPrompt()},
+		{Role: sensei.RoleUser, Content: transcript},
+	})
+	req.Temperature = 0.2
+	req.MaxTokens = 800
+
+	userID := getUserID(c)
+	next := sensei.Usage{Calls: 1, PromptTokens: sensei.EstimateMessages(req.Messages), CompletionTokens: req.MaxTokens}
+	budget, scope, err := reserveBudget(userID, next)
+	if err == nil && scope != "" {
+		recordGuardEvent(userID, 0, "budget_exceeded", scope)
+		err = errors.New("monthly budget exceeded (" + scope + ")")
+	}
+
+	var reply string
+	if err == nil {
+		base, _ := sensei.Default()
+		provider := sensei.Meter(base)
+		reply, err = provider.Complete(c.Request.Context(), req)
+		recordLLMUsage(budget, 0, models.UsageRoleplayEval, provider)
+	}
+	if err == nil {
+		fb, perr := scenario.ParseFeedback(reply)
+		if perr == nil {
+			if fb.Mistakes == nil {
+				fb.Mistakes = []scenario.Mistake{}
+			}
+			return fb
+		}
+		err = perr
+	}
+
+	log.Printf("[WARN] Role-play evaluation unavailable: %v", err)
+	return scenario.Feedback{
+		GoalAchieved: len(usage.Missed) == 0,
+		Mistakes:     []scenario.Mistake{},
+		Summary:      "Penilaian Sensei sedang tidak tersedia. Skor dihitung dari kosakata dan pola kalimat target yang kamu pakai.",
+	}
+}
+
+func toJSONText(v interface{}) models.JSONText {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return models.JSONText(b)
+}
+
+type roleplayHistoryRow struct {
+	models.RoleplaySession
+	Score        *int  `json:"score"`
+	GoalAchieved *bool `json:"goal_achieved"`
+}
+
+// ROLE-PLAY HISTORY
+func ListRoleplaySessions(c *gin.Context) {
+	page, limit := pagination(c)
+	userID := getUserID(c)
+
+	var total int64
+	database.DB.Model(&models.RoleplaySession{}).Where("user_id = ?", userID).Count(&total)
+
+	var rows []roleplayHistoryRow
+	err := database.DB.Table("roleplay_sessions s").
+		Select("s.*, r.score, r.goal_achieved").
+		Joins("LEFT JOIN roleplay_reports r ON r.session_id = s.id").
+		Where("s.user_id = ?", userID).
+		Order("s.started_at DESC").Offset((page - 1) * limit).Limit(limit).
+		Scan(&rows).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": rows, "page": page, "limit": limit, "total": total})
+}
+
+// ROLE-PLAY DETAIL: sesi + laporan (kalau sudah selesai)
+func GetRoleplaySession(c *gin.Context) {
+	session, ok := findRoleplaySession(c)
+	if !ok {
+		return
+	}
+
+	resp := gin.H{"session": session, "report": nil}
+	var report models.RoleplayReport
+	if err := database.DB.Where("session_id = ?", session.ID).First(&report).Error; err == nil {
+		resp["report"] = report
+	}
+	if sc, ok := scenario.Get(session.ScenarioSlug); ok {
+		resp["scenario"] = sc
+	}
+	c.JSON(http.StatusOK, resp)
+}
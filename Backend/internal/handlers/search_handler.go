@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/search"
+	"kotoba-backend/internal/srs"
+	"kotoba-backend/internal/tagging"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+type searchResultRow struct {
+	ID              uint       `json:"id"`
+	Kanji           string     `json:"kanji"`
+	Kana            string     `json:"kana"`
+	Romaji          string     `json:"romaji"`
+	Meaning         string     `json:"meaning"`
+	DifficultyLevel int        `json:"difficulty_level"`
+	Private         bool       `json:"private"`
+	Score           float64    `json:"score"`
+	Status          string     `json:"status" gorm:"-"`
+	LastResult      *int       `json:"last_result"` // nil = belum pernah direview
+	LastReviewedAt  *time.Time `json:"last_reviewed_at"`
+	DueAt           *time.Time `json:"due_at"`
+}
+
+// Escape %, _ dan \ supaya input user tidak jadi pola LIKE
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// Query kandidat + skor. Kana/kanji dicocokkan untuk semua input, romaji & arti hanya kalau
+// ada huruf latin (romaji_key dari "ねこ" kosong, jadi akan cocok dengan semua kata).
+// Skor: sama persis 2, awalan 1.5, kemiripan trigram 0..1, cocok full-text arti 1 + rank.
+func searchHitsSQL(latin bool, tags []string, level int) string {
+	fts := `to_tsvector('` + search.TextConfig + `', v.meaning) @@ plainto_tsquery('` + search.TextConfig + `', @q)`
+	scores := []string{
+		`CASE WHEN v.kanji = @q OR v.kana = @kana THEN 2.0 ELSE 0 END`,
+		`CASE WHEN v.kanji LIKE @prefix OR v.kana LIKE @kana_prefix THEN 1.5 ELSE 0 END`,
+		`similarity(v.kana, @kana)`,
+		`similarity(v.kanji, @q)`,
+	}
+	matches := []string{`v.kanji LIKE @prefix`, `v.kana LIKE @kana_prefix`, `v.kana % @kana`, `v.kanji % @q`}
+	if latin {
+		scores = append(scores,
+			`CASE WHEN romaji_key(v.romaji) = romaji_key(@q) OR lower(v.meaning) = lower(@q) THEN 2.0 ELSE 0 END`,
+			`CASE WHEN romaji_key(v.romaji) LIKE romaji_key(@q) || '%' THEN 1.5 ELSE 0 END`,
+			`similarity(romaji_key(v.romaji), romaji_key(@q))`,
+			`CASE WHEN `+fts+` THEN 1 + ts_rank(to_tsvector('`+search.TextConfig+`', v.meaning), plainto_tsquery('`+search.TextConfig+`', @q)) ELSE 0 END`,
+			`similarity(v.meaning, @q)`,
+		)
+		matches = append(matches,
+			`romaji_key(v.romaji) % romaji_key(@q)`,
+			`romaji_key(v.romaji) LIKE romaji_key(@q) || '%'`,
+			fts,
+			`v.meaning ILIKE @contains`,
+		)
+	}
+
+	sql := `SELECT v.id, v.kanji, v.kana, v.romaji, v.meaning, v.difficulty_level,
+			v.owner_id IS NOT NULL AS private,
+			GREATEST(` + strings.Join(scores, ", ") + `) AS score
+		FROM vocabularies v
+		WHERE v.deleted_at IS NULL AND (v.owner_id IS NULL OR v.owner_id = @user)
+			AND (` + strings.Join(matches, " OR ") + `)`
+	if level > 0 {
+		sql += ` AND v.difficulty_level = @level`
+	}
+	if len(tags) > 0 {
+		sql += ` AND ` + tagging.FilterSQLNamed("v.id", "tags")
+	}
+	return sql
+}
+
+// SEARCH VOCABULARIES: ?q=sensei&level=5&tag=food&page=1&limit=20
+// Cocok dengan kanji, kana (katakana disamakan ke hiragana), romaji tanpa peduli vokal panjang
+// ("sensei" = "sensee" = "sensē") dan arti bahasa Indonesia. Hasil diurutkan dari skor tertinggi.
+func SearchVocabularies(c *gin.Context) {
+	userID := getUserID(c)
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" || utf8.RuneCountInString(q) > search.MaxQueryLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query", "max_length": search.MaxQueryLength})
+		return
+	}
+	level, _ := strconv.Atoi(c.Query("level"))
+	tags, ok := TagFilter(c)
+	if !ok {
+		return
+	}
+	page, limit := pagination(c)
+
+	kana := search.KanaQuery(q)
+	args := map[string]interface{}{
+		"q":           q,
+		"kana":        kana,
+		"prefix":      likeEscaper.Replace(q) + "%",
+		"kana_prefix": likeEscaper.Replace(kana) + "%",
+		"contains":    "%" + likeEscaper.Replace(q) + "%",
+		"user":        userID,
+		"level":       level,
+		"tags":        tags,
+		"offset":      (page - 1) * limit,
+		"limit":       limit,
+	}
+	hits := searchHitsSQL(search.HasLatin(q), tags, level)
+
+	var total int64
+	if err := database.DB.Raw(`SELECT COUNT(*) FROM (`+hits+`) h`, args).Scan(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+
+	// Status user hanya di-join untuk baris di halaman ini
+	var rows []searchResultRow
+	err := database.DB.Raw(`
+		WITH hits AS (`+hits+`
+			ORDER BY score DESC, length(v.kana), v.id
+			OFFSET @offset LIMIT @limit
+		)
+		SELECT h.*, l.result AS last_result, l.reviewed_at AS last_reviewed_at, l.due_at
+		FROM hits h
+		LEFT JOIN user_vocab_states l ON l.vocab_id = h.id AND l.user_id = @user
+		ORDER BY h.score DESC, length(h.kana), h.id`, args).Scan(&rows).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	for i := range rows {
+		rows[i].Status = srs.StatusOf(rows[i].LastResult)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": rows, "query": q, "page": page, "limit": limit, "total": total})
+}
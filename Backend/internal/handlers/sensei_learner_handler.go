@@ -0,0 +1,345 @@
+package handlers
+
+import (
+	"kotoba-backend/internal/achievement"
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/env"
+	"kotoba-backend/internal/models"
+	"kotoba-backend/internal/progression"
+	"kotoba-backend/internal/sensei"
+	"kotoba-backend/internal/srs"
+	"kotoba-backend/internal/streak"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Jumlah kata lemah / jatuh tempo yang disisipkan ke prompt
+const learnerWordLimit = 5
+
+// Kata dianggap "sebentar lagi jatuh tempo" kalau due dalam 24 jam ke depan
+const dueSoonWindow = 24 * time.Hour
+
+// Status terakhir user per kata + waktu jatuh tempo berikutnya (dari user_vocab_states)
+const latestReviewCTE = `
+	WITH latest AS (
+		SELECT vocab_id, result, reviewed_at, due_at
+		FROM user_vocab_states
+		WHERE user_id = ?
+	)`
+
+const learnerWordColumns = `v.id AS vocab_id, v.kanji, v.kana, v.romaji, v.meaning`
+
+func learnerLevel(stats UserStats) string {
+	if stats.IsUnlockedN4 {
+		return "N4"
+	}
+	return "N5"
+}
+
+// Level, kata yang terakhir dijawab "Lupa", dan kata yang segera jatuh tempo
+func buildLearnerContext(userID uint) (*sensei.LearnerContext, error) {
+	stats, err := calculateUserStats(userID)
+	if err != nil {
+		return nil, err
+	}
+	lc := &sensei.LearnerContext{Level: learnerLevel(stats), TotalLearned: stats.TotalLearned}
+
+	err = database.DB.Raw(latestReviewCTE+`
+		SELECT `+learnerWordColumns+`
+		FROM latest l
+		JOIN vocabularies v ON v.id = l.vocab_id AND v.deleted_at IS NULL
+		WHERE l.result = 0
+		ORDER BY l.reviewed_at DESC
+		LIMIT ?`, userID, learnerWordLimit).Scan(&lc.Lapses).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = database.DB.Raw(latestReviewCTE+`
+		SELECT `+learnerWordColumns+`
+		FROM latest l
+		JOIN vocabularies v ON v.id = l.vocab_id AND v.deleted_at IS NULL
+		WHERE l.result > 0 AND l.due_at <= ?
+		ORDER BY l.due_at
+		LIMIT ?`, userID, time.Now().Add(dueSoonWindow), learnerWordLimit).Scan(&lc.DueSoon).Error
+	if err != nil {
+		return nil, err
+	}
+	return lc, nil
+}
+
+// Hasil penilaian kuis yang dikembalikan bersama balasan chat.
+// Baru dicatat (recordQuizOutcome) bersama balasan Sensei, jadi giliran yang gagal tidak meninggalkan review/XP.
+type quizOutcome struct {
+	Quiz    models.SenseiQuiz
+	Word    sensei.Word
+	Skipped bool // pesan bukan jawaban: kuis ditutup tanpa review
+
+	streak *streak.Update
+}
+
+func (o *quizOutcome) note() string {
+	if o == nil || o.Skipped {
+		return ""
+	}
+	return sensei.QuizResultNote(o.Word, o.Quiz.Answer, *o.Quiz.Correct)
+}
+
+func (o *quizOutcome) payload() gin.H {
+	if o == nil || o.Skipped {
+		return nil
+	}
+	return gin.H{
+		"quiz_id":  o.Quiz.ID,
+		"vocab_id": o.Quiz.VocabID,
+		"correct":  *o.Quiz.Correct,
+		"expected": o.Word.Meaning,
+	}
+}
+
+// Pesan user berikutnya setelah kuis dinilai sebagai jawaban (Ingat / Lupa).
+// Pesan yang bukan jawaban (pertanyaan lain, kalimat panjang) menutup kuis tanpa review.
+// Hanya membaca; penyimpanan di recordQuizOutcome.
+func gradePendingQuiz(conv models.Conversation, answer string) (*quizOutcome, error) {
+	var quiz models.SenseiQuiz
+	err := database.DB.Where("conversation_id = ? AND user_id = ? AND status = ?", conv.ID, conv.UserID, models.QuizPending).
+		Order("id DESC").First(&quiz).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var vocab models.Vocabulary
+	if err := database.DB.Unscoped().First(&vocab, quiz.VocabID).Error; err != nil {
+		return nil, err
+	}
+	correct, answered := sensei.GradeAnswer(answer, vocab.Meaning)
+	if !answered {
+		return &quizOutcome{Quiz: quiz, Word: toSenseiWord(vocab), Skipped: true}, nil
+	}
+	quiz.Answer = answer
+	quiz.Correct = &correct
+	return &quizOutcome{Quiz: quiz, Word: toSenseiWord(vocab)}, nil
+}
+
+// Simpan hasil kuis di transaksi balasan Sensei. Kuis diklaim dulu (status masih pending),
+// jadi dua giliran paralel di percakapan yang sama tidak mencatat review/XP dua kali.
+func recordQuizOutcome(tx *gorm.DB, o *quizOutcome) error {
+	if o == nil {
+		return nil
+	}
+	if o.Skipped {
+		return tx.Model(&models.SenseiQuiz{}).Where("id = ? AND status = ?", o.Quiz.ID, models.QuizPending).
+			Update("status", models.QuizSkipped).Error
+	}
+
+	now := time.Now()
+	claim := tx.Model(&models.SenseiQuiz{}).Where("id = ? AND status = ?", o.Quiz.ID, models.QuizPending).
+		Updates(map[string]interface{}{"status": models.QuizAnswered, "answer": o.Quiz.Answer, "correct": *o.Quiz.Correct, "answered_at": now})
+	if claim.Error != nil {
+		return claim.Error
+	}
+	if claim.RowsAffected == 0 {
+		return nil // sudah dinilai giliran lain
+	}
+
+	result := srs.ResultLupa
+	if *o.Quiz.Correct {
+		result = srs.ResultIngat
+	}
+	review := models.ReviewLog{UserID: o.Quiz.UserID, VocabID: o.Quiz.VocabID, Result: result, ReviewedAt: now}
+	if err := tx.Create(&review).Error; err != nil {
+		return err
+	}
+	if err := recordVocabState(tx, review); err != nil {
+		return err
+	}
+	if _, err := awardXPKey(tx, o.Quiz.UserID, progression.SourceReview, reviewXPKey(review), progression.ReviewXP(result)); err != nil {
+		return err
+	}
+	var err error
+	if o.streak, err = recordStudyActivity(tx, o.Quiz.UserID, 1, 0); err != nil {
+		return err
+	}
+	o.Quiz.Status, o.Quiz.ReviewLogID, o.Quiz.AnsweredAt = models.QuizAnswered, &review.ID, &now
+	return tx.Model(&models.SenseiQuiz{}).Where("id = ?", o.Quiz.ID).Update("review_log_id", review.ID).Error
+}
+
+// Setelah commit: achievement dari review kuis
+func (o *quizOutcome) dispatch() {
+	if o == nil || o.Quiz.ReviewLogID == nil {
+		return
+	}
+	dispatchAchievementEvents(o.Quiz.UserID, studyEvents(o.streak, achievement.EventReview)...)
+}
+
+func toSenseiWord(v models.Vocabulary) sensei.Word {
+	return sensei.Word{VocabID: v.ID, Kanji: v.Kanji, Kana: v.Kana, Romaji: v.Romaji, Meaning: v.Meaning}
+}
+
+// System prompt untuk satu giliran chat: persona + konteks murid + hasil kuis (kalau ada)
+func senseiSystemPrompt(conv models.Conversation, message string) (string, *quizOutcome) {
+	outcome, err := gradePendingQuiz(conv, message)
+	if err != nil {
+		log.Printf("[ERROR] Grade quiz failed (conversation %d): %v", conv.ID, err)
+	}
+
+	lc, err := buildLearnerContext(conv.UserID)
+	if err != nil {
+		// Tanpa konteks pun Sensei tetap bisa menjawab
+		log.Printf("[WARN] Learner context unavailable for user %d: %v", conv.UserID, err)
+		lc = nil
+	}
+	return sensei.SystemPromptFor(lc, outcome.note()), outcome
+}
+
+// GET LEARNER CONTEXT: apa yang "diketahui" Sensei tentang user
+func GetSenseiContext(c *gin.Context) {
+	lc, err := buildLearnerContext(getUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": lc})
+}
+
+// Pilih kata untuk kuis: prioritas kata lupa, lalu yang segera jatuh tempo
+func pickQuizWord(userID uint) (*sensei.Word, error) {
+	lc, err := buildLearnerContext(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, words := range [][]sensei.Word{lc.Lapses, lc.DueSoon} {
+		if len(words) > 0 {
+			return &words[0], nil
+		}
+	}
+	return nil, nil
+}
+
+// Satu kuis pending per percakapan (kuis lama yang dobel ditutup sebagai skipped)
+func MigrateSenseiQuizzes() error {
+	statements := []string{
+		`UPDATE sensei_quizzes q SET status = 'skipped'
+		WHERE q.status = 'pending' AND EXISTS (
+			SELECT 1 FROM sensei_quizzes o
+			WHERE o.conversation_id = q.conversation_id AND o.status = 'pending' AND o.id > q.id)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_sensei_quiz_pending
+			ON sensei_quizzes (conversation_id) WHERE status = 'pending'`,
+	}
+	for _, stmt := range statements {
+		if err := database.DB.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sensei mengajukan kuis sendiri setiap SENSEI_QUIZ_EVERY_TURNS pesan user (default 5, 0 = mati)
+func senseiQuizEveryTurns() int { return env.Int("SENSEI_QUIZ_EVERY_TURNS", 5) }
+
+// Buat kuis + pesan pertanyaannya. Kalau sudah ada kuis pending, itu yang dikembalikan (created=false).
+// quiz nil = belum ada kata untuk dikuis.
+func proposeQuiz(conv models.Conversation) (quiz *models.SenseiQuiz, msg *models.ChatMessage, created bool, err error) {
+	var pending models.SenseiQuiz
+	if err := database.DB.Where("conversation_id = ? AND status = ?", conv.ID, models.QuizPending).
+		First(&pending).Error; err == nil {
+		return &pending, nil, false, nil
+	}
+
+	word, err := pickQuizWord(conv.UserID)
+	if err != nil || word == nil {
+		return nil, nil, false, err
+	}
+
+	q := models.SenseiQuiz{
+		UserID: conv.UserID, ConversationID: conv.ID, VocabID: word.VocabID,
+		Question: sensei.QuizQuestion(*word), Status: models.QuizPending,
+	}
+	var m models.ChatMessage
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// idx_sensei_quiz_pending: permintaan paralel tidak bisa membuat dua kuis pending
+		res := tx.Clauses(clause.OnConflict{
+			Columns:     []clause.Column{{Name: "conversation_id"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Eq{Column: "status", Value: models.QuizPending}}},
+			DoNothing:   true,
+		}).Create(&q)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			created = false
+			return tx.Where("conversation_id = ? AND status = ?", conv.ID, models.QuizPending).First(&q).Error
+		}
+		created = true
+		m = models.ChatMessage{ConversationID: conv.ID, Role: models.ChatRoleAssistant, Content: q.Question}
+		if err := tx.Create(&m).Error; err != nil {
+			return err
+		}
+		return tx.Model(&conv).Update("updated_at", time.Now()).Error
+	})
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if !created {
+		return &q, nil, false, nil
+	}
+	return &q, &m, true, nil
+}
+
+// Dipanggil setelah balasan Sensei tersimpan: ajukan kuis kalau gilirannya tiba.
+// Tidak di role-play dan tidak tepat setelah kuis dijawab.
+func maybeProposeQuiz(conv models.Conversation, turn senseiTurn) gin.H {
+	every := senseiQuizEveryTurns()
+	if every == 0 || turn.Roleplay != nil || turn.Quiz != nil {
+		return nil
+	}
+	var turns int64
+	if err := database.DB.Model(&models.ChatMessage{}).
+		Where("conversation_id = ? AND role = ?", conv.ID, models.ChatRoleUser).Count(&turns).Error; err != nil || turns%int64(every) != 0 {
+		return nil
+	}
+
+	quiz, msg, created, err := proposeQuiz(conv)
+	if err != nil {
+		log.Printf("[ERROR] Propose quiz failed (conversation %d): %v", conv.ID, err)
+		return nil
+	}
+	if !created {
+		return nil
+	}
+	return gin.H{"data": quiz, "message": msg}
+}
+
+// PROPOSE QUIZ: minta Sensei mengajukan kuis sekarang (selain yang otomatis tiap beberapa giliran).
+// Pesan user berikutnya di percakapan ini dinilai sebagai jawaban.
+func ProposeSenseiQuiz(c *gin.Context) {
+	conv, ok := findConversation(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	quiz, msg, created, err := proposeQuiz(conv)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create quiz"})
+		return
+	}
+	if quiz == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No words to quiz yet"})
+		return
+	}
+	if !created {
+		// Satu kuis terbuka per percakapan
+		c.JSON(http.StatusOK, gin.H{"data": quiz})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": quiz, "message": msg})
+}
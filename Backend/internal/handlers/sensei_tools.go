@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/models"
+	"kotoba-backend/internal/sensei"
+	"log"
+	"strings"
+)
+
+// Tool yang boleh dipanggil Sensei. Semua dieksekusi atas nama user yang sedang login.
+var senseiTools = []sensei.Tool{
+	sensei.NewTool("lookup_vocabulary",
+		"Cari kata di kamus Kaiwa Rift (kanji, kana, romaji, atau arti bahasa Indonesia). Gunakan sebelum menjelaskan arti kata.",
+		`{"type":"object","properties":{"query":{"type":"string","description":"Kata yang dicari, contoh: neko, 猫, kucing"}},"required":["query"]}`),
+	sensei.NewTool("get_my_stats",
+		"Ambil statistik belajar murid: jumlah kata dipelajari, ingat/ragu/lupa, mastery N5, level.",
+		`{"type":"object","properties":{}}`),
+	sensei.NewTool("add_to_study_queue",
+		"Tambahkan kata (vocab_id dari lookup_vocabulary) ke antrian belajar murid agar muncul di flashcard berikutnya.",
+		`{"type":"object","properties":{"vocab_id":{"type":"integer"}},"required":["vocab_id"]}`),
+}
+
+const (
+	toolLookupLimit    = 5
+	toolMaxQueryLength = 64
+)
+
+type toolVocab struct {
+	VocabID         uint   `json:"vocab_id"`
+	Kanji           string `json:"kanji"`
+	Kana            string `json:"kana"`
+	Romaji          string `json:"romaji"`
+	Meaning         string `json:"meaning"`
+	ExampleSentence string `json:"example_sentence"`
+	Level           string `json:"level"`
+	InStudyQueue    bool   `json:"in_study_queue"`
+}
+
+// Executor terikat ke userID dari sesi. Argumen model tidak pernah menentukan user.
+func senseiToolExecutor(userID uint) sensei.ToolExecutor {
+	return func(ctx context.Context, call sensei.ToolCall) (interface{}, error) {
+		db := database.DB.WithContext(ctx)
+
+		switch call.Function.Name {
+		case "lookup_vocabulary":
+			var args struct {
+				Query string `json:"query"`
+			}
+			if err := sensei.DecodeArgs(call, &args); err != nil {
+				return nil, err
+			}
+			q := strings.TrimSpace(args.Query)
+			if q == "" || len([]rune(q)) > toolMaxQueryLength {
+				return nil, errors.New("query must be 1-64 characters")
+			}
+
+			var rows []toolVocab
+			like := "%" + q + "%"
+			err := db.Raw(`
+				SELECT v.id AS vocab_id, v.kanji, v.kana, v.romaji, v.meaning, v.example_sentence,
+					CASE v.difficulty_level WHEN 2 THEN 'N4' ELSE 'N5' END AS level,
+					EXISTS (SELECT 1 FROM study_queue_items q WHERE q.user_id = ? AND q.vocab_id = v.id) AS in_study_queue
+				FROM vocabularies v
+				WHERE v.deleted_at IS NULL AND `+ReadableVocabSQL("v")+`
+					AND (v.kanji = ? OR v.kana = ? OR v.romaji ILIKE ? OR v.meaning ILIKE ?)
+				ORDER BY (v.kanji = ? OR v.kana = ? OR LOWER(v.romaji) = LOWER(?)) DESC, v.id
+				LIMIT ?`,
+				userID, userID, userID, userID, userID, q, q, like, like, q, q, q, toolLookupLimit).Scan(&rows).Error
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"query": q, "results": rows}, nil
+
+		case "get_my_stats":
+			stats, err := calculateUserStats(userID)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"level": learnerLevel(stats), "stats": stats}, nil
+
+		case "add_to_study_queue":
+			var args struct {
+				VocabID uint `json:"vocab_id"`
+			}
+			if err := sensei.DecodeArgs(call, &args); err != nil {
+				return nil, err
+			}
+			item, created, err := addToStudyQueue(userID, args.VocabID, models.StudySourceSensei)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"vocab_id": item.VocabID, "added": created, "already_queued": !created}, nil
+		}
+		return nil, fmt.Errorf("unknown tool %q", call.Function.Name)
+	}
+}
+
+// Jalankan tool calling (kalau aktif). Balasan kosong = caller perlu Complete/Stream request hasilnya.
+func resolveSenseiTools(ctx context.Context, provider sensei.ChatProvider, req sensei.Request, userID uint) (sensei.Request, string, []sensei.ToolResult) {
+	if _, cfg := sensei.Default(); !cfg.Tools {
+		return req, "", nil
+	}
+
+	req.Tools = senseiTools
+	resolved, reply, trace, err := sensei.ResolveTools(ctx, provider, req, senseiToolExecutor(userID))
+	if err != nil && ctx.Err() == nil {
+		// Model/provider tanpa dukungan tool: lanjut sebagai chat biasa
+		log.Printf("[WARN] Sensei tool calling failed, falling back to plain chat: %v", err)
+	}
+	return resolved, reply, trace
+}
+
+// Versi stream: setiap putaran di-stream, jadi balasan tanpa tool langsung sampai ke client
+func streamSenseiReply(ctx context.Context, provider sensei.ChatProvider, req sensei.Request, userID uint,
+	onDelta func(string) error, onTools func([]sensei.ToolResult)) (string, error) {
+	if _, cfg := sensei.Default(); !cfg.Tools {
+		return provider.Stream(ctx, req, onDelta)
+	}
+	req.Tools = senseiTools
+	return sensei.StreamTools(ctx, provider, req, senseiToolExecutor(userID), onDelta, onTools)
+}
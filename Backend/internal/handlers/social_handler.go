@@ -0,0 +1,329 @@
+package handlers
+
+import (
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ID teman (accepted) dari user; argumen: userID tiga kali
+const friendIDsSQL = `
+	SELECT CASE WHEN f.requester_id = ? THEN f.addressee_id ELSE f.requester_id END
+	FROM friendships f
+	WHERE f.status = 'accepted' AND (f.requester_id = ? OR f.addressee_id = ?)`
+
+// Pasangan user yang saling memblokir (arah mana pun); argumen: a, b, b, a
+const blockedPairSQL = `
+	SELECT 1 FROM blocks
+	WHERE (blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)`
+
+func isBlocked(tx *gorm.DB, a, b uint) bool {
+	var n int64
+	tx.Raw(`SELECT COUNT(*) FROM (`+blockedPairSQL+`) x`, a, b, b, a).Scan(&n)
+	return n > 0
+}
+
+type socialUser struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+	Avatar   string `json:"avatar"`
+}
+
+// Target dari :user_id. Blokir disamarkan sebagai "tidak ditemukan".
+func findSocialTarget(c *gin.Context, userID uint, id string) (socialUser, bool) {
+	var target socialUser
+	err := database.DB.Model(&models.User{}).Select("id, username, avatar").Where("id = ?", id).Take(&target).Error
+	if err != nil || target.ID == userID || isBlocked(database.DB, userID, target.ID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return target, false
+	}
+	return target, true
+}
+
+// --- FRIENDS ---
+
+// SEND FRIEND REQUEST: {"username": "..."} atau {"user_id": N}.
+// Kalau target sudah lebih dulu mengirim permintaan, langsung diterima.
+func SendFriendRequest(c *gin.Context) {
+	var input struct {
+		UserID   uint   `json:"user_id"`
+		Username string `json:"username"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || (input.UserID == 0 && input.Username == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	userID := getUserID(c)
+	query := database.DB.Model(&models.User{}).Select("id")
+	if input.UserID != 0 {
+		query = query.Where("id = ?", input.UserID)
+	} else {
+		query = query.Where("username = ?", input.Username)
+	}
+	var targetID uint
+	query.Scan(&targetID)
+	if targetID == 0 || targetID == userID || isBlocked(database.DB, userID, targetID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Dua percobaan: kalau permintaan balik (target -> user) dibuat bersamaan, baris itu yang dipakai
+	for attempt := 0; attempt < 2; attempt++ {
+		var existing models.Friendship
+		err := database.DB.Where("(requester_id = ? AND addressee_id = ?) OR (requester_id = ? AND addressee_id = ?)",
+			userID, targetID, targetID, userID).First(&existing).Error
+		if err == nil {
+			respondExistingFriendship(c, existing, userID, targetID)
+			return
+		}
+		if err != gorm.ErrRecordNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+			return
+		}
+
+		// Unique index pasangan (LEAST, GREATEST) menolak baris kedua untuk pasangan yang sama
+		req := models.Friendship{RequesterID: userID, AddresseeID: targetID, Status: models.FriendPending}
+		result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&req)
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save"})
+			return
+		}
+		if result.RowsAffected > 0 {
+			c.JSON(http.StatusCreated, gin.H{"data": req})
+			return
+		}
+	}
+	c.JSON(http.StatusConflict, gin.H{"error": "Friend request changed, try again"})
+}
+
+// Permintaan yang ditolak baru bisa dikirim ulang pengirimnya setelah cooldown
+const friendRequestCooldown = 30 * 24 * time.Hour
+
+func respondExistingFriendship(c *gin.Context, existing models.Friendship, userID, targetID uint) {
+	now := time.Now()
+	switch {
+	case existing.Status == models.FriendAccepted,
+		existing.Status == models.FriendPending && existing.RequesterID == userID:
+		c.JSON(http.StatusOK, gin.H{"data": existing})
+		return
+	case existing.Status == models.FriendPending:
+		// Target sudah lebih dulu meminta: langsung berteman
+		existing.Status = models.FriendAccepted
+		existing.RespondedAt = &now
+	case existing.RequesterID == userID:
+		// Ditolak: pengirim yang sama menunggu cooldown
+		if existing.RespondedAt != nil && now.Before(existing.RespondedAt.Add(friendRequestCooldown)) {
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":       "Friend request was declined recently",
+				"retry_after": existing.RespondedAt.Add(friendRequestCooldown),
+			})
+			return
+		}
+		existing.Status = models.FriendPending
+		existing.RespondedAt = nil
+		existing.CreatedAt = now
+	default:
+		// User pernah menolak target, sekarang user sendiri yang meminta
+		existing.RequesterID, existing.AddresseeID = userID, targetID
+		existing.Status = models.FriendPending
+		existing.RespondedAt = nil
+		existing.CreatedAt = now
+	}
+	if err := database.DB.Save(&existing).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": existing})
+}
+
+// MigrateSocial: satu baris pertemanan per pasangan, arah mana pun.
+// Duplikat lama dibersihkan dulu (yang accepted / paling lama disimpan).
+func MigrateSocial() error {
+	statements := []string{
+		`DELETE FROM friendships f USING friendships g
+		WHERE LEAST(f.requester_id, f.addressee_id) = LEAST(g.requester_id, g.addressee_id)
+			AND GREATEST(f.requester_id, f.addressee_id) = GREATEST(g.requester_id, g.addressee_id)
+			AND f.id <> g.id
+			AND ((g.status = 'accepted') > (f.status = 'accepted')
+				OR ((g.status = 'accepted') = (f.status = 'accepted') AND g.id < f.id))`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_friendship_unordered_pair
+			ON friendships (LEAST(requester_id, addressee_id), GREATEST(requester_id, addressee_id))`,
+	}
+	for _, stmt := range statements {
+		if err := database.DB.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type friendRequestRow struct {
+	models.Friendship
+	Username string `json:"username"`
+	Avatar   string `json:"avatar"`
+}
+
+// LIST FRIEND REQUESTS: permintaan masuk & keluar yang masih pending
+func ListFriendRequests(c *gin.Context) {
+	userID := getUserID(c)
+
+	var incoming, outgoing []friendRequestRow
+	if err := database.DB.Table("friendships f").Select("f.*, u.username, u.avatar").
+		Joins("JOIN users u ON u.id = f.requester_id AND u.deleted_at IS NULL").
+		Where("f.addressee_id = ? AND f.status = ?", userID, models.FriendPending).
+		Order("f.created_at DESC").Scan(&incoming).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	if err := database.DB.Table("friendships f").Select("f.*, u.username, u.avatar").
+		Joins("JOIN users u ON u.id = f.addressee_id AND u.deleted_at IS NULL").
+		Where("f.requester_id = ? AND f.status = ?", userID, models.FriendPending).
+		Order("f.created_at DESC").Scan(&outgoing).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"incoming": incoming, "outgoing": outgoing})
+}
+
+// ACCEPT / DECLINE: hanya penerima permintaan yang boleh menjawab
+func respondFriendRequest(c *gin.Context, status string) {
+	var req models.Friendship
+	if err := database.DB.Where("id = ? AND addressee_id = ? AND status = ?", c.Param("id"), getUserID(c), models.FriendPending).
+		First(&req).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Request not found"})
+		return
+	}
+	now := time.Now()
+	req.Status = status
+	req.RespondedAt = &now
+	if err := database.DB.Save(&req).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": req})
+}
+
+func AcceptFriendRequest(c *gin.Context)  { respondFriendRequest(c, models.FriendAccepted) }
+func DeclineFriendRequest(c *gin.Context) { respondFriendRequest(c, models.FriendDeclined) }
+
+// LIST FRIENDS
+func ListFriends(c *gin.Context) {
+	userID := getUserID(c)
+	var friends []socialUser
+	if err := database.DB.Model(&models.User{}).Select("id, username, avatar").
+		Where("id IN ("+friendIDsSQL+")", userID, userID, userID).
+		Order("username").Scan(&friends).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": friends})
+}
+
+// REMOVE FRIEND (atau batalkan permintaan yang dikirim)
+func RemoveFriend(c *gin.Context) {
+	userID := getUserID(c)
+	other := c.Param("user_id")
+	if err := database.DB.Where("(requester_id = ? AND addressee_id = ?) OR (requester_id = ? AND addressee_id = ?)",
+		userID, other, other, userID).Delete(&models.Friendship{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Removed"})
+}
+
+// --- FOLLOWS ---
+
+func FollowUser(c *gin.Context) {
+	userID := getUserID(c)
+	target, ok := findSocialTarget(c, userID, c.Param("user_id"))
+	if !ok {
+		return
+	}
+	follow := models.Follow{FollowerID: userID, FolloweeID: target.ID}
+	if err := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&follow).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Following", "user": target})
+}
+
+func UnfollowUser(c *gin.Context) {
+	if err := database.DB.Where("follower_id = ? AND followee_id = ?", getUserID(c), c.Param("user_id")).
+		Delete(&models.Follow{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Unfollowed"})
+}
+
+// LIST FOLLOWS: yang diikuti + jumlah pengikut
+func ListFollows(c *gin.Context) {
+	userID := getUserID(c)
+	var following []socialUser
+	if err := database.DB.Table("follows f").Select("u.id, u.username, u.avatar").
+		Joins("JOIN users u ON u.id = f.followee_id AND u.deleted_at IS NULL").
+		Where("f.follower_id = ?", userID).Order("u.username").Scan(&following).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	var followers int64
+	database.DB.Model(&models.Follow{}).Where("followee_id = ?", userID).Count(&followers)
+	c.JSON(http.StatusOK, gin.H{"following": following, "followers": followers})
+}
+
+// --- BLOCKS ---
+
+// BLOCK USER: putus pertemanan & follow dua arah, sembunyikan satu sama lain dari feed
+func BlockUser(c *gin.Context) {
+	userID := getUserID(c)
+	var target socialUser
+	if err := database.DB.Model(&models.User{}).Select("id, username, avatar").Where("id = ?", c.Param("user_id")).
+		Take(&target).Error; err != nil || target.ID == userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		block := models.Block{BlockerID: userID, BlockedID: target.ID}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&block).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("(requester_id = ? AND addressee_id = ?) OR (requester_id = ? AND addressee_id = ?)",
+			userID, target.ID, target.ID, userID).Delete(&models.Friendship{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("(follower_id = ? AND followee_id = ?) OR (follower_id = ? AND followee_id = ?)",
+			userID, target.ID, target.ID, userID).Delete(&models.Follow{}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Blocked"})
+}
+
+func UnblockUser(c *gin.Context) {
+	if err := database.DB.Where("blocker_id = ? AND blocked_id = ?", getUserID(c), c.Param("user_id")).
+		Delete(&models.Block{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Unblocked"})
+}
+
+func ListBlocks(c *gin.Context) {
+	var blocked []socialUser
+	if err := database.DB.Table("blocks b").Select("u.id, u.username, u.avatar").
+		Joins("JOIN users u ON u.id = b.blocked_id").
+		Where("b.blocker_id = ?", getUserID(c)).Order("b.created_at DESC").Scan(&blocked).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": blocked})
+}
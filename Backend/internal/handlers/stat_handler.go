@@ -2,7 +2,10 @@ package handlers
 
 import (
 	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/models"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,8 +20,15 @@ type UserStats struct {
 }
 
 func GetUserStats(c *gin.Context) {
-	userID := getUserID(c)
+	stats, err := calculateUserStats(getUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
 
+func calculateUserStats(userID uint) (UserStats, error) {
 	var stats UserStats
 
 	// 1. Hitung Log User (Group by Result)
@@ -38,12 +48,14 @@ func GetUserStats(c *gin.Context) {
 		FROM LatestLogs 
 		WHERE rn = 1;
 	`
-	database.DB.Raw(query, userID).Scan(&stats)
+	if err := database.DB.Raw(query, userID).Scan(&stats).Error; err != nil {
+		return stats, err
+	}
 
 	// 2. Hitung Mastery N5
 	// Asumsi Total N5 standar ada sekitar 100 kata atau ambil count real dari db
 	var totalN5 int64
-	database.DB.Table("vocabularies").Where("difficulty_level = 1").Count(&totalN5)
+	database.DB.Table("vocabularies").Where("difficulty_level = 1 AND owner_id IS NULL AND deleted_at IS NULL").Count(&totalN5)
 
 	if totalN5 > 0 {
 		stats.N5Mastery = (float64(stats.IngatCount) / float64(totalN5)) * 100
@@ -58,5 +70,22 @@ func GetUserStats(c *gin.Context) {
 		stats.IsUnlockedN4 = false
 	}
 
-	c.JSON(http.StatusOK, stats)
+	return stats, nil
+}
+
+// PREDICTION HISTORY: ?weeks= (default 12, maks 52), satu titik per hari dari job batch
+func GetPredictionHistory(c *gin.Context) {
+	weeks, err := strconv.Atoi(c.DefaultQuery("weeks", "12"))
+	if err != nil || weeks < 1 || weeks > 52 {
+		weeks = 12
+	}
+	since := time.Now().UTC().AddDate(0, 0, -7*weeks)
+
+	var rows []models.UserPredictionHistory
+	if err := database.DB.Where("user_id = ? AND day >= ?", getUserID(c), since).
+		Order("day").Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rows, "weeks": weeks})
 }
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"kotoba-backend/internal/achievement"
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/models"
+	"kotoba-backend/internal/streak"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const maxStreakHistoryDays = 365
+
+func userLocation(tx *gorm.DB, userID uint) (*time.Location, error) {
+	var tz string
+	if err := tx.Model(&models.User{}).Where("id = ?", userID).Select("timezone").Scan(&tz).Error; err != nil {
+		return nil, err
+	}
+	return streak.Location(tz), nil
+}
+
+// Event achievement untuk aktivitas belajar; streak yang bertambah ikut jadi event
+func studyEvents(update *streak.Update, events ...string) []string {
+	if update != nil {
+		events = append(events, achievement.EventStreak)
+	}
+	return events
+}
+
+// Streak user; belum ada baris = target default, streak 0
+func loadStreak(tx *gorm.DB, userID uint) (models.UserStreak, error) {
+	row := models.UserStreak{UserID: userID, GoalType: streak.DefaultGoalType, GoalTarget: streak.DefaultGoalTarget}
+	err := tx.Where("user_id = ?", userID).First(&row).Error
+	if err == gorm.ErrRecordNotFound {
+		return row, nil
+	}
+	return row, err
+}
+
+// Catat aktivitas belajar hari ini (timezone user) dan perbarui streak kalau target harian baru tercapai.
+// nil = streak tidak berubah.
+func recordStudyActivity(tx *gorm.DB, userID uint, reviews, seconds int) (*streak.Update, error) {
+	loc, err := userLocation(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	day := streak.Day(time.Now(), loc)
+
+	// Detik belajar per hari dibatasi MaxDaySeconds
+	if seconds > streak.MaxDaySeconds {
+		seconds = streak.MaxDaySeconds
+	}
+	sd := models.StudyDay{UserID: userID, Day: day, Reviews: reviews, Seconds: seconds}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "day"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"reviews":    gorm.Expr("study_days.reviews + ?", reviews),
+			"seconds":    gorm.Expr("LEAST(study_days.seconds + ?, ?)", seconds, streak.MaxDaySeconds),
+			"updated_at": time.Now(),
+		}),
+	}).Create(&sd).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("user_id = ? AND day = ?", userID, day).First(&sd).Error; err != nil {
+		return nil, err
+	}
+	if sd.GoalMet {
+		return nil, nil
+	}
+
+	row, err := loadStreak(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !streak.GoalMet(row.GoalType, row.GoalTarget, sd.Reviews, sd.Seconds) {
+		return nil, nil
+	}
+
+	// Klaim "target tercapai" sekali saja per hari, aman untuk request paralel
+	claim := tx.Model(&models.StudyDay{}).Where("id = ? AND goal_met = ?", sd.ID, false).Update("goal_met", true)
+	if claim.Error != nil || claim.RowsAffected == 0 {
+		return nil, claim.Error
+	}
+
+	state := streak.State{Current: row.Current, Longest: row.Longest, LastGoalDay: row.LastGoalDay, Freezes: row.Freezes}
+	update := state.MeetGoal(day)
+	row.Current, row.Longest, row.LastGoalDay, row.Freezes = state.Current, state.Longest, state.LastGoalDay, state.Freezes
+	row.FreezesUsed += update.FreezesUsed
+	if err := tx.Save(&row).Error; err != nil {
+		return nil, err
+	}
+	if update.Extended && isStreakMilestone(update.Current) {
+		if err := recordActivity(tx, userID, models.ActivityStreakMilestone, gin.H{"days": update.Current}); err != nil {
+			return nil, err
+		}
+	}
+	return &update, nil
+}
+
+type StreakSummary struct {
+	streak.Status
+	Timezone     string `json:"timezone"`
+	Today        string `json:"today"`
+	GoalType     string `json:"goal_type"`
+	GoalTarget   int    `json:"goal_target"`
+	TodayReviews int    `json:"today_reviews"`
+	TodayMinutes int    `json:"today_minutes"`
+}
+
+// StreakStatus: streak saat ini, terpanjang, dan flag "at risk" (dipakai juga oleh /api/stats)
+func StreakStatus(userID uint) (StreakSummary, error) {
+	loc, err := userLocation(database.DB, userID)
+	if err != nil {
+		return StreakSummary{}, err
+	}
+	row, err := loadStreak(database.DB, userID)
+	if err != nil {
+		return StreakSummary{}, err
+	}
+
+	today := streak.Day(time.Now(), loc)
+	var sd models.StudyDay
+	database.DB.Where("user_id = ? AND day = ?", userID, today).First(&sd)
+
+	state := streak.State{Current: row.Current, Longest: row.Longest, LastGoalDay: row.LastGoalDay, Freezes: row.Freezes}
+	return StreakSummary{
+		Status:       state.View(today),
+		Timezone:     loc.String(),
+		Today:        today,
+		GoalType:     row.GoalType,
+		GoalTarget:   row.GoalTarget,
+		TodayReviews: sd.Reviews,
+		TodayMinutes: sd.Seconds / 60,
+	}, nil
+}
+
+// GET STREAK: status + riwayat harian (?days=30)
+func GetStreak(c *gin.Context) {
+	userID := getUserID(c)
+	summary, err := StreakStatus(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if days < 1 || days > maxStreakHistoryDays {
+		days = 30
+	}
+	loc := streak.Location(summary.Timezone)
+	since := streak.Day(time.Now().AddDate(0, 0, -days+1), loc)
+
+	var history []models.StudyDay
+	if err := database.DB.Where("user_id = ? AND day >= ?", userID, since).Order("day").Find(&history).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": summary, "history": history})
+}
+
+// UPDATE DAILY GOAL: {"goal_type": "reviews"|"minutes", "goal_target": N}
+func UpdateStreakGoal(c *gin.Context) {
+	var input struct {
+		GoalType   string `json:"goal_type" binding:"required"`
+		GoalTarget int    `json:"goal_target" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || !streak.ValidGoal(input.GoalType, input.GoalTarget) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	userID := getUserID(c)
+	row, err := loadStreak(database.DB, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	row.GoalType = input.GoalType
+	row.GoalTarget = input.GoalTarget
+	if err := database.DB.Save(&row).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save"})
+		return
+	}
+
+	summary, err := StreakStatus(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": summary})
+}
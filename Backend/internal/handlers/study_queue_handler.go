@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+// Batas isi antrian per user
+const maxStudyQueue = 200
+
+var (
+	errVocabNotFound  = errors.New("vocabulary not found")
+	errStudyQueueFull = errors.New("study queue is full")
+)
+
+// Tambah kata ke antrian belajar. Idempotent: kata yang sudah ada tidak diduplikasi.
+func addToStudyQueue(userID, vocabID uint, source string) (item models.StudyQueueItem, created bool, err error) {
+	// Kartu privat orang lain tidak boleh masuk antrian
+	if _, err := findReadableVocab(userID, vocabID); err != nil {
+		return item, false, err
+	}
+
+	var count int64
+	database.DB.Model(&models.StudyQueueItem{}).Where("user_id = ?", userID).Count(&count)
+	if count >= maxStudyQueue {
+		return item, false, errStudyQueueFull
+	}
+
+	item = models.StudyQueueItem{UserID: userID, VocabID: vocabID, Source: source}
+	result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&item)
+	if result.Error != nil {
+		return item, false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		err = database.DB.Where("user_id = ? AND vocab_id = ?", userID, vocabID).First(&item).Error
+		return item, false, err
+	}
+	return item, true, nil
+}
+
+type studyQueueRow struct {
+	VocabID   uint      `json:"vocab_id"`
+	Kanji     string    `json:"kanji"`
+	Kana      string    `json:"kana"`
+	Romaji    string    `json:"romaji"`
+	Meaning   string    `json:"meaning"`
+	Source    string    `json:"source"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// LIST STUDY QUEUE
+func ListStudyQueue(c *gin.Context) {
+	userID := getUserID(c)
+	var rows []studyQueueRow
+	err := database.DB.Raw(`
+		SELECT q.vocab_id, v.kanji, v.kana, v.romaji, v.meaning, q.source, q.created_at
+		FROM study_queue_items q
+		JOIN vocabularies v ON v.id = q.vocab_id AND v.deleted_at IS NULL
+		WHERE q.user_id = ? AND `+ReadableVocabSQL("v")+`
+		ORDER BY q.created_at`, userID, userID, userID, userID, userID).Scan(&rows).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rows})
+}
+
+// ADD TO STUDY QUEUE
+func AddToStudyQueue(c *gin.Context) {
+	var input struct {
+		VocabID uint `json:"vocab_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	item, created, err := addToStudyQueue(getUserID(c), input.VocabID, models.StudySourceManual)
+	switch {
+	case errors.Is(err, errVocabNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Vocabulary not found"})
+	case errors.Is(err, errStudyQueueFull):
+		c.JSON(http.StatusConflict, gin.H{"error": "Study queue is full"})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+	case created:
+		c.JSON(http.StatusCreated, gin.H{"data": item})
+	default:
+		c.JSON(http.StatusOK, gin.H{"data": item})
+	}
+}
+
+// REMOVE FROM STUDY QUEUE
+func RemoveFromStudyQueue(c *gin.Context) {
+	if err := database.DB.Where("user_id = ? AND vocab_id = ?", getUserID(c), c.Param("vocab_id")).
+		Delete(&models.StudyQueueItem{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Removed"})
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"kotoba-backend/internal/audit"
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/models"
+	"kotoba-backend/internal/tagging"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// TagFilter: baca ?tag=food,verb. Kosong = tanpa filter. Tag yang tidak dikenal -> 400.
+// Dipakai juga oleh endpoint flashcard & ujian di main.
+func TagFilter(c *gin.Context) ([]string, bool) {
+	slugs, err := tagging.ParseFilter(c.Query("tag"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag filter", "detail": err.Error()})
+		return nil, false
+	}
+	if len(slugs) == 0 {
+		return nil, true
+	}
+	var known int64
+	if err := database.DB.Model(&models.Tag{}).Where("slug IN ?", slugs).Count(&known).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return nil, false
+	}
+	if int(known) != len(slugs) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown tag", "tags": slugs})
+		return nil, false
+	}
+	return slugs, true
+}
+
+// Ganti semua tag satu kata. Tag yang tidak dikenal -> gorm.ErrRecordNotFound.
+func setVocabularyTags(tx *gorm.DB, vocabID uint, slugs []string) error {
+	slugs, err := tagging.ParseFilter(strings.Join(slugs, ","))
+	if err != nil {
+		return gorm.ErrRecordNotFound
+	}
+	var tagIDs []uint
+	if len(slugs) > 0 {
+		if err := tx.Model(&models.Tag{}).Where("slug IN ?", slugs).Pluck("id", &tagIDs).Error; err != nil {
+			return err
+		}
+		if len(tagIDs) != len(slugs) {
+			return gorm.ErrRecordNotFound
+		}
+	}
+	if err := tx.Where("vocab_id = ?", vocabID).Delete(&models.VocabularyTag{}).Error; err != nil {
+		return err
+	}
+	// Tag sudah ditetapkan manual: seed tidak akan mengisi ulang (juga kalau dikosongkan)
+	if err := tx.Model(&models.Vocabulary{}).Where("id = ?", vocabID).UpdateColumn("tags_seeded_at", time.Now()).Error; err != nil {
+		return err
+	}
+	if len(tagIDs) == 0 {
+		return nil
+	}
+	links := make([]models.VocabularyTag, 0, len(tagIDs))
+	for _, id := range tagIDs {
+		links = append(links, models.VocabularyTag{VocabID: vocabID, TagID: id})
+	}
+	return tx.Create(&links).Error
+}
+
+type tagRow struct {
+	models.Tag
+	WordCount int64 `json:"word_count"`
+}
+
+// LIST TAGS: ?category=topic|word_class, dengan jumlah kata (kosakata umum)
+func ListTags(c *gin.Context) {
+	query := database.DB.Table("tags t").
+		Select(`t.*, (SELECT COUNT(*) FROM vocabulary_tags vt
+			JOIN vocabularies v ON v.id = vt.vocab_id AND v.deleted_at IS NULL AND v.owner_id IS NULL
+			WHERE vt.tag_id = t.id) AS word_count`)
+	if category := c.Query("category"); category != "" {
+		if !tagging.ValidCategory(category) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category"})
+			return
+		}
+		query = query.Where("t.category = ?", category)
+	}
+	var rows []tagRow
+	if err := query.Order("t.category, t.name").Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rows})
+}
+
+type tagInput struct {
+	Slug     string `json:"slug" binding:"required"`
+	Name     string `json:"name" binding:"required,max=64"`
+	Category string `json:"category" binding:"required"`
+}
+
+func (in tagInput) valid() bool {
+	return tagging.ValidSlug(in.Slug) && tagging.ValidCategory(in.Category)
+}
+
+// CREATE TAG (content)
+func CreateTag(c *gin.Context) {
+	var input tagInput
+	if err := c.ShouldBindJSON(&input); err != nil || !input.valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	tag := models.Tag{Slug: input.Slug, Name: input.Name, Category: input.Category}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&tag).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, audit.Entry{Action: "tag.created", TargetType: "tag", TargetID: tag.ID, After: tag})
+	})
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Tag already exists"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": tag})
+}
+
+// UPDATE TAG (content)
+func UpdateTag(c *gin.Context) {
+	var input tagInput
+	if err := c.ShouldBindJSON(&input); err != nil || !input.valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	var tag models.Tag
+	if err := database.DB.First(&tag, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+		return
+	}
+	// Slug tag seed dipakai untuk mencocokkan seeds/vocab_tags.json, jadi tidak bisa diganti
+	if tag.Seeded && input.Slug != tag.Slug {
+		c.JSON(http.StatusConflict, gin.H{"error": "Seeded tag slug cannot be changed"})
+		return
+	}
+	before := tag
+	tag.Slug, tag.Name, tag.Category = input.Slug, input.Name, input.Category
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&tag).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, audit.Entry{Action: "tag.updated", TargetType: "tag", TargetID: tag.ID, Before: before, After: tag})
+	})
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Tag already exists"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": tag})
+}
+
+// DELETE TAG (content): relasi ke kata ikut dihapus, kata tetap
+func DeleteTag(c *gin.Context) {
+	var tag models.Tag
+	if err := database.DB.First(&tag, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+		return
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tag_id = ?", tag.ID).Delete(&models.VocabularyTag{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&tag).Error; err != nil {
+			return err
+		}
+		return audit.Record(tx, c, audit.Entry{Action: "tag.deleted", TargetType: "tag", TargetID: tag.ID, Before: tag})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Deleted"})
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/models"
+	"kotoba-backend/internal/srs"
+	"kotoba-backend/internal/tagging"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Simpan hasil review sebagai status terakhir user untuk kata itu.
+// Review yang lebih lama dari status tersimpan diabaikan.
+func recordVocabState(tx *gorm.DB, review models.ReviewLog) error {
+	state := models.UserVocabState{
+		UserID: review.UserID, VocabID: review.VocabID, Result: review.Result,
+		ReviewedAt: review.ReviewedAt, DueAt: srs.DueAt(review.Result, review.ReviewedAt),
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "vocab_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"result", "reviewed_at", "due_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Expr{SQL: "user_vocab_states.reviewed_at <= excluded.reviewed_at"},
+		}},
+	}).Create(&state).Error
+}
+
+// --- BROWSE VOCABULARIES ---
+
+type vocabListRow struct {
+	ID              uint       `json:"id"`
+	Kanji           string     `json:"kanji"`
+	Kana            string     `json:"kana"`
+	Romaji          string     `json:"romaji"`
+	Meaning         string     `json:"meaning"`
+	ExampleSentence string     `json:"example_sentence"`
+	DifficultyLevel int        `json:"difficulty_level"`
+	Status          string     `json:"status" gorm:"-"`
+	LastResult      *int       `json:"last_result"` // nil = belum pernah direview
+	LastReviewedAt  *time.Time `json:"last_reviewed_at"`
+	DueAt           *time.Time `json:"due_at"`
+}
+
+// Posisi terakhir di daftar. Hanya field milik urutan yang dipakai yang terisi.
+type vocabCursor struct {
+	ID    uint       `json:"id"`
+	Text  string     `json:"t,omitempty"`
+	Level int        `json:"l,omitempty"`
+	Due   *time.Time `json:"d,omitempty"`
+}
+
+func (cur vocabCursor) encode() string {
+	b, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeVocabCursor(raw string) (*vocabCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, err
+	}
+	var cur vocabCursor
+	if err := json.Unmarshal(b, &cur); err != nil {
+		return nil, err
+	}
+	return &cur, nil
+}
+
+// Urutan daftar: ORDER BY, kondisi keyset "sesudah cursor", dan cursor dari baris terakhir
+type vocabSort struct {
+	orderBy string
+	after   func(cur vocabCursor) string
+	cursor  func(row vocabListRow) vocabCursor
+}
+
+var vocabSorts = map[string]vocabSort{
+	"id": {
+		orderBy: "v.id",
+		after:   func(vocabCursor) string { return "v.id > @id" },
+		cursor:  func(r vocabListRow) vocabCursor { return vocabCursor{ID: r.ID} },
+	},
+	"kana": {
+		orderBy: "v.kana, v.id",
+		after:   func(vocabCursor) string { return "(v.kana, v.id) > (@text, @id)" },
+		cursor:  func(r vocabListRow) vocabCursor { return vocabCursor{ID: r.ID, Text: r.Kana} },
+	},
+	"level": {
+		orderBy: "v.difficulty_level, v.id",
+		after:   func(vocabCursor) string { return "(v.difficulty_level, v.id) > (@level, @id)" },
+		cursor:  func(r vocabListRow) vocabCursor { return vocabCursor{ID: r.ID, Level: r.DifficultyLevel} },
+	},
+	// Yang paling cepat jatuh tempo dulu, kata yang belum pernah direview di akhir
+	"due": {
+		orderBy: "s.due_at NULLS LAST, v.id",
+		after: func(cur vocabCursor) string {
+			if cur.Due == nil {
+				return "s.due_at IS NULL AND v.id > @id"
+			}
+			return "(s.due_at > @due OR (s.due_at = @due AND v.id > @id) OR s.due_at IS NULL)"
+		},
+		cursor: func(r vocabListRow) vocabCursor { return vocabCursor{ID: r.ID, Due: r.DueAt} },
+	},
+}
+
+// ?status= -> kondisi atas user_vocab_states (s)
+var vocabStatusFilters = map[string]string{
+	srs.StatusIngat: "s.result = " + strconv.Itoa(srs.ResultIngat),
+	srs.StatusRagu:  "s.result = " + strconv.Itoa(srs.ResultRagu),
+	srs.StatusLupa:  "s.result = " + strconv.Itoa(srs.ResultLupa),
+	srs.StatusNew:   "s.vocab_id IS NULL",
+	"unseen":        "s.vocab_id IS NULL",
+}
+
+// LIST VOCABULARIES: semua kosakata umum + progres user, cursor-paginated.
+// ?sort=id|kana|level|due &level=1 &tag=food &status=ingat,ragu,lupa,new &due=now|soon &cursor=&limit=50
+func ListVocabularies(c *gin.Context) {
+	userID := getUserID(c)
+
+	sortName := c.DefaultQuery("sort", "id")
+	sort, ok := vocabSorts[sortName]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sort", "allowed": []string{"id", "kana", "level", "due"}})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 200 {
+		limit = 50
+	}
+	tags, ok := TagFilter(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	args := map[string]interface{}{"user": userID, "tags": tags, "now": now, "limit": limit + 1}
+	conds := []string{"v.deleted_at IS NULL", "v.owner_id IS NULL"}
+
+	if raw := c.Query("level"); raw != "" {
+		level, err := strconv.Atoi(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid level"})
+			return
+		}
+		conds = append(conds, "v.difficulty_level = @filter_level")
+		args["filter_level"] = level
+	}
+	if len(tags) > 0 {
+		conds = append(conds, tagging.FilterSQLNamed("v.id", "tags"))
+	}
+	if raw := c.Query("status"); raw != "" {
+		var statuses []string
+		for _, s := range strings.Split(strings.ToLower(raw), ",") {
+			cond, ok := vocabStatusFilters[strings.TrimSpace(s)]
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status", "status": s})
+				return
+			}
+			statuses = append(statuses, cond)
+		}
+		conds = append(conds, "("+strings.Join(statuses, " OR ")+")")
+	}
+	switch c.Query("due") {
+	case "":
+	case "now":
+		conds = append(conds, "s.due_at <= @now")
+	case "soon":
+		conds = append(conds, "s.due_at <= @due_soon")
+		args["due_soon"] = now.Add(dueSoonWindow)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid due filter", "allowed": []string{"now", "soon"}})
+		return
+	}
+	if raw := c.Query("cursor"); raw != "" {
+		cur, err := decodeVocabCursor(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		conds = append(conds, sort.after(*cur))
+		args["id"], args["text"], args["level"], args["due"] = cur.ID, cur.Text, cur.Level, cur.Due
+	}
+
+	// Ambil limit+1 baris untuk tahu masih ada halaman berikutnya
+	var rows []vocabListRow
+	if err := database.DB.Raw(`
+		SELECT v.id, v.kanji, v.kana, v.romaji, v.meaning, v.example_sentence, v.difficulty_level,
+			s.result AS last_result, s.reviewed_at AS last_reviewed_at, s.due_at
+		FROM vocabularies v
+		LEFT JOIN user_vocab_states s ON s.vocab_id = v.id AND s.user_id = @user
+		WHERE `+strings.Join(conds, " AND ")+`
+		ORDER BY `+sort.orderBy+`
+		LIMIT @limit`, args).Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+
+	var next *string
+	if len(rows) > limit {
+		rows = rows[:limit]
+		cur := sort.cursor(rows[len(rows)-1]).encode()
+		next = &cur
+	}
+	for i := range rows {
+		rows[i].Status = srs.StatusOf(rows[i].LastResult)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": rows, "next_cursor": next, "limit": limit, "sort": sortName})
+}
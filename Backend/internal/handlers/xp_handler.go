@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"kotoba-backend/internal/achievement"
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/kana"
+	"kotoba-backend/internal/models"
+	"kotoba-backend/internal/progression"
+	"kotoba-backend/internal/streak"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Catat XP untuk satu event. Event yang sama (source + sourceID) hanya dihitung sekali;
+// return 0 kalau sudah pernah diberikan. Jumlahnya dipotong ke sisa batas harian sumber itu.
+func awardXP(tx *gorm.DB, userID uint, source string, sourceID uint, amount int) (int, error) {
+	return awardXPKey(tx, userID, source, strconv.FormatUint(uint64(sourceID), 10), amount)
+}
+
+func awardXPKey(tx *gorm.DB, userID uint, source, sourceID string, amount int) (int, error) {
+	if limit := progression.DailyCap(source); limit > 0 && amount > 0 {
+		// Kunci baris user supaya award paralel tidak bersama-sama melewati batas.
+		// "Hari" batas XP sama dengan hari streak: menurut timezone user.
+		var timezone string
+		if err := tx.Raw("SELECT COALESCE(timezone, '') FROM users WHERE id = ? FOR UPDATE", userID).Scan(&timezone).Error; err != nil {
+			return 0, err
+		}
+		dayStart := streak.DayStart(time.Now(), streak.Location(timezone))
+		var used int
+		if err := tx.Model(&models.XPEvent{}).
+			Where("user_id = ? AND source = ? AND created_at >= ?", userID, source, dayStart).
+			Select("COALESCE(SUM(amount), 0)").Scan(&used).Error; err != nil {
+			return 0, err
+		}
+		if amount > limit-used {
+			amount = limit - used
+		}
+	}
+	if amount <= 0 {
+		return 0, nil
+	}
+	event := models.XPEvent{UserID: userID, Source: source, SourceID: sourceID, Amount: amount}
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&event)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, nil
+	}
+	return amount, nil
+}
+
+func totalXP(userID uint) (int64, error) {
+	var xp int64
+	err := database.DB.Model(&models.XPEvent{}).Where("user_id = ?", userID).
+		Select("COALESCE(SUM(amount), 0)").Scan(&xp).Error
+	return xp, err
+}
+
+// XPProgress: total XP + rank user saat ini (dipakai juga oleh /api/stats dan /api/profile)
+func XPProgress(userID uint) (progression.Progress, error) {
+	xp, err := totalXP(userID)
+	if err != nil {
+		return progression.Progress{}, err
+	}
+	return progression.For(xp), nil
+}
+
+// GET XP: progres rank + riwayat ledger
+func GetMyXP(c *gin.Context) {
+	userID := getUserID(c)
+	page, limit := pagination(c)
+
+	progress, err := XPProgress(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+
+	query := database.DB.Model(&models.XPEvent{}).Where("user_id = ?", userID)
+	if v := c.Query("source"); v != "" {
+		query = query.Where("source = ?", v)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var events []models.XPEvent
+	if err := query.Order("id DESC").Offset((page - 1) * limit).Limit(limit).Find(&events).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"progress": progress,
+		"ranks":    progression.Ranks(),
+		"data":     events,
+		"page":     page,
+		"limit":    limit,
+		"total":    total,
+	})
+}
+
+// Progres satu huruf kana; huruf di luar tabel kana diabaikan
+func recordKanaAnswer(tx *gorm.DB, userID uint, ch string, correct bool) error {
+	script := kana.ScriptOf(ch)
+	if script == "" {
+		return nil
+	}
+	right, wrong := 0, 1
+	if correct {
+		right, wrong = 1, 0
+	}
+	row := models.KanaProgress{UserID: userID, Kana: ch, Script: script, CorrectCount: right, WrongCount: wrong, LastCorrect: correct}
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "kana"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"correct_count": gorm.Expr("kana_progresses.correct_count + ?", right),
+			"wrong_count":   gorm.Expr("kana_progresses.wrong_count + ?", wrong),
+			"last_correct":  correct,
+			"updated_at":    time.Now(),
+		}),
+	}).Create(&row).Error
+}
+
+// SUBMIT KANA DRILL: hasil latihan hiragana/katakana. Idempotent per drill_id.
+func SubmitKanaDrill(c *gin.Context) {
+	var input struct {
+		DrillID string `json:"drill_id" binding:"required,max=64"`
+		Script  string `json:"script" binding:"required,oneof=hiragana katakana"`
+		Correct int    `json:"correct" binding:"gte=0"`
+		Total   int    `json:"total" binding:"required,gt=0,lte=500"`
+
+		DurationSeconds int `json:"duration_seconds" binding:"gte=0,lte=3600"`
+
+		// Opsional: jawaban per huruf, untuk progres hafalan kana
+		Answers []struct {
+			Kana    string `json:"kana" binding:"required"`
+			Correct bool   `json:"correct"`
+		} `json:"answers" binding:"max=500,dive"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || input.Correct > input.Total {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	// Kalau jawaban per huruf dikirim, skor dihitung dari situ (bukan angka dari client)
+	if len(input.Answers) > 0 {
+		input.Correct, input.Total = 0, len(input.Answers)
+		for _, a := range input.Answers {
+			if a.Correct {
+				input.Correct++
+			}
+		}
+	}
+
+	userID := getUserID(c)
+	drill := models.KanaDrillResult{
+		UserID: userID, DrillID: input.DrillID, Script: input.Script,
+		Correct: input.Correct, Total: input.Total,
+	}
+
+	gained := 0
+	var streakUpdate *streak.Update
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&drill)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			// Retry dari client: kembalikan hasil yang sudah tersimpan
+			return tx.Where("user_id = ? AND drill_id = ?", userID, input.DrillID).First(&drill).Error
+		}
+		var err error
+		gained, err = awardXPKey(tx, userID, progression.SourceKanaDrill, drill.DrillID,
+			progression.KanaDrillXP(drill.Correct, drill.Total))
+		if err != nil {
+			return err
+		}
+		for _, a := range input.Answers {
+			if err := recordKanaAnswer(tx, userID, a.Kana, a.Correct); err != nil {
+				return err
+			}
+		}
+		streakUpdate, err = recordStudyActivity(tx, userID, 0, streak.CapSeconds(input.DurationSeconds, drill.Total))
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save"})
+		return
+	}
+
+	unlocked := dispatchAchievementEvents(userID, studyEvents(streakUpdate, achievement.EventKanaDrill)...)
+
+	resp := gin.H{"data": drill, "exp_gained": gained, "streak": streakUpdate, "achievements": unlocked}
+	if progress, err := XPProgress(userID); err == nil {
+		resp["xp"] = progress
+	}
+	c.JSON(http.StatusCreated, resp)
+}
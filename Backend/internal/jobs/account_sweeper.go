@@ -0,0 +1,204 @@
+package jobs
+
+import (
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/models"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const defaultDeletionGraceDays = 30
+
+// Masa tenggang sebelum akun yang dihapus dibersihkan permanen
+func DeletionGracePeriod() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("ACCOUNT_DELETION_GRACE_DAYS"))
+	if err != nil || days < 0 {
+		days = defaultDeletionGraceDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// PurgeDeletedAccounts: hapus review_logs, chat + user yang soft-delete-nya sudah lewat masa tenggang
+func PurgeDeletedAccounts() error {
+	cutoff := time.Now().Add(-DeletionGracePeriod())
+
+	var ids []uint
+	if err := database.DB.Unscoped().Model(&models.User{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if err := purgeAccount(id); err != nil {
+			return err
+		}
+		log.Printf("[INFO] Purged account %d", id)
+	}
+	return nil
+}
+
+// Semua data satu user dihapus dalam satu transaksi: gagal di tengah = tidak ada yang terhapus,
+// jadi run berikutnya mengulang dari awal. File export dihapus setelah commit.
+func purgeAccount(id uint) error {
+	var files []string
+	if err := database.DB.Model(&models.ExportJob{}).Where("user_id = ? AND file_path <> ''", id).
+		Pluck("file_path", &files).Error; err != nil {
+		return err
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", id).Delete(&models.ReviewLog{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.UserVocabState{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("conversation_id IN (?)",
+			tx.Model(&models.Conversation{}).Select("id").Where("user_id = ?", id)).
+			Delete(&models.ChatMessage{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.DeckSubscription{}).Error; err != nil {
+			return err
+		}
+		owned := tx.Unscoped().Model(&models.Deck{}).Select("id").Where("owner_id = ?", id)
+		if err := tx.Where("deck_id IN (?)", owned).Delete(&models.ClassroomAssignment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("deck_id IN (?)", owned).Delete(&models.DeckSubscription{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("deck_id IN (?)", owned).Delete(&models.DeckChange{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("deck_id IN (?)", owned).Delete(&models.DeckCard{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("owner_id = ?", id).Delete(&models.Deck{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("vocab_id IN (?)", tx.Unscoped().Model(&models.Vocabulary{}).Select("id").Where("owner_id = ?", id)).
+			Delete(&models.VocabularyTag{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("owner_id = ?", id).Delete(&models.Vocabulary{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.ClassroomMember{}).Error; err != nil {
+			return err
+		}
+		taught := tx.Model(&models.Classroom{}).Select("id").Where("teacher_id = ?", id)
+		if err := tx.Where("classroom_id IN (?)", taught).Delete(&models.ClassroomAssignment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("classroom_id IN (?)", taught).Delete(&models.ClassroomMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("teacher_id = ?", id).Delete(&models.Classroom{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.ActivityEvent{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("requester_id = ? OR addressee_id = ?", id, id).Delete(&models.Friendship{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("follower_id = ? OR followee_id = ?", id, id).Delete(&models.Follow{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("blocker_id = ? OR blocked_id = ?", id, id).Delete(&models.Block{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.LeagueMembership{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.UserLeague{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.LeaderboardEntry{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.PrivacySettings{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.UserAchievement{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.KanaProgress{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.UserStreak{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.StudyDay{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.XPEvent{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.KanaDrillResult{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.ExamResult{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.ExamSession{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.UserPrediction{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.UserPredictionHistory{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.ChatGuardEvent{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.RoleplayReport{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.RoleplaySession{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.StudyQueueItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.SenseiQuiz{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.Conversation{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.ExportJob{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.LLMUsage{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.LLMBudgetCounter{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.OIDCPendingLink{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.UserIdentity{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&models.User{}, id).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, path := range files {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("[WARN] Remove export file %s failed: %v", path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package jobs
+
+import (
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/models"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// File export disimpan 24 jam sebelum dihapus
+const ExportRetention = 24 * time.Hour
+
+func ExportDir() string {
+	if dir := os.Getenv("EXPORT_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.TempDir(), "kaiwa-exports")
+}
+
+// PurgeExpiredExports: hapus file export kadaluarsa
+func PurgeExpiredExports() error {
+	var expired []models.ExportJob
+	if err := database.DB.Where("status = ? AND expires_at < ?", models.ExportDone, time.Now()).Find(&expired).Error; err != nil {
+		return err
+	}
+
+	for _, job := range expired {
+		if err := os.Remove(job.FilePath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err := database.DB.Delete(&job).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FailInterruptedExports: dipanggil sekali saat start, sebelum ada export baru. Job pending/running
+// yang tersisa berasal dari proses sebelumnya (restart/crash) dan tidak akan selesai.
+func FailInterruptedExports() error {
+	res := database.DB.Model(&models.ExportJob{}).
+		Where("status IN ?", []string{models.ExportPending, models.ExportRunning}).
+		Updates(map[string]interface{}{"status": models.ExportFailed, "error": "Export interrupted", "completed_at": time.Now()})
+	if res.RowsAffected > 0 {
+		log.Printf("[INFO] Marked %d interrupted export jobs as failed", res.RowsAffected)
+	}
+	return res.Error
+}
@@ -0,0 +1,91 @@
+package jobs
+
+import (
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/env"
+	"kotoba-backend/internal/league"
+	"kotoba-backend/internal/models"
+	"kotoba-backend/internal/streak"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LeaderboardInterval: jarak refresh snapshot papan peringkat (LEADERBOARD_REFRESH_MINUTES, default 10)
+func LeaderboardInterval() time.Duration {
+	return time.Duration(env.PositiveInt("LEADERBOARD_REFRESH_MINUTES", 10)) * time.Minute
+}
+
+// XP minggu ini (minggu UTC, lihat league.WeekStart)
+const weeklyXPSnapshotQuery = `
+	INSERT INTO leaderboard_entries (board, user_id, score, computed_at)
+	SELECT ?, x.user_id, SUM(x.amount), ?
+	FROM xp_events x
+	JOIN users u ON u.id = x.user_id AND u.deleted_at IS NULL AND u.locked_at IS NULL
+	WHERE x.created_at >= ?
+	GROUP BY x.user_id
+	HAVING SUM(x.amount) > 0`
+
+// Jumlah kata dengan status terakhir Ingat (user_vocab_states), semua user sekaligus
+const masteredSnapshotQuery = `
+	INSERT INTO leaderboard_entries (board, user_id, score, computed_at)
+	SELECT ?, s.user_id, COUNT(*), ?
+	FROM user_vocab_states s
+	JOIN users u ON u.id = s.user_id AND u.deleted_at IS NULL AND u.locked_at IS NULL
+	WHERE s.result = 2
+	GROUP BY s.user_id`
+
+// RefreshLeaderboards: hitung ulang snapshot weekly XP, kata dikuasai, dan streak
+func RefreshLeaderboards() error {
+	now := time.Now()
+
+	err := replaceBoard(league.BoardWeeklyXP, func(tx *gorm.DB) error {
+		return tx.Exec(weeklyXPSnapshotQuery, league.BoardWeeklyXP, now, league.WeekStart(now)).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	err = replaceBoard(league.BoardMastered, func(tx *gorm.DB) error {
+		return tx.Exec(masteredSnapshotQuery, league.BoardMastered, now).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	// Streak "hidup" tergantung timezone masing-masing user, jadi dihitung di Go
+	var rows []struct {
+		models.UserStreak
+		Timezone string
+	}
+	if err := database.DB.Table("user_streaks s").
+		Select("s.*, u.timezone").
+		Joins("JOIN users u ON u.id = s.user_id AND u.deleted_at IS NULL AND u.locked_at IS NULL").
+		Where("s.current > 0").Scan(&rows).Error; err != nil {
+		return err
+	}
+	var entries []models.LeaderboardEntry
+	for _, r := range rows {
+		state := streak.State{Current: r.Current, Longest: r.Longest, LastGoalDay: r.LastGoalDay, Freezes: r.Freezes}
+		if st := state.View(streak.Day(now, streak.Location(r.Timezone))); st.Current > 0 {
+			entries = append(entries, models.LeaderboardEntry{
+				Board: league.BoardStreak, UserID: r.UserID, Score: int64(st.Current), ComputedAt: now,
+			})
+		}
+	}
+	return replaceBoard(league.BoardStreak, func(tx *gorm.DB) error {
+		if len(entries) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&entries, 500).Error
+	})
+}
+
+func replaceBoard(board string, fill func(tx *gorm.DB) error) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("board = ?", board).Delete(&models.LeaderboardEntry{}).Error; err != nil {
+			return err
+		}
+		return fill(tx)
+	})
+}
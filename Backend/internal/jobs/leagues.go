@@ -0,0 +1,169 @@
+package jobs
+
+import (
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/env"
+	"kotoba-backend/internal/league"
+	"kotoba-backend/internal/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LeagueInterval: seberapa sering job mengecek pergantian minggu (LEAGUE_CHECK_MINUTES, default 60)
+func LeagueInterval() time.Duration {
+	return time.Duration(env.PositiveInt("LEAGUE_CHECK_MINUTES", 60)) * time.Minute
+}
+
+// JoinLeague: masukkan user ke grup liga minggu `week` sesuai tier-nya.
+// Grup diisi berurutan sampai penuh (league.GroupSize). Idempotent.
+func JoinLeague(userID uint, week string) (models.LeagueMembership, error) {
+	var m models.LeagueMembership
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("week = ? AND user_id = ?", week, userID).First(&m).Error; err == nil {
+			return nil
+		} else if err != gorm.ErrRecordNotFound {
+			return err
+		}
+
+		var ul models.UserLeague
+		if err := tx.Where("user_id = ?", userID).First(&ul).Error; err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+
+		// Kunci per (minggu, tier) supaya dua user tidak mengisi slot terakhir yang sama
+		if err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext(?))`, week+"/"+league.TierName(ul.Tier)).Error; err != nil {
+			return err
+		}
+
+		var group struct {
+			GroupNo int
+			Members int
+		}
+		if err := tx.Raw(`
+			SELECT group_no, COUNT(*) AS members FROM league_memberships
+			WHERE week = ? AND tier = ?
+			GROUP BY group_no ORDER BY group_no DESC LIMIT 1`, week, ul.Tier).Scan(&group).Error; err != nil {
+			return err
+		}
+		groupNo := group.GroupNo
+		if group.Members == 0 || group.Members >= league.GroupSize() {
+			groupNo++
+		}
+
+		m = models.LeagueMembership{Week: week, UserID: userID, Tier: ul.Tier, GroupNo: groupNo}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&m).Error; err != nil {
+			return err
+		}
+		// Baca ulang: kalau request lain lebih dulu memasukkan user, baris itu yang berlaku
+		return tx.Where("week = ? AND user_id = ?", week, userID).First(&m).Error
+	})
+	return m, err
+}
+
+type LeagueStanding struct {
+	MembershipID uint  `json:"-"`
+	UserID       uint  `json:"user_id"`
+	Tier         int   `json:"-"`
+	XP           int64 `json:"xp"`
+}
+
+// XP minggu itu untuk anggota satu grup (tanpa akun terkunci / terhapus), urut peringkat
+func GroupStandings(tx *gorm.DB, week string, tier, groupNo int) ([]LeagueStanding, error) {
+	start, end, err := league.WeekRange(week)
+	if err != nil {
+		return nil, err
+	}
+	var rows []LeagueStanding
+	err = tx.Raw(`
+		SELECT m.id AS membership_id, m.user_id, m.tier, COALESCE(SUM(x.amount), 0) AS xp
+		FROM league_memberships m
+		JOIN users u ON u.id = m.user_id AND u.deleted_at IS NULL AND u.locked_at IS NULL
+		LEFT JOIN xp_events x ON x.user_id = m.user_id AND x.created_at >= ? AND x.created_at < ?
+		WHERE m.week = ? AND m.tier = ? AND m.group_no = ?
+		GROUP BY m.id, m.user_id, m.tier
+		ORDER BY xp DESC, m.id`, start, end, week, tier, groupNo).Scan(&rows).Error
+	return rows, err
+}
+
+// RolloverLeagues: tutup minggu yang sudah lewat (promosi/degradasi), lalu daftarkan
+// user yang aktif minggu lalu atau sudah dapat XP minggu ini ke liga minggu ini.
+func RolloverLeagues() error {
+	now := time.Now()
+	current := league.WeekKey(now)
+
+	var weeks []string
+	if err := database.DB.Model(&models.LeagueMembership{}).
+		Where("week < ? AND closed_at IS NULL", current).
+		Distinct("week").Order("week").Pluck("week", &weeks).Error; err != nil {
+		return err
+	}
+	for _, week := range weeks {
+		if err := closeLeagueWeek(week, now); err != nil {
+			return err
+		}
+		log.Printf("[INFO] League week %s closed", week)
+	}
+
+	// Aktif minggu lalu, atau sudah dapat XP minggu ini
+	previous := league.WeekKey(now.AddDate(0, 0, -7))
+	var ids []uint
+	if err := database.DB.Raw(`
+		SELECT u.id FROM users u
+		WHERE u.deleted_at IS NULL AND u.locked_at IS NULL
+			AND (u.id IN (SELECT user_id FROM league_memberships WHERE week = ? AND final_xp > 0)
+				OR u.id IN (SELECT user_id FROM xp_events WHERE created_at >= ?))
+			AND u.id NOT IN (SELECT user_id FROM league_memberships WHERE week = ?)`,
+		previous, league.WeekStart(now), current).Scan(&ids).Error; err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if _, err := JoinLeague(id, current); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func closeLeagueWeek(week string, now time.Time) error {
+	var groups []struct {
+		Tier    int
+		GroupNo int
+	}
+	if err := database.DB.Model(&models.LeagueMembership{}).
+		Select("DISTINCT tier, group_no").
+		Where("week = ? AND closed_at IS NULL", week).Scan(&groups).Error; err != nil {
+		return err
+	}
+
+	for _, g := range groups {
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			standings, err := GroupStandings(tx, week, g.Tier, g.GroupNo)
+			if err != nil {
+				return err
+			}
+			for i, s := range standings {
+				outcome, tier := league.Outcome(i+1, len(standings), s.Tier, s.XP)
+				if err := tx.Model(&models.LeagueMembership{}).Where("id = ?", s.MembershipID).Updates(map[string]interface{}{
+					"final_xp": s.XP, "final_rank": i + 1, "outcome": outcome, "closed_at": now,
+				}).Error; err != nil {
+					return err
+				}
+				ul := models.UserLeague{UserID: s.UserID, Tier: tier}
+				if err := tx.Save(&ul).Error; err != nil {
+					return err
+				}
+			}
+			// Akun terkunci / terhapus tidak ikut peringkat, cukup ditutup di tier yang sama
+			return tx.Model(&models.LeagueMembership{}).
+				Where("week = ? AND tier = ? AND group_no = ? AND closed_at IS NULL", week, g.Tier, g.GroupNo).
+				Updates(map[string]interface{}{"final_xp": 0, "outcome": league.OutcomeStayed, "closed_at": now}).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
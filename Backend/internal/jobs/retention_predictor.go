@@ -0,0 +1,152 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/env"
+	"kotoba-backend/internal/mlclient"
+	"kotoba-backend/internal/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultPredictionBatchSize  = 200
+	defaultPredictionActiveDays = 30
+)
+
+// PredictionInterval: jarak antar run job prediksi (PREDICTION_INTERVAL_HOURS, default 6)
+func PredictionInterval() time.Duration {
+	return time.Duration(env.PositiveInt("PREDICTION_INTERVAL_HOURS", 6)) * time.Hour
+}
+
+type predictionState struct {
+	UserID       uint
+	TotalLearned int
+	IngatCount   int
+	RaguCount    int
+	LupaCount    int
+	DueNow       int
+	Due24h       int
+	Due7d        int
+}
+
+// Status terakhir per kata (user_vocab_states) + forecast jatuh tempo, untuk sekumpulan user sekaligus
+const predictionStateQuery = `
+	SELECT user_id,
+		COUNT(*) AS total_learned,
+		COUNT(*) FILTER (WHERE result = 2) AS ingat_count,
+		COUNT(*) FILTER (WHERE result = 1) AS ragu_count,
+		COUNT(*) FILTER (WHERE result = 0) AS lupa_count,
+		COUNT(*) FILTER (WHERE due_at <= ?) AS due_now,
+		COUNT(*) FILTER (WHERE due_at <= ?) AS due24h,
+		COUNT(*) FILTER (WHERE due_at <= ?) AS due7d
+	FROM user_vocab_states
+	WHERE user_id IN ?
+	GROUP BY user_id`
+
+// PredictRetention: prediksi retensi + forecast jatuh tempo untuk semua user aktif
+// (review dalam PREDICTION_ACTIVE_DAYS hari terakhir), per PREDICTION_BATCH_SIZE user.
+func PredictRetention() error {
+	batchSize := env.PositiveInt("PREDICTION_BATCH_SIZE", defaultPredictionBatchSize)
+	since := time.Now().AddDate(0, 0, -env.PositiveInt("PREDICTION_ACTIVE_DAYS", defaultPredictionActiveDays))
+
+	var lastID uint
+	total := 0
+	for {
+		var ids []uint
+		err := database.DB.Raw(`
+			SELECT u.id FROM users u
+			WHERE u.deleted_at IS NULL AND u.locked_at IS NULL AND u.id > ?
+				AND EXISTS (SELECT 1 FROM review_logs r WHERE r.user_id = u.id AND r.reviewed_at >= ?)
+			ORDER BY u.id
+			LIMIT ?`, lastID, since, batchSize).Scan(&ids).Error
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		if err := predictBatch(ids); err != nil {
+			return err
+		}
+		total += len(ids)
+		lastID = ids[len(ids)-1]
+	}
+
+	log.Printf("[INFO] Retention predicted for %d users", total)
+	return nil
+}
+
+func predictBatch(ids []uint) error {
+	now := time.Now()
+
+	var states []predictionState
+	if err := database.DB.Raw(predictionStateQuery, now, now.Add(24*time.Hour), now.Add(7*24*time.Hour), ids).
+		Scan(&states).Error; err != nil {
+		return err
+	}
+	if len(states) == 0 {
+		return nil
+	}
+
+	items := make([]mlclient.BatchItem, 0, len(states))
+	byUser := make(map[uint]predictionState, len(states))
+	for _, s := range states {
+		byUser[s.UserID] = s
+		items = append(items, mlclient.BatchItem{UserID: s.UserID, Stats: mlclient.StatsInput{
+			TotalLearned: s.TotalLearned, IngatCount: s.IngatCount, RaguCount: s.RaguCount, LupaCount: s.LupaCount,
+		}})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	results, err := mlclient.Default().PredictRetentionBatch(ctx, items)
+	if err != nil {
+		return err
+	}
+
+	utc := now.UTC()
+	day := time.Date(utc.Year(), utc.Month(), utc.Day(), 0, 0, 0, 0, time.UTC)
+	preds := make([]models.UserPrediction, 0, len(results))
+	history := make([]models.UserPredictionHistory, 0, len(results))
+	for _, r := range results {
+		s, ok := byUser[r.UserID]
+		if !ok {
+			continue
+		}
+		graph := models.JSONText("[]")
+		if b, err := json.Marshal(r.GraphData); err == nil {
+			graph = models.JSONText(b)
+		}
+		preds = append(preds, models.UserPrediction{
+			UserID: s.UserID, TotalLearned: s.TotalLearned,
+			IngatCount: s.IngatCount, RaguCount: s.RaguCount, LupaCount: s.LupaCount,
+			RetentionRate: r.RetentionRate, Status: r.Status, DecayRisk: r.DecayRisk,
+			NextReviewHours: r.NextReviewHours, GraphData: graph,
+			DueNow: s.DueNow, Due24h: s.Due24h, Due7d: s.Due7d, ComputedAt: now,
+		})
+		history = append(history, models.UserPredictionHistory{
+			UserID: s.UserID, Day: day, RetentionRate: r.RetentionRate, DecayRisk: r.DecayRisk,
+			TotalLearned: s.TotalLearned, DueNow: s.DueNow, Due7d: s.Due7d, ComputedAt: now,
+		})
+	}
+	if len(preds) == 0 {
+		return nil
+	}
+
+	if err := database.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(&preds).Error; err != nil {
+		return err
+	}
+	// Satu baris per hari: run berikutnya di hari yang sama menimpa nilainya
+	return database.DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "day"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"retention_rate", "decay_risk", "total_learned", "due_now", "due7d", "computed_at",
+		}),
+	}).Create(&history).Error
+}
@@ -0,0 +1,25 @@
+package jobs
+
+import (
+	"log"
+	"time"
+)
+
+// Every menjalankan fn secara periodik di goroutine sendiri.
+// Run pertama langsung dijalankan saat start.
+func Every(name string, interval time.Duration, fn func() error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			start := time.Now()
+			if err := fn(); err != nil {
+				log.Printf("[ERROR] Job %s failed: %v", name, err)
+			} else {
+				log.Printf("[INFO] Job %s done in %s", name, time.Since(start).Round(time.Millisecond))
+			}
+			<-ticker.C
+		}
+	}()
+}
@@ -0,0 +1,42 @@
+package jobs
+
+import (
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/models"
+	"kotoba-backend/internal/srs"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const vocabStatesMigration = "user_vocab_states_backfill"
+
+// BackfillVocabStates: isi user_vocab_states dari review_logs, sekali saja (dicatat di data_migrations).
+// Setelah itu recordVocabState yang menjaga tabel tetap terbaru, jadi start berikutnya tidak
+// perlu memindai seluruh review_logs lagi.
+func BackfillVocabStates() error {
+	var done int64
+	if err := database.DB.Model(&models.DataMigration{}).Where("name = ?", vocabStatesMigration).Count(&done).Error; err != nil {
+		return err
+	}
+	if done > 0 {
+		return nil
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec(`
+			INSERT INTO user_vocab_states (user_id, vocab_id, result, reviewed_at, due_at)
+			SELECT DISTINCT ON (user_id, vocab_id) user_id, vocab_id, result, reviewed_at, ` + srs.DueAtSQL + `
+			FROM review_logs
+			ORDER BY user_id, vocab_id, reviewed_at DESC
+			ON CONFLICT (user_id, vocab_id) DO UPDATE
+				SET result = excluded.result, reviewed_at = excluded.reviewed_at, due_at = excluded.due_at
+				WHERE user_vocab_states.reviewed_at < excluded.reviewed_at`)
+		if result.Error != nil {
+			return result.Error
+		}
+		log.Printf("[INFO] Backfilled %d vocabulary states", result.RowsAffected)
+		return tx.Create(&models.DataMigration{Name: vocabStatesMigration, CompletedAt: time.Now()}).Error
+	})
+}
@@ -0,0 +1,99 @@
+package jobs
+
+import (
+	"kotoba-backend/internal/database"
+	"kotoba-backend/internal/models"
+	"kotoba-backend/internal/tagging"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SeedVocabularyTags: isi tags + vocabulary_tags dari seeds/vocab_tags.json.
+// Dijalankan saat start. Nama/kategori tag selalu disamakan dengan seed, tapi kata yang
+// tag-nya sudah pernah ditetapkan (tags_seeded_at, termasuk yang sengaja dikosongkan lewat
+// content API) tidak disentuh lagi.
+func SeedVocabularyTags() error {
+	seed, err := tagging.Load(tagging.Path())
+	if err != nil {
+		return err
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		tags := make([]models.Tag, 0, len(seed.Tags))
+		for _, t := range seed.Tags {
+			tags = append(tags, models.Tag{Slug: t.Slug, Name: t.Name, Category: t.Category, Seeded: true})
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "slug"}},
+			DoUpdates: clause.AssignmentColumns([]string{"name", "category", "seeded", "updated_at"}),
+		}).Create(&tags).Error; err != nil {
+			return err
+		}
+
+		var tagRows []models.Tag
+		if err := tx.Find(&tagRows).Error; err != nil {
+			return err
+		}
+		tagIDs := make(map[string]uint, len(tagRows))
+		for _, t := range tagRows {
+			tagIDs[t.Slug] = t.ID
+		}
+
+		// Kata yang sudah bertag dari sebelum kolom tags_seeded_at ada
+		if err := tx.Exec(`
+			UPDATE vocabularies v SET tags_seeded_at = NOW()
+			WHERE v.tags_seeded_at IS NULL
+				AND EXISTS (SELECT 1 FROM vocabulary_tags vt WHERE vt.vocab_id = v.id)`).Error; err != nil {
+			return err
+		}
+
+		// Kosakata umum yang tag-nya belum pernah ditetapkan
+		var vocabs []struct {
+			ID     uint
+			Kanji  string
+			Kana   string
+			Romaji string
+		}
+		if err := tx.Raw(`
+			SELECT id, kanji, kana, romaji FROM vocabularies v
+			WHERE v.deleted_at IS NULL AND v.owner_id IS NULL AND v.tags_seeded_at IS NULL`).Scan(&vocabs).Error; err != nil {
+			return err
+		}
+		if len(vocabs) == 0 {
+			return nil
+		}
+		byKey := make(map[[3]string][]uint, len(vocabs))
+		for _, v := range vocabs {
+			key := [3]string{v.Kanji, v.Kana, v.Romaji}
+			byKey[key] = append(byKey[key], v.ID)
+		}
+
+		var links []models.VocabularyTag
+		var seeded []uint
+		for _, w := range seed.Words {
+			for _, id := range byKey[[3]string{w.Kanji, w.Kana, w.Romaji}] {
+				seeded = append(seeded, id)
+				for _, slug := range w.Tags {
+					links = append(links, models.VocabularyTag{VocabID: id, TagID: tagIDs[slug]})
+				}
+			}
+		}
+		if len(seeded) == 0 {
+			return nil
+		}
+		if len(links) > 0 {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&links, 500).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&models.Vocabulary{}).Where("id IN ?", seeded).
+			UpdateColumn("tags_seeded_at", time.Now()).Error; err != nil {
+			return err
+		}
+		log.Printf("[INFO] Tagged vocabularies from seed (%d links)", len(links))
+		return nil
+	})
+}
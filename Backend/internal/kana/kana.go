@@ -0,0 +1,83 @@
+package kana
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+)
+
+const (
+	Hiragana = "hiragana"
+	Katakana = "katakana"
+)
+
+// Kana dianggap hafal setelah dijawab benar sebanyak ini dan jawaban terakhir benar
+const MasteryCorrect = 3
+
+var (
+	loadOnce sync.Once
+	sets     map[string][]string
+)
+
+// File tabel kana: KANA_FILE (default seeds/kana.json, sama dengan tabel di frontend)
+func path() string {
+	if v := os.Getenv("KANA_FILE"); v != "" {
+		return v
+	}
+	return "seeds/kana.json"
+}
+
+// Load: huruf dasar (seion) per aksara; dakuon & yoon tidak dihitung untuk "semua hiragana"
+func Load(file string) (map[string][]string, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string][][]string
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", file, err)
+	}
+
+	out := map[string][]string{}
+	for _, script := range []string{Hiragana, Katakana} {
+		for _, row := range raw[script+"_seion"] {
+			for _, ch := range row {
+				if ch != "" {
+					out[script] = append(out[script], ch)
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+func ensureLoaded() {
+	loadOnce.Do(func() {
+		var err error
+		if sets, err = Load(path()); err != nil {
+			log.Printf("[WARN] Kana table not loaded: %v", err)
+			sets = map[string][]string{}
+		}
+	})
+}
+
+// Chars: semua huruf dasar satu aksara
+func Chars(script string) []string {
+	ensureLoaded()
+	return sets[script]
+}
+
+// ScriptOf: aksara dari satu huruf ("" kalau tidak ada di tabel)
+func ScriptOf(ch string) string {
+	ensureLoaded()
+	for script, chars := range sets {
+		for _, c := range chars {
+			if c == ch {
+				return script
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,80 @@
+package league
+
+import (
+	"kotoba-backend/internal/env"
+	"time"
+)
+
+// Tier liga dari terendah ke tertinggi
+var Tiers = []string{"Dou", "Gin", "Kin", "Hakkin", "Kongou"}
+
+// Papan peringkat
+const (
+	BoardWeeklyXP = "weekly_xp"
+	BoardMastered = "mastered"
+	BoardStreak   = "streak"
+)
+
+var Boards = []string{BoardWeeklyXP, BoardMastered, BoardStreak}
+
+func ValidBoard(b string) bool {
+	for _, v := range Boards {
+		if v == b {
+			return true
+		}
+	}
+	return false
+}
+
+// Hasil akhir minggu untuk satu anggota liga
+const (
+	OutcomePromoted = "promoted"
+	OutcomeDemoted  = "demoted"
+	OutcomeStayed   = "stayed"
+)
+
+const weekLayout = "2006-01-02"
+
+// WeekStart: Senin 00:00 UTC minggu berjalan. Liga & weekly XP memakai minggu UTC yang sama untuk semua user.
+func WeekStart(t time.Time) time.Time {
+	t = t.UTC()
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.UTC)
+}
+
+func WeekKey(t time.Time) string {
+	return WeekStart(t).Format(weekLayout)
+}
+
+// WeekRange: [start, end) dari week key
+func WeekRange(key string) (time.Time, time.Time, error) {
+	start, err := time.Parse(weekLayout, key)
+	if err != nil {
+		return start, start, err
+	}
+	return start, start.AddDate(0, 0, 7), nil
+}
+
+// Ukuran grup & jumlah promosi/degradasi (LEAGUE_GROUP_SIZE=30, LEAGUE_PROMOTE=5, LEAGUE_DEMOTE=5)
+func GroupSize() int { return env.Int("LEAGUE_GROUP_SIZE", 30) }
+func Promote() int   { return env.Int("LEAGUE_PROMOTE", 5) }
+func Demote() int    { return env.Int("LEAGUE_DEMOTE", 5) }
+
+// Outcome untuk peringkat `rank` (1-based) dari `size` anggota di tier `tier`.
+// Anggota tanpa XP minggu itu tidak naik. Tier teratas tidak bisa naik, terbawah tidak bisa turun.
+func Outcome(rank, size, tier int, xp int64) (string, int) {
+	switch {
+	case rank <= Promote() && xp > 0 && tier < len(Tiers)-1:
+		return OutcomePromoted, tier + 1
+	case rank > size-Demote() && rank > Promote() && tier > 0:
+		return OutcomeDemoted, tier - 1
+	}
+	return OutcomeStayed, tier
+}
+
+func TierName(tier int) string {
+	if tier < 0 || tier >= len(Tiers) {
+		return Tiers[0]
+	}
+	return Tiers[tier]
+}
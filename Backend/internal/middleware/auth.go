@@ -57,3 +57,35 @@ func GenerateToken(userID uint) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
 }
+
+// Token sesi yang dibaca AuthMiddleware (claim user_id + tv + role).
+// tv = TokenVersion user, dipakai untuk revoke sesi lain.
+// role hanya untuk UI, server tetap cek role dari DB tiap request.
+func IssueToken(userID uint, tokenVersion int, role string) (string, error) {
+	return signSessionToken(sessionClaims(userID, tokenVersion, role))
+}
+
+// Claim waktu login lewat IdP. Token OIDC yang masih baru dipakai sebagai re-auth
+// untuk aksi sensitif (akun OIDC tidak punya password).
+const ClaimOIDCAuthAt = "oidc_at"
+
+// Sama dengan IssueToken, plus ClaimOIDCAuthAt = sekarang
+func IssueOIDCToken(userID uint, tokenVersion int, role string) (string, error) {
+	claims := sessionClaims(userID, tokenVersion, role)
+	claims[ClaimOIDCAuthAt] = time.Now().Unix()
+	return signSessionToken(claims)
+}
+
+func sessionClaims(userID uint, tokenVersion int, role string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"user_id": userID,
+		"tv":      tokenVersion,
+		"role":    role,
+		"exp":     time.Now().Add(24 * time.Hour).Unix(),
+	}
+}
+
+func signSessionToken(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	RoleUser    = "user"
+	RoleTeacher = "teacher"
+	RoleAdmin   = "admin"
+)
+
+const (
+	PermUsersRead       = "users:read"
+	PermUsersManage     = "users:manage"
+	PermRolesAssign     = "roles:assign"
+	PermAuditRead       = "audit:read"
+	PermContentWrite    = "content:write"
+	PermClassroomManage = "classroom:manage"
+)
+
+var rolePermissions = map[string][]string{
+	RoleUser:    {},
+	RoleTeacher: {PermContentWrite, PermClassroomManage},
+	RoleAdmin: {
+		PermUsersRead, PermUsersManage, PermRolesAssign, PermAuditRead,
+		PermContentWrite, PermClassroomManage,
+	},
+}
+
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+func Roles() map[string][]string {
+	return rolePermissions
+}
+
+func PermissionsFor(role string) []string {
+	return rolePermissions[role]
+}
+
+func HasPermission(role, perm string) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireRole: role diambil dari context (di-set AuthMiddleware dari DB).
+// Admin selalu lolos.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		if role == RoleAdmin {
+			c.Next()
+			return
+		}
+		for _, r := range roles {
+			if r == role {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+	}
+}
+
+func RequirePermission(perm string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !HasPermission(c.GetString("role"), perm) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden", "required_permission": perm})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,61 @@
+package mlclient
+
+import (
+	"sync"
+	"time"
+)
+
+type cacheEntry struct {
+	value     Prediction
+	expiresAt time.Time
+}
+
+// cache TTL sederhana, dibatasi jumlah entri
+type cache struct {
+	ttl     time.Duration
+	maxSize int
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+func newCache(ttl time.Duration, maxSize int) *cache {
+	return &cache{ttl: ttl, maxSize: maxSize, entries: make(map[string]cacheEntry)}
+}
+
+func (c *cache) get(key string) (Prediction, bool) {
+	if c.ttl <= 0 {
+		return Prediction{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || time.Now().After(e.expiresAt) {
+		delete(c.entries, key)
+		return Prediction{}, false
+	}
+	return e.value, true
+}
+
+func (c *cache) set(key string, v Prediction) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= c.maxSize {
+		// Buang yang kedaluwarsa; kalau masih penuh, kosongkan
+		now := time.Now()
+		for k, e := range c.entries {
+			if now.After(e.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= c.maxSize {
+			c.entries = make(map[string]cacheEntry)
+		}
+	}
+	c.entries[key] = cacheEntry{value: v, expiresAt: time.Now().Add(c.ttl)}
+}
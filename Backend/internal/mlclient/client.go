@@ -0,0 +1,286 @@
+package mlclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"kotoba-backend/internal/env"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Input & hasil /predict_retention
+type StatsInput struct {
+	TotalLearned int `json:"total_learned"`
+	IngatCount   int `json:"ingat_count"`
+	RaguCount    int `json:"ragu_count"`
+	LupaCount    int `json:"lupa_count"`
+}
+
+type Prediction struct {
+	RetentionRate   float64   `json:"retention_rate"`
+	Status          string    `json:"status"`
+	DecayRisk       string    `json:"decay_risk"`
+	NextReviewHours float64   `json:"next_review_hours"`
+	GraphData       []float64 `json:"graph_data"`
+}
+
+// Prediksi fallback saat ML service tidak bisa dihubungi
+func OfflinePrediction() Prediction {
+	return Prediction{
+		RetentionRate: 100, Status: "OFFLINE", DecayRisk: "UNKNOWN", NextReviewHours: 0,
+		GraphData: []float64{0, 0, 0, 0, 0, 0, 0},
+	}
+}
+
+// Error status HTTP dari ML service
+type StatusError struct {
+	Code int
+}
+
+func (e *StatusError) Error() string { return fmt.Sprintf("mlclient: status %d", e.Code) }
+
+type Config struct {
+	BaseURL          string
+	Timeout          time.Duration // per percobaan
+	MaxRetries       int
+	BackoffBase      time.Duration
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+	CacheTTL         time.Duration
+	CacheSize        int
+}
+
+type Client struct {
+	cfg     Config
+	http    *http.Client
+	breaker *Breaker
+	cache   *cache
+	metrics *Metrics
+}
+
+func New(cfg Config) *Client {
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 2 * time.Second
+	}
+	if cfg.BackoffBase <= 0 {
+		cfg.BackoffBase = 100 * time.Millisecond
+	}
+	if cfg.BreakerThreshold <= 0 {
+		cfg.BreakerThreshold = 5
+	}
+	if cfg.BreakerCooldown <= 0 {
+		cfg.BreakerCooldown = 30 * time.Second
+	}
+	if cfg.CacheSize <= 0 {
+		cfg.CacheSize = 10000
+	}
+	return &Client{
+		cfg:     cfg,
+		http:    &http.Client{},
+		breaker: NewBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+		cache:   newCache(cfg.CacheTTL, cfg.CacheSize),
+		metrics: newMetrics(),
+	}
+}
+
+var (
+	defaultOnce   sync.Once
+	defaultClient *Client
+)
+
+// Default: client dari env.
+//
+//	ML_SERVICE_URL        default http://ml_service:5000
+//	ML_TIMEOUT_MS         per percobaan, default 2000
+//	ML_MAX_RETRIES        default 2
+//	ML_BREAKER_THRESHOLD  kegagalan beruntun sebelum breaker terbuka, default 5
+//	ML_BREAKER_COOLDOWN_S default 30
+//	ML_CACHE_TTL_S        default 600 (0 = tanpa cache)
+func Default() *Client {
+	defaultOnce.Do(func() {
+		defaultClient = New(Config{
+			BaseURL:          envOr("ML_SERVICE_URL", "http://ml_service:5000"),
+			Timeout:          time.Duration(env.Int("ML_TIMEOUT_MS", 2000)) * time.Millisecond,
+			MaxRetries:       env.Int("ML_MAX_RETRIES", 2),
+			BreakerThreshold: env.Int("ML_BREAKER_THRESHOLD", 5),
+			BreakerCooldown:  time.Duration(env.Int("ML_BREAKER_COOLDOWN_S", 30)) * time.Second,
+			CacheTTL:         time.Duration(env.Int("ML_CACHE_TTL_S", 600)) * time.Second,
+		})
+	})
+	return defaultClient
+}
+
+// cacheKey: prediksi hanya bergantung pada state review (jumlah ingat/ragu/lupa)
+func (in StatsInput) cacheKey() string {
+	return fmt.Sprintf("retention:%d:%d:%d:%d", in.TotalLearned, in.IngatCount, in.RaguCount, in.LupaCount)
+}
+
+// PredictRetention: dengan cache, circuit breaker, dan retry
+func (c *Client) PredictRetention(ctx context.Context, in StatsInput) (Prediction, error) {
+	const endpoint = "/predict_retention"
+
+	key := in.cacheKey()
+	if p, ok := c.cache.get(key); ok {
+		c.metrics.update(endpoint, func(e *endpointMetrics) { e.CacheHits++ })
+		return p, nil
+	}
+	c.metrics.update(endpoint, func(e *endpointMetrics) { e.CacheMisses++ })
+
+	var out Prediction
+	if err := c.postJSON(ctx, endpoint, in, &out); err != nil {
+		return Prediction{}, err
+	}
+	c.cache.set(key, out)
+	return out, nil
+}
+
+type BatchItem struct {
+	UserID uint       `json:"user_id"`
+	Stats  StatsInput `json:"stats"`
+}
+
+type BatchResult struct {
+	UserID uint `json:"user_id"`
+	Prediction
+}
+
+// PredictRetentionBatch: satu request untuk banyak user (job batch). Hasil ikut mengisi cache.
+func (c *Client) PredictRetentionBatch(ctx context.Context, items []BatchItem) ([]BatchResult, error) {
+	var out struct {
+		Results []BatchResult `json:"results"`
+	}
+	if err := c.postJSON(ctx, "/predict_retention/batch", map[string]interface{}{"items": items}, &out); err != nil {
+		return nil, err
+	}
+
+	stats := make(map[uint]StatsInput, len(items))
+	for _, it := range items {
+		stats[it.UserID] = it.Stats
+	}
+	for _, r := range out.Results {
+		if in, ok := stats[r.UserID]; ok {
+			c.cache.set(in.cacheKey(), r.Prediction)
+		}
+	}
+	return out.Results, nil
+}
+
+func (c *Client) Metrics() Snapshot {
+	return c.metrics.snapshot(c.breaker.State())
+}
+
+func (c *Client) postJSON(ctx context.Context, endpoint string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= c.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			c.metrics.update(endpoint, func(e *endpointMetrics) { e.Retries++ })
+			if err := sleepCtx(ctx, c.backoff(attempt)); err != nil {
+				return err
+			}
+		}
+
+		if err := c.breaker.Allow(); err != nil {
+			c.metrics.update(endpoint, func(e *endpointMetrics) { e.ShortCircuits++ })
+			return err
+		}
+
+		start := time.Now()
+		lastErr = c.do(ctx, endpoint, body, out)
+		c.metrics.observe(endpoint, time.Since(start), lastErr == nil)
+
+		if lastErr == nil {
+			c.breaker.Success()
+			return nil
+		}
+		if ctx.Err() != nil {
+			// Dibatalkan caller, bukan kesalahan service
+			c.breaker.Release()
+			return ctx.Err()
+		}
+		if !retryable(lastErr) {
+			// 4xx / respons rusak = salah caller, service sendiri masih hidup
+			c.breaker.Release()
+			return lastErr
+		}
+		if c.breaker.Failure() {
+			c.metrics.breakerOpened()
+			log.Printf("[WARN] ML circuit opened after error: %v", lastErr)
+		}
+	}
+	return lastErr
+}
+
+func (c *Client) do(ctx context.Context, endpoint string, body []byte, out interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.BaseURL+endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	// Body selalu ditutup (dan dikuras) supaya koneksi bisa dipakai ulang
+	defer func() {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return &StatusError{Code: resp.StatusCode}
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Retry hanya untuk error jaringan/timeout, 5xx, dan 429
+func retryable(err error) bool {
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.Code >= 500 || se.Code == http.StatusTooManyRequests
+	}
+	var syntaxErr *json.SyntaxError
+	return !errors.As(err, &syntaxErr)
+}
+
+// Exponential backoff dengan full jitter
+func (c *Client) backoff(attempt int) time.Duration {
+	max := c.cfg.BackoffBase << uint(attempt-1)
+	return time.Duration(rand.Int63n(int64(max) + 1))
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
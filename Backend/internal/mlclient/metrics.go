@@ -0,0 +1,112 @@
+package mlclient
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Batas bucket latency (ms); bucket terakhir = lebih dari itu
+var latencyBuckets = []int64{50, 100, 250, 500, 1000, 2500}
+
+type endpointMetrics struct {
+	Requests      int64            `json:"requests"`
+	Successes     int64            `json:"successes"`
+	Failures      int64            `json:"failures"`
+	Retries       int64            `json:"retries"`
+	ShortCircuits int64            `json:"short_circuits"`
+	CacheHits     int64            `json:"cache_hits"`
+	CacheMisses   int64            `json:"cache_misses"`
+	FailureRate   float64          `json:"failure_rate"`
+	AvgLatencyMs  float64          `json:"avg_latency_ms"`
+	MaxLatencyMs  int64            `json:"max_latency_ms"`
+	LatencyMs     map[string]int64 `json:"latency_histogram_ms"`
+
+	latencyTotal time.Duration
+	buckets      []int64
+}
+
+// Metrics: latency & kegagalan per endpoint ML service
+type Metrics struct {
+	mu           sync.Mutex
+	endpoints    map[string]*endpointMetrics
+	breakerOpens int64
+}
+
+func newMetrics() *Metrics {
+	return &Metrics{endpoints: make(map[string]*endpointMetrics)}
+}
+
+func (m *Metrics) endpoint(name string) *endpointMetrics {
+	e, ok := m.endpoints[name]
+	if !ok {
+		e = &endpointMetrics{buckets: make([]int64, len(latencyBuckets)+1)}
+		m.endpoints[name] = e
+	}
+	return e
+}
+
+func (m *Metrics) update(name string, fn func(e *endpointMetrics)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fn(m.endpoint(name))
+}
+
+// observe: satu percobaan HTTP (termasuk retry)
+func (m *Metrics) observe(name string, d time.Duration, ok bool) {
+	m.update(name, func(e *endpointMetrics) {
+		e.Requests++
+		if ok {
+			e.Successes++
+		} else {
+			e.Failures++
+		}
+		e.latencyTotal += d
+		ms := d.Milliseconds()
+		if ms > e.MaxLatencyMs {
+			e.MaxLatencyMs = ms
+		}
+		i := 0
+		for i < len(latencyBuckets) && ms > latencyBuckets[i] {
+			i++
+		}
+		e.buckets[i]++
+	})
+}
+
+func (m *Metrics) breakerOpened() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.breakerOpens++
+}
+
+type Snapshot struct {
+	BreakerState string                     `json:"breaker_state"`
+	BreakerOpens int64                      `json:"breaker_opens"`
+	Endpoints    map[string]endpointMetrics `json:"endpoints"`
+}
+
+func (m *Metrics) snapshot(breakerState string) Snapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := Snapshot{BreakerState: breakerState, BreakerOpens: m.breakerOpens, Endpoints: map[string]endpointMetrics{}}
+	for name, e := range m.endpoints {
+		s := *e
+		if s.Requests > 0 {
+			s.FailureRate = float64(s.Failures) / float64(s.Requests)
+			s.AvgLatencyMs = float64(s.latencyTotal) / float64(time.Millisecond) / float64(s.Requests)
+		}
+		s.LatencyMs = make(map[string]int64, len(e.buckets))
+		for i, n := range e.buckets {
+			label := "inf"
+			if i < len(latencyBuckets) {
+				label = "le_" + strconv.FormatInt(latencyBuckets[i], 10)
+			}
+			s.LatencyMs[label] = n
+		}
+		s.buckets = nil
+		out.Endpoints[name] = s
+	}
+	return out
+}
@@ -2,6 +2,8 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type User struct {
@@ -10,8 +12,22 @@ type User struct {
 	Email     string         `gorm:"unique;not null" json:"email"`
 	Password  string         `json:"-"`
 	Role      string         `json:"role" gorm:"default:'user'"`
+	Avatar    string         `json:"avatar" gorm:"default:'default'"`
+	Timezone  string         `json:"timezone"` // IANA, kosong = USER_DEFAULT_TIMEZONE
 	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 	Progress  []UserProgress `json:"progress,omitempty"`
+
+	// Naik setiap password/email diganti -> token lama otomatis invalid
+	TokenVersion int `gorm:"default:0" json:"-"`
+
+	// Diatur admin
+	LockedAt          *time.Time `json:"locked_at,omitempty"`
+	MustResetPassword bool       `gorm:"default:false" json:"must_reset_password"`
+
+	// Diisi kalau email sudah diverifikasi (mis. oleh IdP OIDC); dikosongkan saat email diganti
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
 }
 
 type ReviewLog struct {
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Achievement yang sudah didapat user. Backfilled = terbuka lewat backfill data lama.
+type UserAchievement struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	UserID     uint      `gorm:"uniqueIndex:idx_user_achievement;not null" json:"user_id"`
+	Code       string    `gorm:"uniqueIndex:idx_user_achievement;not null" json:"code"`
+	UnlockedAt time.Time `json:"unlocked_at"`
+	Backfilled bool      `gorm:"not null;default:false" json:"backfilled"`
+}
+
+const (
+	BackfillRunning = "running"
+	BackfillDone    = "done"
+	BackfillFailed  = "failed"
+)
+
+// Job backfill achievement (admin). LastUserID = cursor batch terakhir yang selesai.
+type AchievementBackfillJob struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	Status      string     `gorm:"not null;default:'running'" json:"status"`
+	LastUserID  uint       `json:"last_user_id"`
+	Users       int        `json:"users"`
+	Unlocked    int        `json:"unlocked"`
+	Error       string     `json:"error,omitempty"`
+	StartedBy   uint       `json:"started_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"time"
+)
+
+// Jejak audit: siapa mengubah apa. Hanya di-insert, tidak pernah di-update.
+type AuditLog struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	ActorID    uint      `gorm:"index" json:"actor_id"`
+	Action     string    `gorm:"index;not null" json:"action"`
+	TargetType string    `gorm:"index:idx_audit_target" json:"target_type"`
+	TargetID   uint      `gorm:"index:idx_audit_target" json:"target_id"`
+	Before     JSONText  `gorm:"type:jsonb" json:"before,omitempty"`
+	After      JSONText  `gorm:"type:jsonb" json:"after,omitempty"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+}
+
+// JSONText: kolom jsonb nullable, dikirim ke client sebagai JSON mentah
+type JSONText string
+
+func (j JSONText) Value() (driver.Value, error) {
+	if j == "" {
+		return nil, nil
+	}
+	return string(j), nil
+}
+
+func (j *JSONText) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*j = ""
+	case []byte:
+		*j = JSONText(v)
+	case string:
+		*j = JSONText(v)
+	default:
+		return fmt.Errorf("JSONText: unsupported type %T", value)
+	}
+	return nil
+}
+
+func (j JSONText) MarshalJSON() ([]byte, error) {
+	if j == "" {
+		return []byte("null"), nil
+	}
+	return []byte(j), nil
+}
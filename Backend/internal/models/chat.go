@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+const (
+	ChatRoleUser      = "user"
+	ChatRoleAssistant = "assistant"
+)
+
+// Percakapan dengan Shouma-sensei
+type Conversation struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"index;not null" json:"user_id"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `gorm:"index" json:"updated_at"`
+}
+
+type ChatMessage struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	ConversationID uint      `gorm:"index;not null" json:"conversation_id"`
+	Role           string    `gorm:"not null" json:"role"` // user | assistant
+	Content        string    `gorm:"type:text;not null" json:"content"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+const (
+	QuizPending  = "pending"
+	QuizAnswered = "answered"
+	QuizSkipped  = "skipped" // pesan berikutnya bukan jawaban
+)
+
+// Kuis kecil yang diajukan Sensei di dalam percakapan, jawabannya masuk ke review_logs
+type SenseiQuiz struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	UserID         uint       `gorm:"index;not null" json:"user_id"`
+	ConversationID uint       `gorm:"index;not null" json:"conversation_id"`
+	VocabID        uint       `gorm:"not null" json:"vocab_id"`
+	Question       string     `gorm:"type:text" json:"question"`
+	Status         string     `gorm:"not null;default:pending" json:"status"`
+	Answer         string     `json:"answer,omitempty"`
+	Correct        *bool      `json:"correct"`
+	ReviewLogID    *uint      `json:"review_log_id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	AnsweredAt     *time.Time `json:"answered_at"`
+}
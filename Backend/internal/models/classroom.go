@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Kelas milik guru. Siswa bergabung dengan JoinCode.
+type Classroom struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	TeacherID   uint      `gorm:"index;not null" json:"teacher_id"`
+	Name        string    `gorm:"not null" json:"name"`
+	Description string    `json:"description"`
+	JoinCode    string    `gorm:"uniqueIndex;size:12;not null" json:"join_code"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type ClassroomMember struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	ClassroomID uint      `gorm:"uniqueIndex:idx_classroom_member;not null" json:"classroom_id"`
+	UserID      uint      `gorm:"uniqueIndex:idx_classroom_member;index;not null" json:"user_id"`
+	JoinedAt    time.Time `gorm:"autoCreateTime" json:"joined_at"`
+}
+
+// Jenis tugas kelas
+const (
+	AssignmentLevel = "level" // semua kata dengan difficulty_level tertentu
+	AssignmentDeck  = "deck"  // semua kartu di deck (lihat Deck)
+)
+
+// Tugas kelas: daftar kata yang harus dikuasai siswa sebelum DueAt
+type ClassroomAssignment struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	ClassroomID uint       `gorm:"index;not null" json:"classroom_id"`
+	Title       string     `gorm:"not null" json:"title"`
+	Kind        string     `gorm:"size:8;not null" json:"kind"`
+	Level       int        `json:"level,omitempty"`
+	DeckID      *uint      `gorm:"index" json:"deck_id,omitempty"`
+	DueAt       *time.Time `json:"due_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Deck buatan user. Kartu direferensikan (bukan disalin), jadi perubahan penulis langsung
+// terlihat oleh subscriber dan progres review (per vocab_id) tetap utuh.
+type Deck struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	OwnerID     uint           `gorm:"index;not null" json:"owner_id"`
+	Title       string         `gorm:"not null" json:"title"`
+	Description string         `json:"description"`
+	Published   bool           `gorm:"index;not null;default:false" json:"published"`
+	PublishedAt *time.Time     `json:"published_at"`
+	Version     int            `gorm:"not null;default:1" json:"version"` // naik setiap isi deck berubah
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+type DeckCard struct {
+	ID       uint      `gorm:"primaryKey" json:"id"`
+	DeckID   uint      `gorm:"uniqueIndex:idx_deck_card;not null" json:"deck_id"`
+	VocabID  uint      `gorm:"uniqueIndex:idx_deck_card;index;not null" json:"vocab_id"`
+	Position int       `gorm:"not null;default:0" json:"position"`
+	AddedAt  time.Time `gorm:"autoCreateTime" json:"added_at"`
+}
+
+// SyncedVersion = versi deck terakhir yang sudah dilihat subscriber
+type DeckSubscription struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	DeckID        uint      `gorm:"uniqueIndex:idx_deck_subscription;not null" json:"deck_id"`
+	UserID        uint      `gorm:"uniqueIndex:idx_deck_subscription;index;not null" json:"user_id"`
+	SyncedVersion int       `gorm:"not null;default:0" json:"synced_version"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Jenis perubahan deck
+const (
+	DeckCardAdded   = "added"
+	DeckCardRemoved = "removed"
+	DeckCardUpdated = "updated" // isi kartu privat diubah penulis
+)
+
+// Riwayat perubahan isi deck, untuk menampilkan "yang baru" ke subscriber
+type DeckChange struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	DeckID    uint      `gorm:"index:idx_deck_change_version;not null" json:"deck_id"`
+	Version   int       `gorm:"index:idx_deck_change_version;not null" json:"version"`
+	Action    string    `gorm:"size:8;not null" json:"action"`
+	VocabID   uint      `gorm:"not null" json:"vocab_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
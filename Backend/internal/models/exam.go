@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+const (
+	ExamShiren = "shiren" // Ujian kotoba (Exam.tsx)
+	ExamKaiwa  = "kaiwa"  // Ujian percakapan (ExamKaiwa.tsx)
+)
+
+type ExamResult struct {
+	ID       uint      `gorm:"primaryKey" json:"id"`
+	UserID   uint      `gorm:"index" json:"user_id"`
+	ExamType string    `gorm:"not null" json:"exam_type"`
+	Score    int       `json:"score"`
+	Total    int       `json:"total"`
+	Passed   bool      `json:"passed"`
+	TakenAt  time.Time `gorm:"autoCreateTime" json:"taken_at"`
+
+	SessionID *uint `gorm:"uniqueIndex" json:"session_id,omitempty"`
+}
+
+// Sesi ujian yang dikeluarkan server. Hasil hanya bisa dikirim sekali per sesi,
+// dan jumlah soal mengikuti Size (bukan angka dari client).
+type ExamSession struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      uint       `gorm:"index;not null" json:"user_id"`
+	ExamType    string     `gorm:"not null" json:"exam_type"`
+	Size        int        `gorm:"not null" json:"size"`
+	VocabIDs    JSONText   `gorm:"type:jsonb" json:"-"` // soal shiren, untuk dinilai di server
+	CreatedAt   time.Time  `json:"created_at"`
+	SubmittedAt *time.Time `json:"submitted_at"`
+}
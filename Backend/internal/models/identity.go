@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Akun eksternal (OIDC) yang terhubung ke User
+type UserIdentity struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"index;not null" json:"user_id"`
+	Provider  string    `gorm:"uniqueIndex:idx_identity_subject;not null" json:"provider"`
+	Subject   string    `gorm:"uniqueIndex:idx_identity_subject;not null" json:"-"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// State login OIDC yang sedang berjalan (state -> verifier PKCE + nonce)
+type OIDCLoginState struct {
+	State     string    `gorm:"primaryKey" json:"-"`
+	Provider  string    `json:"-"`
+	Verifier  string    `json:"-"`
+	Nonce     string    `json:"-"`
+	ExpiresAt time.Time `gorm:"index" json:"-"`
+
+	// SHA-256 dari cookie oidc_binding: callback harus datang dari browser yang memulai login
+	BindingHash string `json:"-"`
+}
+
+func (OIDCLoginState) TableName() string { return "oidc_login_states" }
+
+// Identity IdP yang email-nya cocok dengan akun lokal yang email-nya belum terverifikasi.
+// Baru di-link setelah pemilik akun login dan mengonfirmasi dengan password.
+type OIDCPendingLink struct {
+	Token     string    `gorm:"primaryKey" json:"-"`
+	UserID    uint      `gorm:"index;not null" json:"-"`
+	Provider  string    `gorm:"not null" json:"-"`
+	Subject   string    `gorm:"not null" json:"-"`
+	Email     string    `json:"-"`
+	ExpiresAt time.Time `gorm:"index" json:"-"`
+}
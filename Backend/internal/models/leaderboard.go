@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Pengaturan privasi user. Feed* = siapa yang boleh melihat aktivitas jenis itu (Visibility*).
+type PrivacySettings struct {
+	UserID               uint      `gorm:"primaryKey" json:"user_id"`
+	HideFromLeaderboards bool      `gorm:"not null;default:false" json:"hide_from_leaderboards"`
+	FeedExams            string    `gorm:"size:8;not null;default:friends" json:"feed_exams"`
+	FeedAchievements     string    `gorm:"size:8;not null;default:friends" json:"feed_achievements"`
+	FeedStreaks          string    `gorm:"size:8;not null;default:friends" json:"feed_streaks"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+// Snapshot papan peringkat, diisi ulang oleh job leaderboard-snapshot
+type LeaderboardEntry struct {
+	Board      string    `gorm:"primaryKey;size:16" json:"board"`
+	UserID     uint      `gorm:"primaryKey" json:"user_id"`
+	Score      int64     `gorm:"index;not null" json:"score"`
+	ComputedAt time.Time `json:"computed_at"`
+}
+
+// Tier liga user saat ini (0 = tier terendah)
+type UserLeague struct {
+	UserID    uint      `gorm:"primaryKey" json:"user_id"`
+	Tier      int       `gorm:"not null;default:0" json:"tier"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Keanggotaan grup liga per minggu. Outcome diisi saat minggu ditutup job league-rollover.
+type LeagueMembership struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	Week      string     `gorm:"uniqueIndex:idx_league_week_user;index:idx_league_group;size:10;not null" json:"week"`
+	UserID    uint       `gorm:"uniqueIndex:idx_league_week_user;not null" json:"user_id"`
+	Tier      int        `gorm:"index:idx_league_group;not null" json:"tier"`
+	GroupNo   int        `gorm:"index:idx_league_group;not null" json:"group_no"`
+	FinalXP   int64      `json:"final_xp"`
+	FinalRank int        `json:"final_rank"`
+	Outcome   string     `json:"outcome"`
+	ClosedAt  *time.Time `json:"closed_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,9 @@
+package models
+
+import "time"
+
+// Migrasi data sekali jalan yang sudah selesai (mis. backfill), supaya tidak diulang tiap start
+type DataMigration struct {
+	Name        string    `gorm:"primaryKey;size:64" json:"name"`
+	CompletedAt time.Time `json:"completed_at"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Prediksi retensi terbaru per user, diisi job batch (jobs.PredictRetention)
+type UserPrediction struct {
+	UserID          uint      `gorm:"primaryKey" json:"user_id"`
+	TotalLearned    int       `json:"total_learned"`
+	IngatCount      int       `json:"ingat_count"`
+	RaguCount       int       `json:"ragu_count"`
+	LupaCount       int       `json:"lupa_count"`
+	RetentionRate   float64   `json:"retention_rate"`
+	Status          string    `json:"status"`
+	DecayRisk       string    `json:"decay_risk"`
+	NextReviewHours float64   `json:"next_review_hours"`
+	GraphData       JSONText  `gorm:"type:jsonb" json:"graph_data"`
+	DueNow          int       `json:"due_now"` // sudah jatuh tempo
+	Due24h          int       `json:"due_24h"` // jatuh tempo dalam 24 jam
+	Due7d           int       `json:"due_7d"`  // jatuh tempo dalam 7 hari
+	ComputedAt      time.Time `json:"computed_at"`
+}
+
+// Riwayat prediksi, satu baris per user per hari (untuk grafik mingguan)
+type UserPredictionHistory struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	UserID        uint      `gorm:"uniqueIndex:idx_prediction_history_user_day;not null" json:"user_id"`
+	Day           time.Time `gorm:"type:date;uniqueIndex:idx_prediction_history_user_day;not null" json:"day"`
+	RetentionRate float64   `json:"retention_rate"`
+	DecayRisk     string    `json:"decay_risk"`
+	TotalLearned  int       `json:"total_learned"`
+	DueNow        int       `json:"due_now"`
+	Due7d         int       `json:"due_7d"`
+	ComputedAt    time.Time `json:"computed_at"`
+}
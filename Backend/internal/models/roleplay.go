@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+const (
+	RoleplayActive    = "active"
+	RoleplayFinishing = "finishing" // sedang dinilai (FinishRoleplay), ended_at = waktu klaim
+	RoleplayCompleted = "completed"
+)
+
+// Sesi role-play: satu skenario (seeds/scenarios.json) dimainkan di satu Conversation
+type RoleplaySession struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	UserID         uint       `gorm:"index;not null" json:"user_id"`
+	ScenarioSlug   string     `gorm:"not null" json:"scenario_slug"`
+	ScenarioTitle  string     `json:"scenario_title"`
+	ConversationID uint       `gorm:"uniqueIndex;not null" json:"conversation_id"`
+	Status         string     `gorm:"not null;default:active" json:"status"`
+	StartedAt      time.Time  `gorm:"autoCreateTime" json:"started_at"`
+	EndedAt        *time.Time `json:"ended_at"`
+}
+
+// Laporan akhir role-play
+type RoleplayReport struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	SessionID     uint      `gorm:"uniqueIndex;not null" json:"session_id"`
+	UserID        uint      `gorm:"index;not null" json:"user_id"`
+	Score         int       `json:"score"`
+	GoalAchieved  bool      `json:"goal_achieved"`
+	TargetsUsed   JSONText  `gorm:"type:jsonb" json:"targets_used"`
+	TargetsMissed JSONText  `gorm:"type:jsonb" json:"targets_missed"`
+	Mistakes      JSONText  `gorm:"type:jsonb" json:"mistakes"`
+	Summary       string    `gorm:"type:text" json:"summary"`
+	CreatedAt     time.Time `json:"created_at"`
+}
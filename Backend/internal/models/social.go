@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+const (
+	FriendPending  = "pending"
+	FriendAccepted = "accepted"
+	FriendDeclined = "declined"
+)
+
+// Permintaan pertemanan. Satu baris per pasangan; status accepted = berteman dua arah.
+type Friendship struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	RequesterID uint       `gorm:"uniqueIndex:idx_friendship_pair;not null" json:"requester_id"`
+	AddresseeID uint       `gorm:"uniqueIndex:idx_friendship_pair;index;not null" json:"addressee_id"`
+	Status      string     `gorm:"not null;default:pending" json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RespondedAt *time.Time `json:"responded_at"`
+}
+
+// Follow satu arah: follower melihat aktivitas publik followee
+type Follow struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	FollowerID uint      `gorm:"uniqueIndex:idx_follow_pair;not null" json:"follower_id"`
+	FolloweeID uint      `gorm:"uniqueIndex:idx_follow_pair;index;not null" json:"followee_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type Block struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	BlockerID uint      `gorm:"uniqueIndex:idx_block_pair;not null" json:"blocker_id"`
+	BlockedID uint      `gorm:"uniqueIndex:idx_block_pair;index;not null" json:"blocked_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Jenis aktivitas di feed
+const (
+	ActivityExamPassed      = "exam_passed"
+	ActivityAchievement     = "achievement_unlocked"
+	ActivityStreakMilestone = "streak_milestone"
+)
+
+// Level privasi per jenis aktivitas
+const (
+	VisibilityPublic  = "public"  // teman + follower
+	VisibilityFriends = "friends" // teman saja
+	VisibilityPrivate = "private" // diri sendiri
+)
+
+type ActivityEvent struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"index;not null" json:"user_id"`
+	Kind      string    `gorm:"not null" json:"kind"`
+	Payload   JSONText  `gorm:"type:jsonb" json:"payload"`
+	CreatedAt time.Time `json:"created_at"`
+}
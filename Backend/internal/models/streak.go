@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Streak & target harian per user. Hari disimpan sebagai "YYYY-MM-DD" menurut timezone user.
+type UserStreak struct {
+	UserID      uint      `gorm:"primaryKey" json:"user_id"`
+	GoalType    string    `gorm:"not null;default:reviews" json:"goal_type"`
+	GoalTarget  int       `gorm:"not null;default:20" json:"goal_target"`
+	Current     int       `gorm:"not null;default:0" json:"current"`
+	Longest     int       `gorm:"not null;default:0" json:"longest"`
+	LastGoalDay string    `gorm:"size:10" json:"last_goal_day"`
+	Freezes     int       `gorm:"not null;default:0" json:"freezes"`
+	FreezesUsed int       `gorm:"not null;default:0" json:"freezes_used"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Aktivitas belajar satu hari (timezone user)
+type StudyDay struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	UserID    uint      `gorm:"uniqueIndex:idx_study_day_user_day;not null" json:"user_id"`
+	Day       string    `gorm:"uniqueIndex:idx_study_day_user_day;size:10;not null" json:"day"`
+	Reviews   int       `gorm:"not null;default:0" json:"reviews"`
+	Seconds   int       `gorm:"not null;default:0" json:"seconds"`
+	GoalMet   bool      `gorm:"not null;default:false" json:"goal_met"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+const (
+	StudySourceManual = "manual"
+	StudySourceSensei = "sensei"
+)
+
+// Antrian belajar: kata yang ditandai user (atau Sensei) untuk muncul lebih dulu di flashcard
+type StudyQueueItem struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"uniqueIndex:idx_study_queue_user_vocab;not null" json:"user_id"`
+	VocabID   uint      `gorm:"uniqueIndex:idx_study_queue_user_vocab;not null" json:"vocab_id"`
+	Source    string    `gorm:"not null;default:manual" json:"source"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Tag/kategori kosakata (topik seperti food, atau jenis kata seperti verb)
+type Tag struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Slug      string    `gorm:"uniqueIndex;size:32;not null" json:"slug"`
+	Name      string    `gorm:"not null" json:"name"`
+	Category  string    `gorm:"index;size:16;not null" json:"category"`
+	Seeded    bool      `gorm:"not null;default:false" json:"seeded"` // dari seeds/vocab_tags.json, slug tidak bisa diubah
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Relasi many-to-many vocabularies <-> tags
+type VocabularyTag struct {
+	VocabID uint `gorm:"primaryKey" json:"vocab_id"`
+	TagID   uint `gorm:"primaryKey;index" json:"tag_id"`
+}
+
+func (VocabularyTag) TableName() string {
+	return "vocabulary_tags"
+}
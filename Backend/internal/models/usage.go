@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+const (
+	UsageChat         = "chat"
+	UsageChatStream   = "chat_stream"
+	UsageRoleplayEval = "roleplay_eval"
+)
+
+// Pemakaian LLM per giliran (token dari provider, atau perkiraan kalau tidak dilaporkan)
+type LLMUsage struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	UserID           uint      `gorm:"index:idx_llm_usage_user_time;not null" json:"user_id"`
+	ConversationID   uint      `json:"conversation_id"`
+	Kind             string    `gorm:"not null" json:"kind"`
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	Calls            int       `json:"calls"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	CostUSD          float64   `json:"cost_usd"`
+	CreatedAt        time.Time `gorm:"index:idx_llm_usage_user_time;index" json:"created_at"`
+}
+
+func (LLMUsage) TableName() string { return "llm_usages" }
+
+// Total pemakaian per user per bulan (user_id 0 = seluruh deployment), dasar budget bulanan.
+// Perkiraan direservasi sebelum panggilan LLM, lalu dikoreksi dengan pemakaian sebenarnya.
+type LLMBudgetCounter struct {
+	UserID      uint      `gorm:"primaryKey;autoIncrement:false" json:"user_id"`
+	PeriodStart time.Time `gorm:"primaryKey" json:"period_start"`
+	Tokens      int64     `gorm:"not null;default:0" json:"tokens"`
+	CostUSD     float64   `gorm:"not null;default:0" json:"cost_usd"`
+}
+
+// Kejadian guard (input ditolak / output difilter), untuk pemantauan admin
+type ChatGuardEvent struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	UserID         uint      `gorm:"index;not null" json:"user_id"`
+	ConversationID uint      `json:"conversation_id"`
+	Kind           string    `gorm:"not null" json:"kind"` // too_long | prompt_injection | output_filtered | budget_exceeded
+	Excerpt        string    `json:"excerpt"`
+	CreatedAt      time.Time `gorm:"index" json:"created_at"`
+}
@@ -1,13 +1,25 @@
 package models
 
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
 type Vocabulary struct {
-	ID              uint   `gorm:"primaryKey" json:"id"`
-	Kanji           string `gorm:"column:kanji" json:"kanji"`
-	Kana            string `gorm:"column:kana" json:"kana"`
-	Romaji          string `gorm:"column:romaji" json:"romaji"`
-	Meaning         string `gorm:"column:meaning" json:"meaning"`
-	ExampleSentence string `gorm:"column:example_sentence" json:"example_sentence"`
-	DifficultyLevel int    `gorm:"column:difficulty_level" json:"difficulty_level"`
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	Kanji           string         `gorm:"column:kanji" json:"kanji"`
+	Kana            string         `gorm:"column:kana" json:"kana"`
+	Romaji          string         `gorm:"column:romaji" json:"romaji"`
+	Meaning         string         `gorm:"column:meaning" json:"meaning"`
+	ExampleSentence string         `gorm:"column:example_sentence" json:"example_sentence"`
+	DifficultyLevel int            `gorm:"column:difficulty_level" json:"difficulty_level"`
+	OwnerID         *uint          `gorm:"column:owner_id;index" json:"owner_id,omitempty"` // kartu privat (deck user), nil = kosakata umum
+	Tags            []Tag          `gorm:"many2many:vocabulary_tags;joinForeignKey:VocabID;joinReferences:TagID" json:"tags,omitempty"`
+	TagsSeededAt    *time.Time     `gorm:"column:tags_seeded_at" json:"-"` // tag sudah ditetapkan (seed / content API), seed tidak menyentuh lagi
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 func (Vocabulary) TableName() string {
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Status terakhir user per kata (salinan review_logs terbaru), supaya daftar kosakata
+// tidak perlu DISTINCT ON atas seluruh log. Diisi setiap review; di-backfill sekali dari review_logs.
+type UserVocabState struct {
+	UserID     uint      `gorm:"primaryKey;autoIncrement:false;index:idx_vocab_state_due,priority:1;index:idx_vocab_state_result,priority:1" json:"user_id"`
+	VocabID    uint      `gorm:"primaryKey;autoIncrement:false" json:"vocab_id"`
+	Result     int       `gorm:"not null;index:idx_vocab_state_result,priority:2" json:"result"`
+	ReviewedAt time.Time `gorm:"not null" json:"reviewed_at"`
+	DueAt      time.Time `gorm:"not null;index:idx_vocab_state_due,priority:2" json:"due_at"`
+}
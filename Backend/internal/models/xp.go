@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Ledger XP. Satu baris per event (source + source_id), jadi pemberian XP idempotent.
+type XPEvent struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"uniqueIndex:idx_xp_event;index;not null" json:"user_id"`
+	Source    string    `gorm:"uniqueIndex:idx_xp_event;not null" json:"source"`
+	SourceID  string    `gorm:"uniqueIndex:idx_xp_event;not null" json:"source_id"`
+	Amount    int       `gorm:"not null" json:"amount"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+func (XPEvent) TableName() string { return "xp_events" }
+
+// Hasil latihan kana (hiragana/katakana). DrillID dibuat client supaya retry tidak dobel.
+type KanaDrillResult struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"uniqueIndex:idx_kana_drill_user_drill;not null" json:"user_id"`
+	DrillID   string    `gorm:"uniqueIndex:idx_kana_drill_user_drill;size:64;not null" json:"drill_id"`
+	Script    string    `gorm:"not null" json:"script"`
+	Correct   int       `json:"correct"`
+	Total     int       `json:"total"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Progres per huruf kana dari latihan kana
+type KanaProgress struct {
+	ID           uint      `gorm:"primaryKey" json:"-"`
+	UserID       uint      `gorm:"uniqueIndex:idx_kana_progress_user_kana;not null" json:"user_id"`
+	Kana         string    `gorm:"uniqueIndex:idx_kana_progress_user_kana;size:8;not null" json:"kana"`
+	Script       string    `gorm:"index;not null" json:"script"`
+	CorrectCount int       `gorm:"not null;default:0" json:"correct_count"`
+	WrongCount   int       `gorm:"not null;default:0" json:"wrong_count"`
+	LastCorrect  bool      `gorm:"not null;default:false" json:"last_correct"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
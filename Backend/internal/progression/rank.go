@@ -0,0 +1,99 @@
+package progression
+
+import (
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Urutan rank default. Bisa diganti lewat env XP_RANKS="Nama:minXP,Nama:minXP,..."
+const defaultRanks = "Ronin:0,Ashigaru:300,Samurai:1000,Hatamoto:2500,Daimyo:5000,Shogun:10000"
+
+type Rank struct {
+	Name  string `json:"name"`
+	MinXP int64  `json:"min_xp"`
+}
+
+var (
+	ranksOnce sync.Once
+	ranks     []Rank
+)
+
+// Ranks: daftar rank terurut dari XP terkecil. Rank pertama selalu mulai dari 0.
+func Ranks() []Rank {
+	ranksOnce.Do(func() {
+		if v := os.Getenv("XP_RANKS"); v != "" {
+			parsed, ok := ParseRanks(v)
+			if ok {
+				ranks = parsed
+				return
+			}
+			log.Printf("[WARN] Invalid XP_RANKS %q, using defaults", v)
+		}
+		ranks, _ = ParseRanks(defaultRanks)
+	})
+	return ranks
+}
+
+// ParseRanks: "Ronin:0,Ashigaru:300" -> []Rank. false kalau formatnya salah.
+func ParseRanks(s string) ([]Rank, bool) {
+	var out []Rank
+	seen := map[int64]bool{}
+	for _, part := range strings.Split(s, ",") {
+		name, min, found := strings.Cut(strings.TrimSpace(part), ":")
+		name = strings.TrimSpace(name)
+		xp, err := strconv.ParseInt(strings.TrimSpace(min), 10, 64)
+		if !found || name == "" || err != nil || xp < 0 || seen[xp] {
+			return nil, false
+		}
+		seen[xp] = true
+		out = append(out, Rank{Name: name, MinXP: xp})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].MinXP < out[j].MinXP })
+	if len(out) == 0 || out[0].MinXP != 0 {
+		return nil, false
+	}
+	return out, true
+}
+
+// Progress: posisi user di tangga rank
+type Progress struct {
+	XP         int64   `json:"xp"`
+	Level      int     `json:"level"`
+	Rank       string  `json:"rank"`
+	RankMinXP  int64   `json:"rank_min_xp"`
+	NextRank   *string `json:"next_rank"`
+	NextRankXP *int64  `json:"next_rank_xp"`
+	XPToNext   int64   `json:"xp_to_next"`
+	Percent    float64 `json:"progress_percent"` // 0-100 menuju rank berikutnya
+}
+
+func For(xp int64) Progress {
+	return progressFor(Ranks(), xp)
+}
+
+func progressFor(list []Rank, xp int64) Progress {
+	if xp < 0 {
+		xp = 0
+	}
+	idx := 0
+	for i, r := range list {
+		if xp >= r.MinXP {
+			idx = i
+		}
+	}
+
+	cur := list[idx]
+	p := Progress{XP: xp, Level: idx + 1, Rank: cur.Name, RankMinXP: cur.MinXP, Percent: 100}
+	if idx+1 < len(list) {
+		next := list[idx+1]
+		p.NextRank = &next.Name
+		p.NextRankXP = &next.MinXP
+		p.XPToNext = next.MinXP - xp
+		p.Percent = float64(xp-cur.MinXP) / float64(next.MinXP-cur.MinXP) * 100
+	}
+	return p
+}
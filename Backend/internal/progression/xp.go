@@ -0,0 +1,90 @@
+package progression
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Sumber XP (kolom source di xp_events)
+const (
+	SourceReview    = "review"
+	SourceExam      = "exam"
+	SourceKanaDrill = "kana_drill"
+	SourceRoleplay  = "roleplay"
+)
+
+// XP per review flashcard: 0 Lupa, 1 Ragu, 2 Ingat
+var reviewXP = map[int]int{0: 2, 1: 5, 2: 10}
+
+func ReviewXP(result int) int {
+	return reviewXP[result]
+}
+
+// Ujian: 2 XP per jawaban benar, bonus 50 kalau lulus
+func ExamXP(score int, passed bool) int {
+	xp := score * 2
+	if passed {
+		xp += 50
+	}
+	return xp
+}
+
+// Latihan kana: 1 XP per jawaban benar, bonus 10 kalau sempurna
+func KanaDrillXP(correct, total int) int {
+	xp := correct
+	if total > 0 && correct == total {
+		xp += 10
+	}
+	return xp
+}
+
+// Role-play: setengah skor (0-100), bonus 20 kalau tujuan adegan tercapai
+func RoleplayXP(score int, goalAchieved bool) int {
+	xp := score / 2
+	if goalAchieved {
+		xp += 20
+	}
+	return xp
+}
+
+// Batas XP per hari (UTC) per sumber, supaya XP tidak bisa di-farm.
+// Bisa diganti lewat env XP_DAILY_CAPS="review:1000,exam:600,..."; 0 = tanpa batas.
+const defaultDailyCaps = "review:1000,exam:600,kana_drill:300,roleplay:400"
+
+var (
+	capsOnce sync.Once
+	caps     map[string]int
+)
+
+func DailyCap(source string) int {
+	capsOnce.Do(func() {
+		if v := os.Getenv("XP_DAILY_CAPS"); v != "" {
+			parsed, ok := ParseDailyCaps(v)
+			if ok {
+				caps = parsed
+				return
+			}
+			log.Printf("[WARN] Invalid XP_DAILY_CAPS %q, using defaults", v)
+		}
+		caps, _ = ParseDailyCaps(defaultDailyCaps)
+	})
+	return caps[source]
+}
+
+// ParseDailyCaps: "review:1000,exam:600" -> map. false kalau formatnya salah.
+func ParseDailyCaps(s string) (map[string]int, bool) {
+	out := map[string]int{}
+	for _, part := range strings.Split(s, ",") {
+		source, limit, found := strings.Cut(strings.TrimSpace(part), ":")
+		source = strings.TrimSpace(source)
+		n, err := strconv.Atoi(strings.TrimSpace(limit))
+		if !found || source == "" || err != nil || n < 0 {
+			return nil, false
+		}
+		out[source] = n
+	}
+	return out, true
+}
@@ -0,0 +1,59 @@
+package scenario
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SystemPrompt: Sensei memerankan lawan bicara di skenario
+func (s Scenario) SystemPrompt() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Kamu sedang ROLE-PLAY untuk latihan percakapan bahasa Jepang level %s.\n", s.Level)
+	fmt.Fprintf(&b, "Situasi: %s\n", s.Description)
+	fmt.Fprintf(&b, "Peranmu: %s. Peran murid: %s.\n", s.Counterpart, s.UserRole)
+	fmt.Fprintf(&b, "Tujuan murid: %s\n", s.Goal)
+	b.WriteString("\nATURAN:\n")
+	b.WriteString("1. Tetap dalam peranmu. Bicara dalam bahasa Jepang sederhana sesuai level, sertakan romaji di dalam kurung.\n")
+	b.WriteString("2. Satu atau dua kalimat per giliran, seperti percakapan nyata.\n")
+	b.WriteString("3. Jangan mengoreksi murid selama adegan; koreksi diberikan di laporan akhir.\n")
+	b.WriteString("4. Jika murid bingung atau menulis dalam bahasa Indonesia, bantu dengan petunjuk singkat tetap dalam peran.\n")
+	b.WriteString("5. Jika tujuan sudah tercapai, tutup adegan dengan sopan.\n")
+	if words := s.vocabList(); words != "" {
+		b.WriteString("\nKosakata yang sebaiknya dipancing agar dipakai murid: " + words + ".\n")
+	}
+	if grammar := s.grammarList(); grammar != "" {
+		b.WriteString("Pola kalimat target: " + grammar + ".\n")
+	}
+	return b.String()
+}
+
+func (s Scenario) vocabList() string {
+	items := make([]string, 0, len(s.TargetVocabulary))
+	for _, w := range s.TargetVocabulary {
+		items = append(items, fmt.Sprintf("%s (%s) = %s", w.Word, w.Romaji, w.Meaning))
+	}
+	return strings.Join(items, "; ")
+}
+
+func (s Scenario) grammarList() string {
+	items := make([]string, 0, len(s.TargetGrammar))
+	for _, g := range s.TargetGrammar {
+		items = append(items, fmt.Sprintf("%s = %s", g.Pattern, g.Meaning))
+	}
+	return strings.Join(items, "; ")
+}
+
+// EvaluationPrompt: minta penilaian percakapan dalam format JSON
+func (s Scenario) EvaluationPrompt() string {
+	return fmt.Sprintf(`Kamu adalah penguji bahasa Jepang. Nilai percakapan role-play berikut.
+Skenario: %s. Tujuan murid: %s
+Kosakata target: %s
+Pola kalimat target: %s
+
+Balas HANYA dengan JSON valid tanpa teks lain, format:
+{"goal_achieved": true/false,
+ "mistakes": [{"original": "kalimat murid yang salah", "correction": "kalimat yang benar", "explanation": "penjelasan singkat dalam bahasa Indonesia"}],
+ "summary": "umpan balik singkat dalam bahasa Indonesia, gaya Shouma-sensei"}
+Hanya nilai kalimat yang ditulis MURID (role "user"). Maksimal 5 kesalahan terpenting.`,
+		s.Title, s.Goal, s.vocabList(), s.grammarList())
+}
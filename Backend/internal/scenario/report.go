@@ -0,0 +1,88 @@
+package scenario
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+type Mistake struct {
+	Original    string `json:"original"`
+	Correction  string `json:"correction"`
+	Explanation string `json:"explanation"`
+}
+
+// Feedback dari LLM penguji
+type Feedback struct {
+	GoalAchieved bool      `json:"goal_achieved"`
+	Mistakes     []Mistake `json:"mistakes"`
+	Summary      string    `json:"summary"`
+}
+
+// Usage: target kosakata & grammar yang dipakai / belum dipakai murid
+type Usage struct {
+	Used   []string `json:"used"`
+	Missed []string `json:"missed"`
+}
+
+// TargetUsage dihitung deterministik dari pesan murid (kanji, kana, atau romaji)
+func (s Scenario) TargetUsage(userMessages []string) Usage {
+	text := strings.ToLower(strings.Join(userMessages, "\n"))
+	u := Usage{Used: []string{}, Missed: []string{}}
+
+	mark := func(label string, forms ...string) {
+		for _, f := range forms {
+			if f = strings.ToLower(strings.TrimSpace(f)); f != "" && strings.Contains(text, f) {
+				u.Used = append(u.Used, label)
+				return
+			}
+		}
+		u.Missed = append(u.Missed, label)
+	}
+	for _, w := range s.TargetVocabulary {
+		mark(w.Word, w.Word, w.Reading, w.Romaji)
+	}
+	for _, g := range s.TargetGrammar {
+		mark(g.Pattern, g.Match...)
+	}
+	return u
+}
+
+// ParseFeedback: ambil objek JSON pertama dari balasan LLM (kadang dibungkus ```json)
+func ParseFeedback(reply string) (Feedback, error) {
+	var fb Feedback
+	start := strings.Index(reply, "{")
+	end := strings.LastIndex(reply, "}")
+	if start < 0 || end <= start {
+		return fb, errors.New("scenario: no JSON object in feedback")
+	}
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &fb); err != nil {
+		return fb, err
+	}
+	if len(fb.Mistakes) > 5 {
+		fb.Mistakes = fb.Mistakes[:5]
+	}
+	return fb, nil
+}
+
+// Score 0-100: 70 poin cakupan target, 30 poin tujuan tercapai, -5 per kesalahan (maks -25)
+func Score(u Usage, fb Feedback) int {
+	score := 0.0
+	if total := len(u.Used) + len(u.Missed); total > 0 {
+		score += 70 * float64(len(u.Used)) / float64(total)
+	} else {
+		score += 70
+	}
+	if fb.GoalAchieved {
+		score += 30
+	}
+	penalty := 5 * len(fb.Mistakes)
+	if penalty > 25 {
+		penalty = 25
+	}
+	score -= float64(penalty)
+	if score < 0 {
+		score = 0
+	}
+	return int(score + 0.5)
+}
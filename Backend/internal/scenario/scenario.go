@@ -0,0 +1,102 @@
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+)
+
+type TargetWord struct {
+	Word    string `json:"word"`
+	Reading string `json:"reading"`
+	Romaji  string `json:"romaji"`
+	Meaning string `json:"meaning"`
+}
+
+type TargetGrammar struct {
+	Pattern string   `json:"pattern"`
+	Meaning string   `json:"meaning"`
+	Match   []string `json:"match"` // bentuk yang dianggap "dipakai"
+}
+
+// Scenario role-play (Kaiwa), didefinisikan di seeds/scenarios.json
+type Scenario struct {
+	Slug             string          `json:"slug"`
+	Title            string          `json:"title"`
+	Level            string          `json:"level"`
+	Description      string          `json:"description"`
+	Counterpart      string          `json:"counterpart"`
+	UserRole         string          `json:"user_role"`
+	Goal             string          `json:"goal"`
+	OpeningLine      string          `json:"opening_line"`
+	MaxTurns         int             `json:"max_turns"`
+	TargetVocabulary []TargetWord    `json:"target_vocabulary"`
+	TargetGrammar    []TargetGrammar `json:"target_grammar"`
+}
+
+var (
+	loadOnce sync.Once
+	catalog  map[string]Scenario
+)
+
+// File scenario: SCENARIOS_FILE (default seeds/scenarios.json, di container /app/seeds)
+func path() string {
+	if v := os.Getenv("SCENARIOS_FILE"); v != "" {
+		return v
+	}
+	return "seeds/scenarios.json"
+}
+
+func Load(file string) (map[string]Scenario, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var list []Scenario
+	if err := json.Unmarshal(b, &list); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", file, err)
+	}
+
+	out := make(map[string]Scenario, len(list))
+	for _, s := range list {
+		if s.Slug == "" || s.Goal == "" {
+			return nil, fmt.Errorf("scenario %q: slug and goal are required", s.Title)
+		}
+		if s.MaxTurns <= 0 {
+			s.MaxTurns = 10
+		}
+		out[s.Slug] = s
+	}
+	return out, nil
+}
+
+func ensureLoaded() {
+	loadOnce.Do(func() {
+		var err error
+		if catalog, err = Load(path()); err != nil {
+			log.Printf("[WARN] Role-play scenarios not loaded: %v", err)
+			catalog = map[string]Scenario{}
+			return
+		}
+		log.Printf("[INFO] Loaded %d role-play scenarios", len(catalog))
+	})
+}
+
+func Get(slug string) (Scenario, bool) {
+	ensureLoaded()
+	s, ok := catalog[slug]
+	return s, ok
+}
+
+func All() []Scenario {
+	ensureLoaded()
+	out := make([]Scenario, 0, len(catalog))
+	for _, s := range catalog {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Slug < out[j].Slug })
+	return out
+}
@@ -0,0 +1,67 @@
+package search
+
+import (
+	"log"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Batas panjang kata kunci
+const MaxQueryLength = 64
+
+// Konfigurasi full-text untuk arti (bahasa Indonesia). Jatuh ke "simple" kalau
+// Postgres tidak punya stemmer indonesian.
+var TextConfig = "simple"
+
+var latinPattern = regexp.MustCompile(`[A-Za-z]`)
+
+// Migrate: pg_trgm, fungsi romaji_key dan index pencarian di vocabularies.
+//
+// romaji_key menyamakan penulisan vokal panjang: "sensei" = "sensee" = "sense",
+// "toukyou" = "tōkyō" = "tokyo". Kalau definisinya diubah, index romaji harus dibuat ulang.
+func Migrate(db *gorm.DB) error {
+	var hasIndonesian bool
+	db.Raw(`SELECT EXISTS (SELECT 1 FROM pg_ts_config WHERE cfgname = 'indonesian')`).Scan(&hasIndonesian)
+	if hasIndonesian {
+		TextConfig = "indonesian"
+	}
+
+	statements := []string{
+		`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+		`CREATE OR REPLACE FUNCTION romaji_key(t text) RETURNS text AS $$
+			SELECT replace(replace(replace(replace(replace(replace(replace(
+				regexp_replace(translate(lower(t), 'āīūēōâîûêô', 'aiueoaiueo'), '[^a-z]', '', 'g'),
+				'ou', 'o'), 'oo', 'o'), 'uu', 'u'), 'ei', 'e'), 'ee', 'e'), 'aa', 'a'), 'ii', 'i')
+		$$ LANGUAGE sql IMMUTABLE STRICT PARALLEL SAFE`,
+		`CREATE INDEX IF NOT EXISTS idx_vocab_romaji_key_trgm ON vocabularies USING gin (romaji_key(romaji) gin_trgm_ops)`,
+		`CREATE INDEX IF NOT EXISTS idx_vocab_kana_trgm ON vocabularies USING gin (kana gin_trgm_ops)`,
+		`CREATE INDEX IF NOT EXISTS idx_vocab_kanji_trgm ON vocabularies USING gin (kanji gin_trgm_ops)`,
+		`CREATE INDEX IF NOT EXISTS idx_vocab_meaning_trgm ON vocabularies USING gin (meaning gin_trgm_ops)`,
+		`CREATE INDEX IF NOT EXISTS idx_vocab_meaning_fts_` + TextConfig + ` ON vocabularies
+			USING gin (to_tsvector('` + TextConfig + `', meaning))`,
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	log.Printf("[INFO] Search indexes ready (text search config %s)", TextConfig)
+	return nil
+}
+
+// KanaQuery: katakana -> hiragana, karena kolom kana kebanyakan ditulis hiragana (パン -> ぱん)
+func KanaQuery(q string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'ァ' && r <= 'ヶ' {
+			return r - 0x60
+		}
+		return r
+	}, q)
+}
+
+// HasLatin: kata kunci mengandung huruf latin (romaji / arti), bukan hanya kana/kanji
+func HasLatin(q string) bool {
+	return latinPattern.MatchString(q)
+}
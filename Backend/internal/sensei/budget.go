@@ -0,0 +1,53 @@
+package sensei
+
+import (
+	"os"
+	"strconv"
+	"sync"
+)
+
+// Limits: harga per 1K token dan budget bulanan (0 = tanpa batas).
+//
+//	SENSEI_PRICE_INPUT_PER_1K / SENSEI_PRICE_OUTPUT_PER_1K  USD, default 0 (model gratis)
+//	SENSEI_USER_MONTHLY_TOKENS      default 200000
+//	SENSEI_USER_MONTHLY_BUDGET_USD  default 0
+//	SENSEI_MONTHLY_TOKENS           seluruh deployment, default 0
+//	SENSEI_MONTHLY_BUDGET_USD       seluruh deployment, default 0
+type Limits struct {
+	InputPer1K       float64 `json:"input_per_1k"`
+	OutputPer1K      float64 `json:"output_per_1k"`
+	UserTokens       int64   `json:"user_monthly_tokens"`
+	UserUSD          float64 `json:"user_monthly_budget_usd"`
+	DeploymentTokens int64   `json:"deployment_monthly_tokens"`
+	DeploymentUSD    float64 `json:"deployment_monthly_budget_usd"`
+}
+
+var (
+	limitsOnce sync.Once
+	limits     Limits
+)
+
+func GetLimits() Limits {
+	limitsOnce.Do(func() {
+		limits = Limits{
+			InputPer1K:       envFloat("SENSEI_PRICE_INPUT_PER_1K", 0),
+			OutputPer1K:      envFloat("SENSEI_PRICE_OUTPUT_PER_1K", 0),
+			UserTokens:       int64(envFloat("SENSEI_USER_MONTHLY_TOKENS", 200000)),
+			UserUSD:          envFloat("SENSEI_USER_MONTHLY_BUDGET_USD", 0),
+			DeploymentTokens: int64(envFloat("SENSEI_MONTHLY_TOKENS", 0)),
+			DeploymentUSD:    envFloat("SENSEI_MONTHLY_BUDGET_USD", 0),
+		}
+	})
+	return limits
+}
+
+func (l Limits) Cost(u Usage) float64 {
+	return float64(u.PromptTokens)/1000*l.InputPer1K + float64(u.CompletionTokens)/1000*l.OutputPer1K
+}
+
+func envFloat(key string, fallback float64) float64 {
+	if v, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil && v >= 0 {
+		return v
+	}
+	return fallback
+}
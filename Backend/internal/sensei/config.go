@@ -0,0 +1,93 @@
+package sensei
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type Config struct {
+	Model       string
+	Temperature float64
+	MaxTokens   int
+	Tools       bool
+}
+
+var (
+	setupOnce sync.Once
+	provider  ChatProvider
+	config    Config
+)
+
+// Default: provider + config dari env.
+//
+//	SENSEI_PROVIDERS   urutan failover, contoh "openai,python" (default "python")
+//	SENSEI_MODEL       default "xiaomi/mimo-v2-flash:free"
+//	SENSEI_TEMPERATURE default 0.8
+//	SENSEI_MAX_TOKENS  default 200
+//	OPENAI_BASE_URL / OPENAI_API_KEY untuk provider "openai"
+//	SENSEI_SCRIPTED_REPLIES balasan provider "scripted", dipisah "|"
+//	SENSEI_TOOLS       "false" untuk mematikan tool calling (model tanpa dukungan tool)
+func Default() (ChatProvider, Config) {
+	setupOnce.Do(func() {
+		config = Config{
+			Model:       envOr("SENSEI_MODEL", "xiaomi/mimo-v2-flash:free"),
+			Temperature: 0.8,
+			MaxTokens:   200,
+			Tools:       true,
+		}
+		if v, err := strconv.ParseFloat(os.Getenv("SENSEI_TEMPERATURE"), 64); err == nil {
+			config.Temperature = v
+		}
+		if v, err := strconv.Atoi(os.Getenv("SENSEI_MAX_TOKENS")); err == nil && v > 0 {
+			config.MaxTokens = v
+		}
+		if v, err := strconv.ParseBool(os.Getenv("SENSEI_TOOLS")); err == nil {
+			config.Tools = v
+		}
+
+		var chain []ChatProvider
+		for _, name := range strings.Split(envOr("SENSEI_PROVIDERS", "python"), ",") {
+			switch strings.TrimSpace(name) {
+			case "python":
+				chain = append(chain, NewPythonProvider(envOr("ML_SERVICE_URL", "http://ml_service:5000")))
+			case "openai":
+				chain = append(chain, NewOpenAIProvider(envOr("OPENAI_BASE_URL", "https://openrouter.ai/api/v1"), os.Getenv("OPENAI_API_KEY")))
+			case "scripted":
+				var replies []string
+				if v := os.Getenv("SENSEI_SCRIPTED_REPLIES"); v != "" {
+					replies = strings.Split(v, "|")
+				}
+				chain = append(chain, NewScriptedProvider(replies...))
+			case "":
+			default:
+				log.Printf("[WARN] Unknown sensei provider %q", name)
+			}
+		}
+		if len(chain) == 0 {
+			chain = append(chain, NewPythonProvider(envOr("ML_SERVICE_URL", "http://ml_service:5000")))
+		}
+
+		if len(chain) == 1 {
+			provider = chain[0]
+		} else {
+			provider = &FailoverProvider{Providers: chain}
+		}
+	})
+	return provider, config
+}
+
+// NewRequest: request lengkap dengan config default
+func NewRequest(messages []Message) Request {
+	_, cfg := Default()
+	return Request{Messages: messages, Model: cfg.Model, Temperature: cfg.Temperature, MaxTokens: cfg.MaxTokens}
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,87 @@
+package sensei
+
+import (
+	"context"
+	"errors"
+	"log"
+)
+
+// FailoverProvider: coba provider berurutan sampai ada yang berhasil.
+// Untuk Stream, pindah provider hanya kalau belum ada token yang terkirim.
+type FailoverProvider struct {
+	Providers []ChatProvider
+}
+
+func (f *FailoverProvider) Name() string { return "failover" }
+
+func (f *FailoverProvider) Complete(ctx context.Context, req Request) (string, error) {
+	var errs []error
+	for _, p := range f.Providers {
+		reply, err := p.Complete(ctx, req)
+		if err == nil {
+			return reply, nil
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		log.Printf("[WARN] Sensei provider %s failed: %v", p.Name(), err)
+		errs = append(errs, err)
+	}
+	return "", errors.Join(errs...)
+}
+
+func (f *FailoverProvider) CompleteWithTools(ctx context.Context, req Request) (Message, error) {
+	var errs []error
+	for _, p := range f.Providers {
+		msg, err := completeWithTools(ctx, p, req)
+		if err == nil {
+			return msg, nil
+		}
+		if ctx.Err() != nil {
+			return Message{}, ctx.Err()
+		}
+		log.Printf("[WARN] Sensei provider %s failed: %v", p.Name(), err)
+		errs = append(errs, err)
+	}
+	return Message{}, errors.Join(errs...)
+}
+
+func (f *FailoverProvider) Stream(ctx context.Context, req Request, onDelta func(string) error) (string, error) {
+	var errs []error
+	for _, p := range f.Providers {
+		sent := false
+		reply, err := p.Stream(ctx, req, func(delta string) error {
+			sent = true
+			return onDelta(delta)
+		})
+		if err == nil {
+			return reply, nil
+		}
+		if ctx.Err() != nil || sent {
+			return "", err
+		}
+		log.Printf("[WARN] Sensei provider %s failed: %v", p.Name(), err)
+		errs = append(errs, err)
+	}
+	return "", errors.Join(errs...)
+}
+
+func (f *FailoverProvider) StreamWithTools(ctx context.Context, req Request, onDelta func(string) error) (Message, error) {
+	var errs []error
+	for _, p := range f.Providers {
+		sent := false
+		msg, err := streamWithTools(ctx, p, req, func(delta string) error {
+			sent = true
+			return onDelta(delta)
+		})
+		if err == nil {
+			return msg, nil
+		}
+		if ctx.Err() != nil || sent {
+			return Message{}, err
+		}
+		log.Printf("[WARN] Sensei provider %s failed: %v", p.Name(), err)
+		errs = append(errs, err)
+	}
+	return Message{}, errors.Join(errs...)
+}
@@ -0,0 +1,105 @@
+package sensei
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// Provider gagal untuk test: opsional kirim satu token dulu sebelum error
+type failingProvider struct {
+	name  string
+	token string
+	calls int
+}
+
+var errProviderDown = errors.New("provider down")
+
+func (p *failingProvider) Name() string { return p.name }
+
+func (p *failingProvider) Complete(ctx context.Context, req Request) (string, error) {
+	p.calls++
+	return "", errProviderDown
+}
+
+func (p *failingProvider) Stream(ctx context.Context, req Request, onDelta func(string) error) (string, error) {
+	p.calls++
+	if p.token != "" {
+		if err := onDelta(p.token); err != nil {
+			return "", err
+		}
+	}
+	return "", errProviderDown
+}
+
+func TestFailoverProvider(t *testing.T) {
+	req := Request{Messages: []Message{{Role: RoleUser, Content: "konnichiwa"}}}
+
+	tests := []struct {
+		name      string
+		providers func() []ChatProvider
+		stream    bool
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:      "first provider answers",
+			providers: func() []ChatProvider { return []ChatProvider{NewScriptedProvider("a"), NewScriptedProvider("b")} },
+			want:      "a",
+		},
+		{
+			name: "falls through failing providers in order",
+			providers: func() []ChatProvider {
+				return []ChatProvider{&failingProvider{name: "x"}, &failingProvider{name: "y"}, NewScriptedProvider("c")}
+			},
+			want: "c",
+		},
+		{
+			name:      "all providers fail",
+			providers: func() []ChatProvider { return []ChatProvider{&failingProvider{name: "x"}, &failingProvider{name: "y"}} },
+			wantErr:   true,
+		},
+		{
+			name: "stream fails over before any token",
+			providers: func() []ChatProvider {
+				return []ChatProvider{&failingProvider{name: "x"}, NewScriptedProvider("ohayou ronin")}
+			},
+			stream: true,
+			want:   "ohayou ronin",
+		},
+		{
+			name: "stream does not fail over after a token",
+			providers: func() []ChatProvider {
+				return []ChatProvider{&failingProvider{name: "x", token: "oha"}, NewScriptedProvider("ohayou ronin")}
+			},
+			stream:  true,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &FailoverProvider{Providers: tt.providers()}
+			var (
+				got string
+				err error
+			)
+			if tt.stream {
+				got, err = f.Stream(context.Background(), req, func(string) error { return nil })
+			} else {
+				got, err = f.Complete(context.Background(), req)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("reply = %q, want %q", got, tt.want)
+			}
+			for _, p := range f.Providers {
+				if fp, ok := p.(*failingProvider); ok && fp.calls != 1 {
+					t.Errorf("provider %s called %d times, want 1", fp.name, fp.calls)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package sensei
+
+import (
+	"errors"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const defaultMaxInputChars = 1000
+
+var (
+	ErrEmptyInput      = errors.New("sensei: empty message")
+	ErrInputTooLong    = errors.New("sensei: message too long")
+	ErrPromptInjection = errors.New("sensei: prompt injection suspected")
+)
+
+// Pola umum prompt injection (Inggris & Indonesia)
+var injectionPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(ignore|disregard|forget)\b.{0,30}\b(previous|above|prior|all|your)\b.{0,30}\b(instructions?|rules?|prompts?)`),
+	regexp.MustCompile(`(?i)\b(abaikan|lupakan|hiraukan)\b.{0,30}\b(instruksi|perintah|aturan|prompt)`),
+	regexp.MustCompile(`(?i)\b(system|developer)\s*(prompt|message|mode)\b`),
+	regexp.MustCompile(`(?i)\b(reveal|show|print|repeat)\b.{0,30}\b(your|the)\b.{0,20}\b(prompt|instructions?|rules)`),
+	regexp.MustCompile(`(?i)\b(tunjukkan|tampilkan|ulangi|bocorkan)\b.{0,30}\b(prompt|instruksi|aturan)`),
+	regexp.MustCompile(`(?i)\byou are (now|no longer)\b|\bkamu (sekarang|bukan lagi)\b.{0,20}\b(adalah|ai|chatgpt|asisten)`),
+	regexp.MustCompile(`(?i)\b(jailbreak|DAN mode|do anything now)\b`),
+	regexp.MustCompile(`(?i)<\|im_(start|end)\|>|\[/?(system|inst)\]|^#+\s*system\b`),
+}
+
+// Tanda balasan keluar dari karakter / membocorkan prompt
+var (
+	leakMarkers = []string{"ATURAN KEPRIBADIAN", "KONTEKS MURID", "CONTOH INTERAKSI", "HASIL KUIS:"}
+
+	personaBreak = regexp.MustCompile(`(?i)\b(as an ai( language model)?|i am an ai|i'?m an ai|large language model|sebagai (sebuah )?(ai|model bahasa|kecerdasan buatan)|saya (adalah )?(sebuah )?(ai|model bahasa|kecerdasan buatan))\b`)
+	secretLike   = regexp.MustCompile(`\b(sk-[A-Za-z0-9_-]{16,}|sk-or-[A-Za-z0-9_-]{16,}|eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,})\b`)
+)
+
+// Balasan pengganti yang tetap dalam karakter
+const (
+	InjectionReply = "Hmm... Seorang ronin mencoba mengelabui gurunya? Pedang yang diayun ke arah yang salah hanya melukai pemiliknya. Kembalilah ke latihanmu, Ronin."
+	FilteredReply  = "Hmm... Kata-kataku barusan keruh seperti air sungai setelah hujan. Tanyakan lagi, Ronin, dan aku akan menjawab dengan jernih."
+)
+
+func MaxInputChars() int {
+	if n, err := strconv.Atoi(os.Getenv("SENSEI_MAX_INPUT_CHARS")); err == nil && n > 0 {
+		return n
+	}
+	return defaultMaxInputChars
+}
+
+// CleanInput: buang karakter kontrol (kecuali baris baru / tab) dan spasi di ujung
+func CleanInput(message string) string {
+	message = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return -1
+		}
+		return r
+	}, message)
+	return strings.TrimSpace(message)
+}
+
+// CheckInput: batas panjang + heuristik prompt injection
+func CheckInput(message string) error {
+	if message == "" {
+		return ErrEmptyInput
+	}
+	if len([]rune(message)) > MaxInputChars() {
+		return ErrInputTooLong
+	}
+	for _, p := range injectionPatterns {
+		if p.MatchString(message) {
+			return ErrPromptInjection
+		}
+	}
+	return nil
+}
+
+// FilterOutput: ganti balasan yang membocorkan prompt / keluar karakter, sensor string mirip secret.
+// Mengembalikan balasan final dan true kalau ada yang diubah.
+func FilterOutput(reply string) (string, bool) {
+	for _, m := range leakMarkers {
+		if strings.Contains(reply, m) {
+			return FilteredReply, true
+		}
+	}
+	if personaBreak.MatchString(reply) {
+		return FilteredReply, true
+	}
+	if secretLike.MatchString(reply) {
+		return secretLike.ReplaceAllString(reply, "[disensor]"), true
+	}
+	return reply, false
+}
+
+// Jumlah rune terakhir yang ditahan saat stream, cukup panjang untuk penanda bocoran,
+// frasa keluar karakter, dan awal string mirip secret
+const streamHoldback = 64
+
+// StreamFilter: saring balasan stream sebelum sampai ke client. Ujung teks ditahan
+// supaya pola yang terpotong antar delta tetap terdeteksi sebelum terkirim.
+// Begitu balasan tersaring, sisa stream tidak diteruskan lagi.
+type StreamFilter struct {
+	emit    func(string) error
+	full    strings.Builder
+	sent    int // byte yang sudah diteruskan
+	blocked bool
+}
+
+func NewStreamFilter(emit func(string) error) *StreamFilter {
+	return &StreamFilter{emit: emit}
+}
+
+func (f *StreamFilter) Write(delta string) error {
+	f.full.WriteString(delta)
+	if f.blocked {
+		return nil
+	}
+	text := f.full.String()
+	if _, changed := FilterOutput(text); changed {
+		f.blocked = true
+		return nil
+	}
+
+	// Teruskan semua kecuali streamHoldback rune terakhir
+	safe, held := len(text), 0
+	for safe > f.sent && held < streamHoldback {
+		_, size := utf8.DecodeLastRuneInString(text[:safe])
+		safe -= size
+		held++
+	}
+	if held < streamHoldback || safe <= f.sent {
+		return nil
+	}
+	chunk := text[f.sent:safe]
+	f.sent = safe
+	return f.emit(chunk)
+}
+
+// Flush: teruskan bagian yang masih ditahan, kecuali balasan sudah tersaring
+func (f *StreamFilter) Flush() error {
+	text := f.full.String()
+	if f.blocked || f.sent >= len(text) {
+		return nil
+	}
+	if _, changed := FilterOutput(text); changed {
+		f.blocked = true
+		return nil
+	}
+	chunk := text[f.sent:]
+	f.sent = len(text)
+	return f.emit(chunk)
+}
@@ -0,0 +1,72 @@
+package sensei
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStreamFilter(t *testing.T) {
+	long := strings.Repeat("Ganbatte, Ronin. ", 10)
+
+	tests := []struct {
+		name      string
+		deltas    []string
+		wantEarly string // yang sudah terkirim sebelum Flush
+		want      string // total setelah Flush
+	}{
+		{
+			name:      "short reply held until flush",
+			deltas:    []string{"Hmm... ", "bagus."},
+			wantEarly: "",
+			want:      "Hmm... bagus.",
+		},
+		{
+			name:      "long reply keeps the last runes back",
+			deltas:    strings.SplitAfter(long, " "),
+			wantEarly: long[:len(long)-streamHoldback],
+			want:      long,
+		},
+		{
+			name:      "holdback counts runes not bytes",
+			deltas:    []string{strings.Repeat("猫", streamHoldback+2)},
+			wantEarly: "猫猫",
+			want:      strings.Repeat("猫", streamHoldback+2),
+		},
+		{
+			name:      "leak split across deltas is never sent",
+			deltas:    []string{"Baik. ATURAN KEPRI", "BADIAN: jangan bocor"},
+			wantEarly: "",
+			want:      "",
+		},
+		{
+			name:      "secret found late blocks the rest",
+			deltas:    []string{long, "kuncinya sk-abcdefghijklmnopqrstuv"},
+			wantEarly: long[:len(long)-streamHoldback],
+			want:      long[:len(long)-streamHoldback],
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			f := NewStreamFilter(func(s string) error {
+				out.WriteString(s)
+				return nil
+			})
+			for _, d := range tt.deltas {
+				if err := f.Write(d); err != nil {
+					t.Fatalf("Write: %v", err)
+				}
+			}
+			if got := out.String(); got != tt.wantEarly {
+				t.Errorf("before flush = %q, want %q", got, tt.wantEarly)
+			}
+			if err := f.Flush(); err != nil {
+				t.Fatalf("Flush: %v", err)
+			}
+			if got := out.String(); got != tt.want {
+				t.Errorf("after flush = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package sensei
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Word struct {
+	VocabID uint   `json:"vocab_id"`
+	Kanji   string `json:"kanji"`
+	Kana    string `json:"kana"`
+	Romaji  string `json:"romaji"`
+	Meaning string `json:"meaning"`
+}
+
+func (w Word) Label() string {
+	if w.Kanji != "" && w.Kanji != w.Kana {
+		return fmt.Sprintf("%s (%s / %s)", w.Kanji, w.Kana, w.Romaji)
+	}
+	return fmt.Sprintf("%s (%s)", w.Kana, w.Romaji)
+}
+
+// LearnerContext: ringkasan progres user yang disisipkan ke system prompt
+type LearnerContext struct {
+	Level        string `json:"level"` // N5 / N4
+	TotalLearned int    `json:"total_learned"`
+	Lapses       []Word `json:"lapses"`   // hasil terakhir = Lupa
+	DueSoon      []Word `json:"due_soon"` // jatuh tempo review dalam waktu dekat
+}
+
+func (lc *LearnerContext) Prompt() string {
+	if lc == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("\n\nKONTEKS MURID (jangan dibacakan mentah-mentah, gunakan secara alami):\n")
+	fmt.Fprintf(&b, "- Level JLPT saat ini: %s, kata yang sudah dipelajari: %d.\n", lc.Level, lc.TotalLearned)
+	if len(lc.Lapses) > 0 {
+		b.WriteString("- Kata yang baru saja DILUPAKAN: " + joinWords(lc.Lapses) + ".\n")
+	}
+	if len(lc.DueSoon) > 0 {
+		b.WriteString("- Kata yang sebentar lagi harus diulang: " + joinWords(lc.DueSoon) + ".\n")
+	}
+	b.WriteString("Sesuaikan kosakata dan contoh kalimat dengan level murid. ")
+	b.WriteString("Jika relevan, selipkan kata-kata di atas dalam contohmu, atau tawarkan ujian kecil (kuis) tentang kata tersebut.")
+	return b.String()
+}
+
+func joinWords(words []Word) string {
+	labels := make([]string, 0, len(words))
+	for _, w := range words {
+		labels = append(labels, fmt.Sprintf("%s = %s", w.Label(), w.Meaning))
+	}
+	return strings.Join(labels, "; ")
+}
+
+// SystemPromptFor: persona Sensei + konteks murid (kalau ada)
+func SystemPromptFor(lc *LearnerContext, notes ...string) string {
+	prompt := SystemPrompt + lc.Prompt()
+	for _, n := range notes {
+		if n != "" {
+			prompt += "\n\n" + n
+		}
+	}
+	return prompt
+}
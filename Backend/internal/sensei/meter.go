@@ -0,0 +1,151 @@
+package sensei
+
+import (
+	"context"
+	"sync"
+	"unicode"
+)
+
+// Usage: token satu giliran (bisa beberapa panggilan LLM, mis. tool calling).
+// Dari field usage provider kalau dilaporkan, selain itu perkiraan.
+type Usage struct {
+	Calls            int
+	PromptTokens     int
+	CompletionTokens int
+}
+
+func (u Usage) Total() int { return u.PromptTokens + u.CompletionTokens }
+
+// EstimateTokens: ~4 karakter latin per token, 1 token per karakter CJK
+func EstimateTokens(text string) int {
+	latin, cjk := 0, 0
+	for _, r := range text {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) {
+			cjk++
+		} else {
+			latin++
+		}
+	}
+	return cjk + (latin+3)/4
+}
+
+func EstimateMessages(msgs []Message) int {
+	n := 0
+	for _, m := range msgs {
+		n += 4 + EstimateTokens(m.Content) // overhead per pesan
+		for _, tc := range m.ToolCalls {
+			n += EstimateTokens(tc.Function.Name + tc.Function.Arguments)
+		}
+	}
+	return n
+}
+
+type usageReporterKey struct{}
+
+// reportUsage: provider melaporkan usage asli dari response API ke MeteredProvider (kalau ada)
+func reportUsage(ctx context.Context, promptTokens, completionTokens int) {
+	if promptTokens+completionTokens == 0 {
+		return
+	}
+	if fn, ok := ctx.Value(usageReporterKey{}).(func(Usage)); ok {
+		fn(Usage{Calls: 1, PromptTokens: promptTokens, CompletionTokens: completionTokens})
+	}
+}
+
+// Field usage di response OpenAI / OpenRouter
+type apiUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// MeteredProvider: bungkus provider dan catat token setiap panggilan
+type MeteredProvider struct {
+	ChatProvider
+
+	mu    sync.Mutex
+	usage Usage
+}
+
+func Meter(p ChatProvider) *MeteredProvider {
+	return &MeteredProvider{ChatProvider: p}
+}
+
+func (m *MeteredProvider) Usage() Usage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.usage
+}
+
+// Context yang menampung usage asli dari provider selama satu panggilan
+func withUsageReporter(ctx context.Context) (context.Context, *Usage) {
+	reported := &Usage{}
+	var mu sync.Mutex
+	return context.WithValue(ctx, usageReporterKey{}, func(u Usage) {
+		mu.Lock()
+		defer mu.Unlock()
+		reported.PromptTokens += u.PromptTokens
+		reported.CompletionTokens += u.CompletionTokens
+	}), reported
+}
+
+// add: pakai usage dari provider kalau dilaporkan, selain itu perkiraan dari teks
+func (m *MeteredProvider) add(req Request, reply string, reported *Usage) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.usage.Calls++
+	if reported.Total() > 0 {
+		m.usage.PromptTokens += reported.PromptTokens
+		m.usage.CompletionTokens += reported.CompletionTokens
+		return
+	}
+	m.usage.PromptTokens += EstimateMessages(req.Messages)
+	m.usage.CompletionTokens += EstimateTokens(reply)
+}
+
+func (m *MeteredProvider) Complete(ctx context.Context, req Request) (string, error) {
+	ctx, reported := withUsageReporter(ctx)
+	reply, err := m.ChatProvider.Complete(ctx, req)
+	m.add(req, reply, reported)
+	return reply, err
+}
+
+func (m *MeteredProvider) CompleteWithTools(ctx context.Context, req Request) (Message, error) {
+	ctx, reported := withUsageReporter(ctx)
+	msg, err := completeWithTools(ctx, m.ChatProvider, req)
+	m.add(req, msg.Content+toolCallText(msg), reported)
+	return msg, err
+}
+
+// Stream: token yang sudah terkirim tetap dihitung walau stream terputus
+func (m *MeteredProvider) Stream(ctx context.Context, req Request, onDelta func(string) error) (string, error) {
+	ctx, reported := withUsageReporter(ctx)
+	var sent []byte
+	reply, err := m.ChatProvider.Stream(ctx, req, func(delta string) error {
+		sent = append(sent, delta...)
+		return onDelta(delta)
+	})
+	if err != nil {
+		reply = string(sent)
+	}
+	m.add(req, reply, reported)
+	return reply, err
+}
+
+func (m *MeteredProvider) StreamWithTools(ctx context.Context, req Request, onDelta func(string) error) (Message, error) {
+	ctx, reported := withUsageReporter(ctx)
+	var sent []byte
+	msg, err := streamWithTools(ctx, m.ChatProvider, req, func(delta string) error {
+		sent = append(sent, delta...)
+		return onDelta(delta)
+	})
+	m.add(req, string(sent)+toolCallText(msg), reported)
+	return msg, err
+}
+
+func toolCallText(msg Message) string {
+	out := ""
+	for _, tc := range msg.ToolCalls {
+		out += tc.Function.Name + tc.Function.Arguments
+	}
+	return out
+}
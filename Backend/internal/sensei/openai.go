@@ -0,0 +1,173 @@
+package sensei
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAIProvider: API kompatibel OpenAI (/chat/completions).
+// Contoh BaseURL: https://openrouter.ai/api/v1, http://localhost:11434/v1 (Ollama), http://localhost:8081/v1 (llama.cpp)
+type OpenAIProvider struct {
+	BaseURL string
+	APIKey  string
+	Headers map[string]string
+	client  *http.Client
+}
+
+func NewOpenAIProvider(baseURL, apiKey string) *OpenAIProvider {
+	return &OpenAIProvider{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		APIKey:  apiKey,
+		Headers: map[string]string{"X-Title": "Kaiwa Rift"},
+		client:  &http.Client{Transport: &http.Transport{ResponseHeaderTimeout: 60 * time.Second}},
+	}
+}
+
+func (p *OpenAIProvider) Name() string { return "openai" }
+
+type openAIChatRequest struct {
+	Model       string    `json:"model"`
+	Messages    []Message `json:"messages"`
+	Temperature float64   `json:"temperature"`
+	MaxTokens   int       `json:"max_tokens,omitempty"`
+	Stream      bool      `json:"stream"`
+	Tools       []Tool    `json:"tools,omitempty"`
+	// Stream: minta chunk terakhir membawa usage asli
+	StreamOptions *openAIStreamOptions `json:"stream_options,omitempty"`
+}
+
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+func (p *OpenAIProvider) post(ctx context.Context, req Request, stream bool) (*http.Response, error) {
+	payload := openAIChatRequest{
+		Model:       req.Model,
+		Messages:    req.Messages,
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+		Stream:      stream,
+		Tools:       req.Tools,
+	}
+	if stream {
+		payload.StreamOptions = &openAIStreamOptions{IncludeUsage: true}
+	}
+	body, _ := json.Marshal(payload)
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.BaseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.APIKey)
+	}
+	for k, v := range p.Headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("openai provider: status %d", resp.StatusCode)
+	}
+	return resp, nil
+}
+
+func (p *OpenAIProvider) Complete(ctx context.Context, req Request) (string, error) {
+	req.Tools = nil
+	msg, err := p.CompleteWithTools(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	if msg.Content == "" {
+		return "", ErrEmptyReply
+	}
+	return msg.Content, nil
+}
+
+func (p *OpenAIProvider) CompleteWithTools(ctx context.Context, req Request) (Message, error) {
+	resp, err := p.post(ctx, req, false)
+	if err != nil {
+		return Message{}, err
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		Choices []struct {
+			Message Message `json:"message"`
+		} `json:"choices"`
+		Usage apiUsage `json:"usage"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return Message{}, err
+	}
+	reportUsage(ctx, out.Usage.PromptTokens, out.Usage.CompletionTokens)
+	if len(out.Choices) == 0 {
+		return Message{}, ErrEmptyReply
+	}
+	return out.Choices[0].Message, nil
+}
+
+func (p *OpenAIProvider) Stream(ctx context.Context, req Request, onDelta func(string) error) (string, error) {
+	req.Tools = nil
+	msg, err := p.StreamWithTools(ctx, req, onDelta)
+	if err != nil {
+		return "", err
+	}
+	return msg.Content, nil
+}
+
+func (p *OpenAIProvider) StreamWithTools(ctx context.Context, req Request, onDelta func(string) error) (Message, error) {
+	resp, err := p.post(ctx, req, true)
+	if err != nil {
+		return Message{}, err
+	}
+	defer resp.Body.Close()
+
+	var full strings.Builder
+	var calls []ToolCall
+	_, err = readSSE(resp.Body, func(data string) error {
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content   string          `json:"content"`
+					ToolCalls []toolCallDelta `json:"tool_calls"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Usage *apiUsage `json:"usage"`
+		}
+		if json.Unmarshal([]byte(data), &chunk) != nil {
+			return nil
+		}
+		if chunk.Usage != nil {
+			reportUsage(ctx, chunk.Usage.PromptTokens, chunk.Usage.CompletionTokens)
+		}
+		if len(chunk.Choices) == 0 {
+			return nil
+		}
+		delta := chunk.Choices[0].Delta
+		calls = mergeToolCallDeltas(calls, delta.ToolCalls)
+		if delta.Content == "" {
+			return nil
+		}
+		full.WriteString(delta.Content)
+		return onDelta(delta.Content)
+	})
+	if err != nil {
+		return Message{}, err
+	}
+	// Beberapa server lokal menutup stream tanpa [DONE]
+	if full.Len() == 0 && len(calls) == 0 {
+		return Message{}, ErrEmptyReply
+	}
+	return Message{Role: RoleAssistant, Content: full.String(), ToolCalls: calls}, nil
+}
@@ -0,0 +1,49 @@
+package sensei
+
+import (
+	"context"
+	"errors"
+)
+
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool"
+)
+
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+	// Khusus tool calling (format OpenAI)
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+}
+
+// Request ke LLM. Messages sudah termasuk system prompt di index 0.
+type Request struct {
+	Messages    []Message
+	Model       string
+	Temperature float64
+	MaxTokens   int
+	Tools       []Tool
+}
+
+// ChatProvider: backend LLM untuk Shouma-sensei
+type ChatProvider interface {
+	Name() string
+	Complete(ctx context.Context, req Request) (string, error)
+	// Stream memanggil onDelta untuk setiap potongan teks dan mengembalikan balasan lengkap
+	Stream(ctx context.Context, req Request, onDelta func(string) error) (string, error)
+}
+
+var ErrEmptyReply = errors.New("sensei: empty reply")
+
+// BuildMessages: system prompt + riwayat + pesan user terbaru
+func BuildMessages(systemPrompt string, history []Message, userMessage string) []Message {
+	msgs := make([]Message, 0, len(history)+2)
+	msgs = append(msgs, Message{Role: RoleSystem, Content: systemPrompt})
+	msgs = append(msgs, history...)
+	msgs = append(msgs, Message{Role: RoleUser, Content: userMessage})
+	return msgs
+}
@@ -0,0 +1,177 @@
+package sensei
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// PythonProvider: lewat ml_service (/chat & /chat/stream) yang meneruskan ke OpenRouter
+type PythonProvider struct {
+	BaseURL string
+	client  *http.Client
+}
+
+func NewPythonProvider(baseURL string) *PythonProvider {
+	return &PythonProvider{
+		BaseURL: baseURL,
+		client:  &http.Client{Transport: &http.Transport{ResponseHeaderTimeout: 30 * time.Second}},
+	}
+}
+
+func (p *PythonProvider) Name() string { return "python" }
+
+type pythonChatRequest struct {
+	Message      string    `json:"message"`
+	History      []Message `json:"history"`
+	SystemPrompt string    `json:"system_prompt,omitempty"`
+	Model        string    `json:"model,omitempty"`
+	Temperature  *float64  `json:"temperature,omitempty"` // pointer: 0 tetap dikirim
+	MaxTokens    int       `json:"max_tokens,omitempty"`
+	// Mode tool calling: messages dikirim utuh (termasuk system & hasil tool)
+	Messages []Message `json:"messages,omitempty"`
+	Tools    []Tool    `json:"tools,omitempty"`
+}
+
+func (p *PythonProvider) payload(req Request) ([]byte, error) {
+	temperature := req.Temperature
+	out := pythonChatRequest{Model: req.Model, Temperature: &temperature, MaxTokens: req.MaxTokens}
+	msgs := req.Messages
+	if len(msgs) > 0 && msgs[0].Role == RoleSystem {
+		out.SystemPrompt = msgs[0].Content
+		msgs = msgs[1:]
+	}
+	if len(msgs) == 0 {
+		return nil, fmt.Errorf("python provider: no user message")
+	}
+	out.Message = msgs[len(msgs)-1].Content
+	out.History = msgs[:len(msgs)-1]
+	// Putaran terakhir tool (Tools sudah nil) tetap butuh messages utuh, kalau tidak hasil tool
+	// ikut diratakan jadi pesan user oleh ml_service
+	if len(req.Tools) > 0 || hasToolMessages(req.Messages) {
+		out.Messages = req.Messages
+		out.Tools = req.Tools
+	}
+	return json.Marshal(out)
+}
+
+func hasToolMessages(msgs []Message) bool {
+	for _, m := range msgs {
+		if m.Role == RoleTool || len(m.ToolCalls) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *PythonProvider) post(ctx context.Context, path string, req Request) (*http.Response, error) {
+	body, err := p.payload(req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.BaseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("python provider: status %d", resp.StatusCode)
+	}
+	return resp, nil
+}
+
+func (p *PythonProvider) Complete(ctx context.Context, req Request) (string, error) {
+	req.Tools = nil
+	msg, err := p.CompleteWithTools(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	if msg.Content == "" {
+		return "", ErrEmptyReply
+	}
+	return msg.Content, nil
+}
+
+func (p *PythonProvider) CompleteWithTools(ctx context.Context, req Request) (Message, error) {
+	resp, err := p.post(ctx, "/chat", req)
+	if err != nil {
+		return Message{}, err
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		Reply     string     `json:"reply"`
+		ToolCalls []ToolCall `json:"tool_calls"`
+		Usage     apiUsage   `json:"usage"`
+		Error     string     `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return Message{}, err
+	}
+	reportUsage(ctx, out.Usage.PromptTokens, out.Usage.CompletionTokens)
+	// ml_service melaporkan kegagalan upstream lewat field error -> biar failover jalan
+	if out.Error != "" {
+		return Message{}, fmt.Errorf("python provider: %s", out.Error)
+	}
+	return Message{Role: RoleAssistant, Content: out.Reply, ToolCalls: out.ToolCalls}, nil
+}
+
+func (p *PythonProvider) Stream(ctx context.Context, req Request, onDelta func(string) error) (string, error) {
+	req.Tools = nil
+	msg, err := p.StreamWithTools(ctx, req, onDelta)
+	if err != nil {
+		return "", err
+	}
+	return msg.Content, nil
+}
+
+// StreamWithTools: ml_service meneruskan delta teks dan delta tool call dari OpenRouter
+func (p *PythonProvider) StreamWithTools(ctx context.Context, req Request, onDelta func(string) error) (Message, error) {
+	resp, err := p.post(ctx, "/chat/stream", req)
+	if err != nil {
+		return Message{}, err
+	}
+	defer resp.Body.Close()
+
+	var full bytes.Buffer
+	var calls []ToolCall
+	done, err := readSSE(resp.Body, func(data string) error {
+		var chunk struct {
+			Delta     string          `json:"delta"`
+			ToolCalls []toolCallDelta `json:"tool_calls"`
+			Usage     *apiUsage       `json:"usage"`
+			Error     string          `json:"error"`
+		}
+		if json.Unmarshal([]byte(data), &chunk) != nil {
+			return nil
+		}
+		if chunk.Usage != nil {
+			reportUsage(ctx, chunk.Usage.PromptTokens, chunk.Usage.CompletionTokens)
+		}
+		if chunk.Error != "" {
+			return fmt.Errorf("python provider: %s", chunk.Error)
+		}
+		calls = mergeToolCallDeltas(calls, chunk.ToolCalls)
+		if chunk.Delta == "" {
+			return nil
+		}
+		full.WriteString(chunk.Delta)
+		return onDelta(chunk.Delta)
+	})
+	if err != nil {
+		return Message{}, err
+	}
+	if !done {
+		return Message{}, fmt.Errorf("python provider: stream ended without [DONE]")
+	}
+	return Message{Role: RoleAssistant, Content: full.String(), ToolCalls: calls}, nil
+}
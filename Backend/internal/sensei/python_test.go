@@ -0,0 +1,98 @@
+package sensei
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestPythonPayload(t *testing.T) {
+	tools := []Tool{NewTool("lookup_word", "cari kata", `{"type":"object"}`)}
+	system := Message{Role: RoleSystem, Content: "Kamu Shouma-sensei."}
+	hello := Message{Role: RoleUser, Content: "konnichiwa"}
+	answer := Message{Role: RoleAssistant, Content: "Hmm... konnichiwa, Ronin."}
+	ask := Message{Role: RoleUser, Content: "neko itu apa?"}
+	call := Message{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "call_1", Type: "function", Function: FunctionCall{Name: "lookup_word", Arguments: "{}"}}}}
+	result := Message{Role: RoleTool, ToolCallID: "call_1", Content: `{"meaning":"kucing"}`}
+
+	tests := []struct {
+		name    string
+		req     Request
+		want    pythonChatRequest
+		wantErr bool
+	}{
+		{
+			name: "system prompt, history and last message are split",
+			req:  Request{Messages: []Message{system, hello, answer, ask}, Model: "m", MaxTokens: 200},
+			want: pythonChatRequest{
+				Message:      ask.Content,
+				History:      []Message{hello, answer},
+				SystemPrompt: system.Content,
+				Model:        "m",
+				MaxTokens:    200,
+			},
+		},
+		{
+			name: "no system prompt",
+			req:  Request{Messages: []Message{ask}},
+			want: pythonChatRequest{Message: ask.Content, History: []Message{}},
+		},
+		{
+			name: "tools send the full messages",
+			req:  Request{Messages: []Message{system, ask}, Tools: tools},
+			want: pythonChatRequest{
+				Message:      ask.Content,
+				History:      []Message{},
+				SystemPrompt: system.Content,
+				Messages:     []Message{system, ask},
+				Tools:        tools,
+			},
+		},
+		{
+			name: "tool results without tools still send the full messages",
+			req:  Request{Messages: []Message{system, ask, call, result}},
+			want: pythonChatRequest{
+				Message:      result.Content,
+				History:      []Message{ask, call},
+				SystemPrompt: system.Content,
+				Messages:     []Message{system, ask, call, result},
+			},
+		},
+		{
+			name:    "only a system prompt",
+			req:     Request{Messages: []Message{system}},
+			wantErr: true,
+		},
+	}
+
+	p := NewPythonProvider("http://ml")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := p.payload(tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var got pythonChatRequest
+			if err := json.Unmarshal(body, &got); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if got.Temperature == nil || *got.Temperature != tt.req.Temperature {
+				t.Errorf("temperature = %v, want %v", got.Temperature, tt.req.Temperature)
+			}
+			got.Temperature = nil
+
+			// Bandingkan lewat JSON supaya nil dan slice kosong tidak dibedakan
+			wantJSON, _ := json.Marshal(tt.want)
+			gotJSON, _ := json.Marshal(got)
+			var w, g interface{}
+			json.Unmarshal(wantJSON, &w)
+			json.Unmarshal(gotJSON, &g)
+			if !reflect.DeepEqual(g, w) {
+				t.Errorf("payload = %s\nwant %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
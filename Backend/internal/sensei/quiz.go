@@ -0,0 +1,77 @@
+package sensei
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	parenthetical = regexp.MustCompile(`\([^)]*\)`)
+	nonWord       = regexp.MustCompile(`[^\p{L}\p{N}\s-]+`)
+)
+
+func QuizQuestion(w Word) string {
+	return fmt.Sprintf("Ujian kecil, Ronin! Apa arti kata '%s'?", w.Label())
+}
+
+// AcceptedAnswers: "Anda/Kamu" -> [anda kamu], "Dia (Laki-laki)" -> [dia]
+func AcceptedAnswers(meaning string) []string {
+	meaning = parenthetical.ReplaceAllString(meaning, "")
+	var out []string
+	for _, part := range strings.FieldsFunc(meaning, func(r rune) bool { return r == '/' || r == ',' || r == ';' }) {
+		if p := normalizeAnswer(part); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Kata pembuka / penutup yang biasa menempel di jawaban: "artinya kucing", "kucing kan sensei"
+var (
+	answerPrefixes = map[string]bool{"artinya": true, "arti": true, "nya": true, "jawabannya": true, "jawaban": true,
+		"itu": true, "adalah": true, "yaitu": true, "maksudnya": true, "mungkin": true}
+	answerSuffixes = map[string]bool{"ya": true, "kan": true, "deh": true, "sensei": true, "mungkin": true}
+)
+
+// Jawaban kuis paling banyak sekian kata (setelah pembuka/penutup dibuang); lebih dari itu dianggap pesan lain
+const maxAnswerWords = 4
+
+// GradeAnswer: benar kalau jawaban (tanpa pembuka/penutup) sama persis dengan salah satu arti.
+// answered=false kalau pesan tidak terlihat seperti jawaban (pertanyaan / kalimat panjang).
+func GradeAnswer(answer, meaning string) (correct, answered bool) {
+	words := strings.Fields(normalizeAnswer(answer))
+	for len(words) > 1 && answerPrefixes[words[0]] {
+		words = words[1:]
+	}
+	for len(words) > 1 && answerSuffixes[words[len(words)-1]] {
+		words = words[:len(words)-1]
+	}
+	ans := strings.Join(words, " ")
+
+	for _, accepted := range AcceptedAnswers(meaning) {
+		if ans == accepted {
+			return true, true
+		}
+	}
+	if ans == "" || strings.Contains(answer, "?") || len(words) > maxAnswerWords {
+		return false, false
+	}
+	return false, true
+}
+
+func normalizeAnswer(s string) string {
+	s = strings.ToLower(s)
+	s = nonWord.ReplaceAllString(s, " ")
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// QuizResultNote: catatan untuk LLM setelah jawaban kuis dinilai
+func QuizResultNote(w Word, answer string, correct bool) string {
+	verdict := "SALAH"
+	if correct {
+		verdict = "BENAR"
+	}
+	return fmt.Sprintf("HASIL KUIS: Ronin menjawab %q untuk kata %s. Jawaban %s. Arti yang tepat: %s. "+
+		"Tanggapi hasil ini dengan singkat sesuai karaktermu.", answer, w.Label(), verdict, w.Meaning)
+}
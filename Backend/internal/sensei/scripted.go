@@ -0,0 +1,98 @@
+package sensei
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ScriptedProvider: balasan deterministik untuk test/dev tanpa LLM.
+// Balasan diambil berurutan dari Replies (berputar). Kalau kosong, pesan user di-echo.
+// Balasan berbentuk `tool:<nama> <json>` menjadi tool call (kalau request membawa Tools).
+type ScriptedProvider struct {
+	Replies []string
+
+	mu   sync.Mutex
+	next int
+}
+
+func NewScriptedProvider(replies ...string) *ScriptedProvider {
+	return &ScriptedProvider{Replies: replies}
+}
+
+func (p *ScriptedProvider) Name() string { return "scripted" }
+
+func (p *ScriptedProvider) reply(req Request) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.Replies) == 0 {
+		last := ""
+		if n := len(req.Messages); n > 0 {
+			last = req.Messages[n-1].Content
+		}
+		return "Hmm... " + last
+	}
+	r := p.Replies[p.next%len(p.Replies)]
+	p.next++
+	return r
+}
+
+func (p *ScriptedProvider) Complete(ctx context.Context, req Request) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return p.reply(req), nil
+}
+
+// Stream per kata (spasi ikut di potongan berikutnya)
+func (p *ScriptedProvider) Stream(ctx context.Context, req Request, onDelta func(string) error) (string, error) {
+	reply := p.reply(req)
+	for _, word := range strings.SplitAfter(reply, " ") {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		if err := onDelta(word); err != nil {
+			return "", err
+		}
+	}
+	return reply, nil
+}
+
+func (p *ScriptedProvider) CompleteWithTools(ctx context.Context, req Request) (Message, error) {
+	if err := ctx.Err(); err != nil {
+		return Message{}, err
+	}
+	reply := p.reply(req)
+	if len(req.Tools) == 0 || !strings.HasPrefix(reply, "tool:") {
+		return Message{Role: RoleAssistant, Content: reply}, nil
+	}
+
+	name, args, _ := strings.Cut(strings.TrimPrefix(reply, "tool:"), " ")
+	if args = strings.TrimSpace(args); args == "" {
+		args = "{}"
+	}
+	call := ToolCall{
+		ID:       fmt.Sprintf("call_%d", len(req.Messages)),
+		Type:     "function",
+		Function: FunctionCall{Name: name, Arguments: args},
+	}
+	return Message{Role: RoleAssistant, ToolCalls: []ToolCall{call}}, nil
+}
+
+func (p *ScriptedProvider) StreamWithTools(ctx context.Context, req Request, onDelta func(string) error) (Message, error) {
+	msg, err := p.CompleteWithTools(ctx, req)
+	if err != nil || len(msg.ToolCalls) > 0 {
+		return msg, err
+	}
+	for _, word := range strings.SplitAfter(msg.Content, " ") {
+		if err := ctx.Err(); err != nil {
+			return Message{}, err
+		}
+		if err := onDelta(word); err != nil {
+			return Message{}, err
+		}
+	}
+	return msg, nil
+}
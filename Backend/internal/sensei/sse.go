@@ -0,0 +1,28 @@
+package sensei
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// readSSE: panggil fn untuk setiap payload "data:" sampai [DONE] atau EOF.
+// done=true berarti stream selesai dengan [DONE].
+func readSSE(r io.Reader, fn func(data string) error) (done bool, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			return true, nil
+		}
+		if err := fn(data); err != nil {
+			return false, err
+		}
+	}
+	return false, scanner.Err()
+}
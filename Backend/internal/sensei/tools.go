@@ -0,0 +1,245 @@
+package sensei
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Tool & ToolCall mengikuti format function calling OpenAI
+type Tool struct {
+	Type     string       `json:"type"` // "function"
+	Function ToolFunction `json:"function"`
+}
+
+type ToolFunction struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters"`
+}
+
+type ToolCall struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"`
+	Function FunctionCall `json:"function"`
+}
+
+type FunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"` // JSON string
+}
+
+func NewTool(name, description, parameters string) Tool {
+	return Tool{Type: "function", Function: ToolFunction{Name: name, Description: description, Parameters: json.RawMessage(parameters)}}
+}
+
+// ToolCaller: provider yang mendukung tool calling.
+// Balasan berupa pesan assistant: Content biasa, atau ToolCalls.
+type ToolCaller interface {
+	CompleteWithTools(ctx context.Context, req Request) (Message, error)
+}
+
+// ToolStreamer: provider yang bisa men-stream balasan sambil tetap menawarkan tool.
+// Potongan Content dikirim ke onDelta; tool call yang diminta model dikembalikan di Message.
+type ToolStreamer interface {
+	StreamWithTools(ctx context.Context, req Request, onDelta func(string) error) (Message, error)
+}
+
+// Potongan tool call dari stream (format delta OpenAI): name/arguments datang bertahap per index
+type toolCallDelta struct {
+	Index    int          `json:"index"`
+	ID       string       `json:"id"`
+	Type     string       `json:"type"`
+	Function FunctionCall `json:"function"`
+}
+
+// Batas jumlah tool call paralel yang diterima dari satu stream
+const maxStreamToolCalls = 8
+
+func mergeToolCallDeltas(calls []ToolCall, deltas []toolCallDelta) []ToolCall {
+	for _, d := range deltas {
+		if d.Index < 0 || d.Index >= maxStreamToolCalls {
+			continue
+		}
+		for len(calls) <= d.Index {
+			calls = append(calls, ToolCall{Type: "function"})
+		}
+		call := &calls[d.Index]
+		if d.ID != "" {
+			call.ID = d.ID
+		}
+		call.Function.Name += d.Function.Name
+		call.Function.Arguments += d.Function.Arguments
+	}
+	return calls
+}
+
+// ToolExecutor menjalankan satu tool call dan mengembalikan hasil (JSON) untuk model.
+// Implementasi wajib memakai identitas user dari sesi, bukan dari argumen model.
+type ToolExecutor func(ctx context.Context, call ToolCall) (result interface{}, err error)
+
+// ToolResult: jejak eksekusi tool untuk response API / log
+type ToolResult struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+	OK        bool            `json:"ok"`
+	Result    interface{}     `json:"result,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// Batas putaran tool per giliran chat
+const MaxToolRounds = 3
+
+func completeWithTools(ctx context.Context, p ChatProvider, req Request) (Message, error) {
+	if tc, ok := p.(ToolCaller); ok {
+		return tc.CompleteWithTools(ctx, req)
+	}
+	// Provider tanpa dukungan tool: jawab biasa
+	req.Tools = nil
+	reply, err := p.Complete(ctx, req)
+	return Message{Role: RoleAssistant, Content: reply}, err
+}
+
+func streamWithTools(ctx context.Context, p ChatProvider, req Request, onDelta func(string) error) (Message, error) {
+	if ts, ok := p.(ToolStreamer); ok {
+		return ts.StreamWithTools(ctx, req, onDelta)
+	}
+	if _, ok := p.(ToolCaller); ok {
+		// Tool calling hanya non-stream: balasan biasa dikirim sekaligus
+		msg, err := completeWithTools(ctx, p, req)
+		if err == nil && len(msg.ToolCalls) == 0 && msg.Content != "" {
+			err = onDelta(msg.Content)
+		}
+		return msg, err
+	}
+	req.Tools = nil
+	reply, err := p.Stream(ctx, req, onDelta)
+	return Message{Role: RoleAssistant, Content: reply}, err
+}
+
+// ResolveTools menjalankan putaran tool calling sampai model berhenti memanggil tool.
+// Mengembalikan request tanpa Tools yang sudah berisi hasil tool, plus balasan akhir
+// kalau model sudah menjawab. Balasan kosong berarti caller perlu Complete/Stream sekali lagi.
+func ResolveTools(ctx context.Context, p ChatProvider, req Request, exec ToolExecutor) (Request, string, []ToolResult, error) {
+	var trace []ToolResult
+	if len(req.Tools) == 0 {
+		return req, "", nil, nil
+	}
+
+	for round := 0; round < MaxToolRounds; round++ {
+		msg, err := completeWithTools(ctx, p, req)
+		if err != nil {
+			req.Tools = nil
+			return req, "", trace, err
+		}
+		if len(msg.ToolCalls) == 0 {
+			req.Tools = nil
+			if msg.Content == "" {
+				return req, "", trace, ErrEmptyReply
+			}
+			return req, msg.Content, trace, nil
+		}
+
+		msg.Role = RoleAssistant
+		req.Messages = append(req.Messages, msg)
+		for _, call := range msg.ToolCalls {
+			res := runTool(ctx, exec, call)
+			trace = append(trace, res)
+
+			payload := res.Result
+			if !res.OK {
+				payload = map[string]string{"error": res.Error}
+			}
+			content, _ := json.Marshal(payload)
+			req.Messages = append(req.Messages, Message{Role: RoleTool, ToolCallID: call.ID, Content: string(content)})
+		}
+	}
+
+	// Putaran habis: minta jawaban akhir tanpa tool
+	req.Tools = nil
+	return req, "", trace, nil
+}
+
+// StreamTools: seperti ResolveTools, tapi setiap putaran di-stream. Kalau model langsung
+// menjawab tanpa tool, jawabannya sampai ke onDelta tanpa panggilan LLM tambahan.
+// onTools dipanggil dengan hasil tool setiap putaran. Mengembalikan seluruh teks yang di-stream.
+func StreamTools(ctx context.Context, p ChatProvider, req Request, exec ToolExecutor,
+	onDelta func(string) error, onTools func([]ToolResult)) (string, error) {
+	var full strings.Builder
+	sent := func(delta string) error {
+		full.WriteString(delta)
+		return onDelta(delta)
+	}
+
+	for round := 0; round < MaxToolRounds && len(req.Tools) > 0; round++ {
+		msg, err := streamWithTools(ctx, p, req, sent)
+		if err != nil {
+			if ctx.Err() != nil || full.Len() > 0 {
+				return full.String(), err
+			}
+			// Model/provider tanpa dukungan tool: lanjut sebagai chat biasa
+			log.Printf("[WARN] Sensei tool calling failed, falling back to plain chat: %v", err)
+			break
+		}
+		if len(msg.ToolCalls) == 0 {
+			if full.Len() == 0 {
+				return "", ErrEmptyReply
+			}
+			return full.String(), nil
+		}
+
+		msg.Role = RoleAssistant
+		req.Messages = append(req.Messages, msg)
+		var results []ToolResult
+		for _, call := range msg.ToolCalls {
+			res := runTool(ctx, exec, call)
+			results = append(results, res)
+
+			payload := res.Result
+			if !res.OK {
+				payload = map[string]string{"error": res.Error}
+			}
+			content, _ := json.Marshal(payload)
+			req.Messages = append(req.Messages, Message{Role: RoleTool, ToolCallID: call.ID, Content: string(content)})
+		}
+		onTools(results)
+	}
+
+	// Putaran habis (atau tool tidak didukung): jawaban akhir tanpa tool, tetap di-stream
+	req.Tools = nil
+	if _, err := p.Stream(ctx, req, sent); err != nil {
+		return full.String(), err
+	}
+	return full.String(), nil
+}
+
+func runTool(ctx context.Context, exec ToolExecutor, call ToolCall) ToolResult {
+	res := ToolResult{Name: call.Function.Name, Arguments: json.RawMessage(call.Function.Arguments)}
+	if !json.Valid(res.Arguments) {
+		res.Arguments = json.RawMessage("{}")
+	}
+
+	out, err := exec(ctx, call)
+	if err != nil {
+		log.Printf("[WARN] Sensei tool %s failed: %v", call.Function.Name, err)
+		res.Error = err.Error()
+		return res
+	}
+	res.OK = true
+	res.Result = out
+	return res
+}
+
+// DecodeArgs: parse argumen tool ke struct
+func DecodeArgs(call ToolCall, v interface{}) error {
+	args := call.Function.Arguments
+	if args == "" {
+		args = "{}"
+	}
+	if err := json.Unmarshal([]byte(args), v); err != nil {
+		return fmt.Errorf("invalid arguments for %s", call.Function.Name)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package sensei
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestResolveTools(t *testing.T) {
+	tools := []Tool{NewTool("lookup_word", "cari kata", `{"type":"object"}`)}
+
+	tests := []struct {
+		name      string
+		replies   []string
+		tools     []Tool
+		execErr   error
+		wantReply string
+		wantTrace int
+		wantOK    bool
+		wantErr   error
+	}{
+		{
+			name:      "no tools skips the loop",
+			replies:   []string{"tidak dipanggil"},
+			wantReply: "",
+		},
+		{
+			name:      "direct answer",
+			replies:   []string{"Neko artinya kucing."},
+			tools:     tools,
+			wantReply: "Neko artinya kucing.",
+		},
+		{
+			name:      "one tool round then answer",
+			replies:   []string{`tool:lookup_word {"word":"neko"}`, "Neko artinya kucing."},
+			tools:     tools,
+			wantReply: "Neko artinya kucing.",
+			wantTrace: 1,
+			wantOK:    true,
+		},
+		{
+			name:      "rounds exhausted leaves reply empty",
+			replies:   []string{"tool:lookup_word {}"},
+			tools:     tools,
+			wantTrace: MaxToolRounds,
+			wantOK:    true,
+		},
+		{
+			name:      "tool error is traced, not fatal",
+			replies:   []string{"tool:lookup_word {}", "Maaf, kamus sedang tutup."},
+			tools:     tools,
+			execErr:   errors.New("db down"),
+			wantReply: "Maaf, kamus sedang tutup.",
+			wantTrace: 1,
+		},
+		{
+			name:    "empty answer",
+			replies: []string{""},
+			tools:   tools,
+			wantErr: ErrEmptyReply,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewScriptedProvider(tt.replies...)
+			req := Request{Messages: []Message{{Role: RoleUser, Content: "neko itu apa?"}}, Tools: tt.tools}
+			exec := func(ctx context.Context, call ToolCall) (interface{}, error) {
+				if tt.execErr != nil {
+					return nil, tt.execErr
+				}
+				return map[string]string{"meaning": "kucing"}, nil
+			}
+
+			out, reply, trace, err := ResolveTools(context.Background(), p, req, exec)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if reply != tt.wantReply {
+				t.Errorf("reply = %q, want %q", reply, tt.wantReply)
+			}
+			if len(trace) != tt.wantTrace {
+				t.Fatalf("trace = %d entries, want %d", len(trace), tt.wantTrace)
+			}
+			for _, res := range trace {
+				if res.Name != "lookup_word" || res.OK != tt.wantOK {
+					t.Errorf("trace entry = %+v, want lookup_word ok=%v", res, tt.wantOK)
+				}
+			}
+			if out.Tools != nil {
+				t.Errorf("returned request still has tools")
+			}
+			// Setiap putaran menambah pesan assistant + hasil tool
+			if want := 1 + 2*tt.wantTrace; len(out.Messages) != want {
+				t.Errorf("messages = %d, want %d", len(out.Messages), want)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package srs
+
+import "time"
+
+const (
+	ResultLupa  = 0
+	ResultRagu  = 1
+	ResultIngat = 2
+)
+
+// Jeda review berikutnya berdasarkan hasil review terakhir
+var intervals = map[int]time.Duration{
+	ResultLupa:  4 * time.Hour,
+	ResultRagu:  24 * time.Hour,
+	ResultIngat: 72 * time.Hour,
+}
+
+func Interval(result int) time.Duration {
+	if d, ok := intervals[result]; ok {
+		return d
+	}
+	return intervals[ResultLupa]
+}
+
+func DueAt(result int, reviewedAt time.Time) time.Time {
+	return reviewedAt.Add(Interval(result))
+}
+
+// DueAtSQL: ekspresi Postgres yang sama dengan DueAt, untuk kolom result & reviewed_at
+const DueAtSQL = `(reviewed_at + CASE result
+	WHEN 0 THEN INTERVAL '4 hours'
+	WHEN 1 THEN INTERVAL '1 day'
+	ELSE INTERVAL '3 days' END)`
+
+// Status kata untuk user berdasarkan hasil review terakhir
+const (
+	StatusNew   = "new" // belum pernah direview
+	StatusLupa  = "lupa"
+	StatusRagu  = "ragu"
+	StatusIngat = "ingat"
+)
+
+var statusNames = map[int]string{ResultLupa: StatusLupa, ResultRagu: StatusRagu, ResultIngat: StatusIngat}
+
+// StatusOf: nil = belum pernah direview
+func StatusOf(lastResult *int) string {
+	if lastResult == nil {
+		return StatusNew
+	}
+	if s, ok := statusNames[*lastResult]; ok {
+		return s
+	}
+	return StatusLupa
+}
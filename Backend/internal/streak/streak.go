@@ -0,0 +1,193 @@
+package streak
+
+import (
+	"kotoba-backend/internal/env"
+	"os"
+	"time"
+)
+
+// Jenis target harian
+const (
+	GoalReviews = "reviews"
+	GoalMinutes = "minutes"
+)
+
+const (
+	DefaultGoalType   = GoalReviews
+	DefaultGoalTarget = 20
+
+	// Satu review paling lama dihitung 2 menit (kartu yang ditinggal tidak menambah menit belajar)
+	MaxReviewSeconds = 120
+	// Satu soal drill/ujian paling lama dihitung 30 detik
+	MaxAnswerSeconds = 30
+	// Menit belajar per hari dibatasi setinggi target menit maksimal (240 menit)
+	MaxDaySeconds = 240 * 60
+
+	dayLayout = "2006-01-02"
+)
+
+// Timezone default untuk user yang belum memilih (env USER_DEFAULT_TIMEZONE)
+func DefaultTimezone() string {
+	if v := os.Getenv("USER_DEFAULT_TIMEZONE"); v != "" {
+		if _, err := time.LoadLocation(v); err == nil {
+			return v
+		}
+	}
+	return "Asia/Jakarta"
+}
+
+// Location user; timezone kosong/tidak valid -> default
+func Location(tz string) *time.Location {
+	if tz != "" {
+		if loc, err := time.LoadLocation(tz); err == nil {
+			return loc
+		}
+	}
+	loc, err := time.LoadLocation(DefaultTimezone())
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func ValidTimezone(tz string) bool {
+	_, err := time.LoadLocation(tz)
+	return tz != "" && err == nil
+}
+
+// Hari belajar user ("2006-01-02") menurut timezone-nya
+func Day(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format(dayLayout)
+}
+
+// Awal hari belajar (00:00 di timezone user) yang memuat t
+func DayStart(t time.Time, loc *time.Location) time.Time {
+	start, _ := time.ParseInLocation(dayLayout, Day(t, loc), loc)
+	return start
+}
+
+// Selisih hari kalender (b - a). Hari kosong dianggap sangat jauh.
+func daysBetween(a, b string) int {
+	ta, errA := time.Parse(dayLayout, a)
+	tb, errB := time.Parse(dayLayout, b)
+	if errA != nil || errB != nil {
+		return 1 << 30
+	}
+	return int(tb.Sub(ta).Hours() / 24)
+}
+
+func ValidGoal(goalType string, target int) bool {
+	switch goalType {
+	case GoalReviews:
+		return target >= 1 && target <= 500
+	case GoalMinutes:
+		return target >= 1 && target <= 240
+	}
+	return false
+}
+
+// GoalMet: apakah aktivitas satu hari sudah memenuhi target
+func GoalMet(goalType string, target, reviews, seconds int) bool {
+	if goalType == GoalMinutes {
+		return seconds >= target*60
+	}
+	return reviews >= target
+}
+
+// CapSeconds: durasi dari client dibatasi sesuai jumlah soal yang dikerjakan
+func CapSeconds(seconds, items int) int {
+	if max := items * MaxAnswerSeconds; seconds > max {
+		seconds = max
+	}
+	if seconds < 0 {
+		return 0
+	}
+	return seconds
+}
+
+// Freeze didapat setiap STREAK_FREEZE_EVERY_DAYS hari beruntun (default 7), maksimal STREAK_MAX_FREEZES (default 2)
+func FreezeEvery() int { return env.Int("STREAK_FREEZE_EVERY_DAYS", 7) }
+func MaxFreezes() int  { return env.Int("STREAK_MAX_FREEZES", 2) }
+
+// State: streak yang tersimpan per user
+type State struct {
+	Current     int
+	Longest     int
+	LastGoalDay string
+	Freezes     int
+}
+
+// Update: hasil MeetGoal
+type Update struct {
+	Current      int  `json:"current"`
+	Extended     bool `json:"extended"`      // streak bertambah (bukan mulai dari 1)
+	FreezesUsed  int  `json:"freezes_used"`  // dipakai untuk menutup hari yang bolong
+	FreezeEarned bool `json:"freeze_earned"` // dapat freeze baru hari ini
+}
+
+// MeetGoal: target hari `day` tercapai. Hari bolong ditutup freeze kalau cukup.
+func (s *State) MeetGoal(day string) Update {
+	var u Update
+	// Hari yang sama atau lebih awal (mis. setelah pindah timezone) tidak mengubah streak
+	if s.LastGoalDay != "" && day <= s.LastGoalDay {
+		u.Current = s.Current
+		return u
+	}
+
+	missed := daysBetween(s.LastGoalDay, day) - 1
+	switch {
+	case s.Current > 0 && missed == 0:
+		s.Current++
+		u.Extended = true
+	case s.Current > 0 && missed > 0 && missed <= s.Freezes:
+		s.Freezes -= missed
+		u.FreezesUsed = missed
+		s.Current++
+		u.Extended = true
+	default:
+		s.Current = 1
+	}
+
+	if every := FreezeEvery(); every > 0 && s.Current%every == 0 && s.Freezes < MaxFreezes() {
+		s.Freezes++
+		u.FreezeEarned = true
+	}
+	if s.Current > s.Longest {
+		s.Longest = s.Current
+	}
+	s.LastGoalDay = day
+	u.Current = s.Current
+	return u
+}
+
+// Status streak untuk ditampilkan pada hari `today`
+type Status struct {
+	Current     int    `json:"current"`
+	Longest     int    `json:"longest"`
+	Freezes     int    `json:"freezes"`
+	MetToday    bool   `json:"met_today"`
+	AtRisk      bool   `json:"at_risk"`
+	LastGoalDay string `json:"last_goal_day,omitempty"`
+}
+
+// View: streak dianggap putus kalau hari bolong sebelum hari ini melebihi freeze.
+// AtRisk = streak masih hidup, target hari ini belum tercapai, dan tidak ada freeze tersisa untuk menutupnya.
+func (s State) View(today string) Status {
+	st := Status{Longest: s.Longest, Freezes: s.Freezes, LastGoalDay: s.LastGoalDay}
+	if s.Current == 0 {
+		return st
+	}
+	if s.LastGoalDay == today {
+		st.Current = s.Current
+		st.MetToday = true
+		return st
+	}
+
+	missed := daysBetween(s.LastGoalDay, today) - 1
+	if missed > s.Freezes {
+		return st
+	}
+	st.Current = s.Current
+	st.AtRisk = missed+1 > s.Freezes
+	return st
+}
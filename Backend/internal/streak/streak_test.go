@@ -0,0 +1,178 @@
+package streak
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMeetGoal(t *testing.T) {
+	t.Setenv("STREAK_FREEZE_EVERY_DAYS", "7")
+	t.Setenv("STREAK_MAX_FREEZES", "2")
+
+	tests := []struct {
+		name  string
+		state State
+		day   string
+		want  State
+		upd   Update
+	}{
+		{
+			name:  "first goal",
+			state: State{},
+			day:   "2026-03-10",
+			want:  State{Current: 1, Longest: 1, LastGoalDay: "2026-03-10"},
+			upd:   Update{Current: 1},
+		},
+		{
+			name:  "next day extends",
+			state: State{Current: 3, Longest: 5, LastGoalDay: "2026-03-09"},
+			day:   "2026-03-10",
+			want:  State{Current: 4, Longest: 5, LastGoalDay: "2026-03-10"},
+			upd:   Update{Current: 4, Extended: true},
+		},
+		{
+			name:  "same day is a no-op",
+			state: State{Current: 3, Longest: 3, LastGoalDay: "2026-03-10", Freezes: 1},
+			day:   "2026-03-10",
+			want:  State{Current: 3, Longest: 3, LastGoalDay: "2026-03-10", Freezes: 1},
+			upd:   Update{Current: 3},
+		},
+		{
+			name:  "earlier day is a no-op",
+			state: State{Current: 3, Longest: 3, LastGoalDay: "2026-03-10", Freezes: 1},
+			day:   "2026-03-09",
+			want:  State{Current: 3, Longest: 3, LastGoalDay: "2026-03-10", Freezes: 1},
+			upd:   Update{Current: 3},
+		},
+		{
+			name:  "gap covered by freezes",
+			state: State{Current: 4, Longest: 4, LastGoalDay: "2026-03-07", Freezes: 2},
+			day:   "2026-03-10",
+			want:  State{Current: 5, Longest: 5, LastGoalDay: "2026-03-10", Freezes: 0},
+			upd:   Update{Current: 5, Extended: true, FreezesUsed: 2},
+		},
+		{
+			name:  "gap larger than freezes resets",
+			state: State{Current: 4, Longest: 6, LastGoalDay: "2026-03-06", Freezes: 2},
+			day:   "2026-03-10",
+			want:  State{Current: 1, Longest: 6, LastGoalDay: "2026-03-10", Freezes: 2},
+			upd:   Update{Current: 1},
+		},
+		{
+			name:  "freeze earned every seventh day",
+			state: State{Current: 6, Longest: 6, LastGoalDay: "2026-03-09"},
+			day:   "2026-03-10",
+			want:  State{Current: 7, Longest: 7, LastGoalDay: "2026-03-10", Freezes: 1},
+			upd:   Update{Current: 7, Extended: true, FreezeEarned: true},
+		},
+		{
+			name:  "freezes capped",
+			state: State{Current: 13, Longest: 13, LastGoalDay: "2026-03-09", Freezes: 2},
+			day:   "2026-03-10",
+			want:  State{Current: 14, Longest: 14, LastGoalDay: "2026-03-10", Freezes: 2},
+			upd:   Update{Current: 14, Extended: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.state
+			upd := s.MeetGoal(tt.day)
+			if s != tt.want {
+				t.Errorf("state = %+v, want %+v", s, tt.want)
+			}
+			if upd != tt.upd {
+				t.Errorf("update = %+v, want %+v", upd, tt.upd)
+			}
+		})
+	}
+}
+
+func TestView(t *testing.T) {
+	tests := []struct {
+		name  string
+		state State
+		today string
+		want  Status
+	}{
+		{
+			name:  "no streak",
+			state: State{Longest: 4},
+			today: "2026-03-10",
+			want:  Status{Longest: 4},
+		},
+		{
+			name:  "met today",
+			state: State{Current: 3, Longest: 3, LastGoalDay: "2026-03-10"},
+			today: "2026-03-10",
+			want:  Status{Current: 3, Longest: 3, MetToday: true, LastGoalDay: "2026-03-10"},
+		},
+		{
+			name:  "met yesterday without freezes is at risk",
+			state: State{Current: 3, Longest: 3, LastGoalDay: "2026-03-09"},
+			today: "2026-03-10",
+			want:  Status{Current: 3, Longest: 3, AtRisk: true, LastGoalDay: "2026-03-09"},
+		},
+		{
+			name:  "met yesterday with a freeze is safe",
+			state: State{Current: 3, Longest: 3, LastGoalDay: "2026-03-09", Freezes: 1},
+			today: "2026-03-10",
+			want:  Status{Current: 3, Longest: 3, Freezes: 1, LastGoalDay: "2026-03-09"},
+		},
+		{
+			name:  "missed day covered by freeze",
+			state: State{Current: 3, Longest: 3, LastGoalDay: "2026-03-08", Freezes: 1},
+			today: "2026-03-10",
+			want:  Status{Current: 3, Longest: 3, Freezes: 1, AtRisk: true, LastGoalDay: "2026-03-08"},
+		},
+		{
+			name:  "broken streak",
+			state: State{Current: 3, Longest: 5, LastGoalDay: "2026-03-07", Freezes: 1},
+			today: "2026-03-10",
+			want:  Status{Longest: 5, Freezes: 1, LastGoalDay: "2026-03-07"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.state.View(tt.today); got != tt.want {
+				t.Errorf("View(%s) = %+v, want %+v", tt.today, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCapSeconds(t *testing.T) {
+	tests := []struct {
+		seconds, items, want int
+	}{
+		{seconds: 120, items: 10, want: 120},
+		{seconds: 3600, items: 10, want: 10 * MaxAnswerSeconds},
+		{seconds: -5, items: 10, want: 0},
+		{seconds: 60, items: 0, want: 0},
+	}
+	for _, tt := range tests {
+		if got := CapSeconds(tt.seconds, tt.items); got != tt.want {
+			t.Errorf("CapSeconds(%d, %d) = %d, want %d", tt.seconds, tt.items, got, tt.want)
+		}
+	}
+}
+
+func TestDayStart(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*3600)
+	tests := []struct {
+		at   time.Time
+		loc  *time.Location
+		want time.Time
+	}{
+		{at: time.Date(2026, 3, 10, 20, 0, 0, 0, time.UTC), loc: time.UTC, want: time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)},
+		// 20:00 UTC sudah hari berikutnya di WIB
+		{at: time.Date(2026, 3, 10, 20, 0, 0, 0, time.UTC), loc: jakarta, want: time.Date(2026, 3, 11, 0, 0, 0, 0, jakarta)},
+		{at: time.Date(2026, 3, 10, 16, 59, 0, 0, time.UTC), loc: jakarta, want: time.Date(2026, 3, 10, 0, 0, 0, 0, jakarta)},
+	}
+	for _, tt := range tests {
+		if got := DayStart(tt.at, tt.loc); !got.Equal(tt.want) {
+			t.Errorf("DayStart(%v, %s) = %v, want %v", tt.at, tt.loc, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package tagging
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Kategori tag
+const (
+	CategoryTopic     = "topic"      // people, food, time, ...
+	CategoryWordClass = "word_class" // noun, verb, adjective, adverb
+)
+
+// Maksimal tag per filter (?tag=food,verb)
+const MaxFilterTags = 10
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+type Tag struct {
+	Slug     string `json:"slug"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+}
+
+// Kata dari satu bagian init.sql, dicocokkan ke vocabularies lewat kanji + kana + romaji
+type Word struct {
+	Kanji   string   `json:"kanji"`
+	Kana    string   `json:"kana"`
+	Romaji  string   `json:"romaji"`
+	Section string   `json:"section"`
+	Tags    []string `json:"tags"`
+}
+
+// Seed: isi seeds/vocab_tags.json (dibuat dari komentar bagian di data/init.sql)
+type Seed struct {
+	Tags  []Tag  `json:"tags"`
+	Words []Word `json:"words"`
+}
+
+// File seed: VOCAB_TAGS_FILE (default seeds/vocab_tags.json)
+func Path() string {
+	if v := os.Getenv("VOCAB_TAGS_FILE"); v != "" {
+		return v
+	}
+	return "seeds/vocab_tags.json"
+}
+
+func ValidSlug(slug string) bool {
+	return slugPattern.MatchString(slug)
+}
+
+func ValidCategory(category string) bool {
+	return category == CategoryTopic || category == CategoryWordClass
+}
+
+func Load(file string) (*Seed, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var seed Seed
+	if err := json.Unmarshal(b, &seed); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", file, err)
+	}
+
+	known := map[string]bool{}
+	for _, t := range seed.Tags {
+		if !ValidSlug(t.Slug) || known[t.Slug] || !ValidCategory(t.Category) {
+			return nil, fmt.Errorf("tag %q: invalid or duplicate slug, or unknown category %q", t.Slug, t.Category)
+		}
+		known[t.Slug] = true
+	}
+	for _, w := range seed.Words {
+		for _, slug := range w.Tags {
+			if !known[slug] {
+				return nil, fmt.Errorf("word %s (%s): unknown tag %q", w.Kana, w.Section, slug)
+			}
+		}
+	}
+	return &seed, nil
+}
+
+// ParseFilter: "food, Verb" -> [food verb]. Slug tidak valid atau terlalu banyak = error.
+func ParseFilter(raw string) ([]string, error) {
+	var out []string
+	seen := map[string]bool{}
+	for _, part := range strings.Split(raw, ",") {
+		slug := strings.ToLower(strings.TrimSpace(part))
+		if slug == "" || seen[slug] {
+			continue
+		}
+		if !ValidSlug(slug) {
+			return nil, fmt.Errorf("invalid tag %q", part)
+		}
+		seen[slug] = true
+		out = append(out, slug)
+	}
+	if len(out) > MaxFilterTags {
+		return nil, fmt.Errorf("at most %d tags", MaxFilterTags)
+	}
+	return out, nil
+}
+
+const filterSubquery = `SELECT vt.vocab_id FROM vocabulary_tags vt JOIN tags t ON t.id = vt.tag_id WHERE t.slug IN `
+
+// FilterSQL: kondisi "kata punya salah satu tag ini" atas kolom id vocabulary (mis. "v.id").
+// Argumennya: slugs.
+func FilterSQL(column string) string {
+	return column + ` IN (` + filterSubquery + `?)`
+}
+
+// FilterSQLNamed: sama seperti FilterSQL, untuk query dengan argumen bernama (@param)
+func FilterSQLNamed(column, param string) string {
+	return column + ` IN (` + filterSubquery + `@` + param + `)`
+}
@@ -195,6 +195,9 @@ const FlashcardGame = ({ onBack }: { onBack: () => void }) => {
     
     const queryClient = useQueryClient();
     const audioRef = useRef(new Audio('/sounds/flip.mp3'));
+    const cardShownAt = useRef(Date.now());
+
+    useEffect(() => { cardShownAt.current = Date.now(); }, [currentIndex, view]);
 
     const startSession = async () => {
         setLoading(true);
@@ -217,7 +220,7 @@ const FlashcardGame = ({ onBack }: { onBack: () => void }) => {
     };
 
     const reviewMutation = useMutation({
-        mutationFn: (data: { vocab_id: number; result: number }) => api.post('/api/review', data),
+        mutationFn: (data: { vocab_id: number; result: number; duration_seconds: number }) => api.post('/api/review', data),
         onSuccess: () => {
             queryClient.invalidateQueries({ queryKey: ['dashboardStats'] });
         }
@@ -225,7 +228,8 @@ const FlashcardGame = ({ onBack }: { onBack: () => void }) => {
 
     const handleReview = (result: number) => {
         const currentCard = cards[currentIndex];
-        reviewMutation.mutate({ vocab_id: currentCard.id, result });
+        const durationSeconds = Math.round((Date.now() - cardShownAt.current) / 1000);
+        reviewMutation.mutate({ vocab_id: currentCard.id, result, duration_seconds: durationSeconds });
 
         if (currentIndex < cards.length - 1) {
             setIsFlipped(false);
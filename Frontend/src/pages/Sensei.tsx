@@ -114,6 +114,7 @@ const Sensei = () => {
         }
     ]);
     const [isTyping, setIsTyping] = useState(false);
+    const [conversationId, setConversationId] = useState<number | null>(null);
     const scrollRef = useRef<HTMLDivElement>(null);
     const inputRef = useRef<HTMLTextAreaElement>(null);
 
@@ -142,13 +143,11 @@ const Sensei = () => {
         setIsTyping(true);
 
         try {
-            const history = messages.slice(-8).map(m => ({
-                role: m.sender === 'user' ? 'user' : 'assistant',
-                content: m.text
-            }));
+            // Riwayat disimpan & dibangun ulang di server
+            const res = await api.post('/api/chat', { message: userText, conversation_id: conversationId ?? undefined });
+            // Pesan yang ditolak guard tidak membuat percakapan baru
+            if (res.data.conversation_id) setConversationId(res.data.conversation_id);
 
-            const res = await api.post('/api/chat', { message: userText, history });
-            
             setMessages(prev => [...prev, {
                 id: Date.now() + 1,
                 sender: 'sensei',
@@ -156,9 +155,12 @@ const Sensei = () => {
                 timestamp: new Date()
             }]);
 
-        } catch (error) {
+        } catch (error: any) {
             console.error("Chat Error", error);
-            setMessages(prev => [...prev, { id: Date.now()+2, sender: 'sensei', text: "Maaf, koneksi terputus. Coba lagi nanti.", timestamp: new Date() }]);
+            // Budget habis / pesan terlalu panjang: tampilkan pesan dari server kalau ada
+            const data = error?.response?.data;
+            const text = data?.reply || (data?.max_chars ? `Pesanmu terlalu panjang, Ronin. Maksimal ${data.max_chars} karakter.` : "Maaf, koneksi terputus. Coba lagi nanti.");
+            setMessages(prev => [...prev, { id: Date.now()+2, sender: 'sensei', text, timestamp: new Date() }]);
         } finally {
             setIsTyping(false);
             setTimeout(() => inputRef.current?.focus(), 100);
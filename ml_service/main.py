@@ -1,7 +1,9 @@
 from fastapi import FastAPI, HTTPException
+from fastapi.responses import JSONResponse, StreamingResponse
 from pydantic import BaseModel
 from typing import List, Optional
 import numpy as np
+import json
 import os
 import requests
 from dotenv import load_dotenv
@@ -13,6 +15,7 @@ app = FastAPI()
 
 # --- CONFIG---
 OPENROUTER_API_KEY = os.getenv("OPENROUTER_API_KEY")
+OPENROUTER_URL = "https://openrouter.ai/api/v1/chat/completions"
 AI_MODEL = "xiaomi/mimo-v2-flash:free"
 
 # --- DATA MODELS ---
@@ -23,6 +26,13 @@ class UserStats(BaseModel):
     ragu_count: int
     lupa_count: int
 
+class BatchItem(BaseModel):
+    user_id: int
+    stats: UserStats
+
+class BatchRequest(BaseModel):
+    items: List[BatchItem]
+
 class ChatMessage(BaseModel):
     role: str 
     content: str
@@ -30,6 +40,14 @@ class ChatMessage(BaseModel):
 class ChatRequest(BaseModel):
     message: str
     history: List[ChatMessage] = []
+    # Opsional, dikirim oleh backend Go (sensei.PythonProvider)
+    system_prompt: Optional[str] = None
+    model: Optional[str] = None
+    temperature: Optional[float] = None
+    max_tokens: Optional[int] = None
+    # Mode tool calling: messages utuh (format OpenAI) + definisi tools
+    messages: Optional[List[dict]] = None
+    tools: Optional[List[dict]] = None
 
 # --- SYSTEM PROMPT ---
 SYSTEM_PROMPT = """
@@ -111,36 +129,64 @@ def predict(stats: UserStats):
         "graph_data": graph_data 
     }
 
-@app.post("/chat")
-def chat(req: ChatRequest):
-    # Cek API Key
-    if not OPENROUTER_API_KEY:
-        print("ERROR: API Key OpenRouter belum diset di .env")
-        return {"reply": "Maaf Ronin, merpati pos (API Key) belum sampai. Mohon periksa konfigurasi .env mu."}
-    
-    messages = [{"role": "system", "content": SYSTEM_PROMPT}]
+@app.post("/predict_retention/batch")
+def predict_batch(req: BatchRequest):
+    # Dipanggil job batch di backend Go (jobs.PredictRetention)
+    return {"results": [{"user_id": item.user_id, **predict(item.stats)} for item in req.items]}
+
+def build_messages(req: ChatRequest):
+    if req.messages:
+        return req.messages
+
+    messages = [{"role": "system", "content": req.system_prompt or SYSTEM_PROMPT}]
     
     for msg in req.history[-6:]:
         role = "assistant" if msg.role == "assistant" or msg.role == "sensei" else "user"
         messages.append({"role": role, "content": msg.content})
     
     messages.append({"role": "user", "content": req.message})
+    return messages
+
+def openrouter_headers():
+    return {
+        "Authorization": f"Bearer {OPENROUTER_API_KEY}",
+        "Content-Type": "application/json",
+        "HTTP-Referer": "http://localhost:3000", # optional ini mah
+        "X-Title": "Kaiwa Rift",
+    }
+
+def openrouter_payload(req: ChatRequest, stream: bool = False):
+    payload = {
+        "model": req.model or AI_MODEL,
+        "messages": build_messages(req),
+        "temperature": req.temperature if req.temperature is not None else 0.8, 
+        "max_tokens": req.max_tokens or 200,  # Batasi panjang jawaban agar tidak boros kasian ai nya
+        "stream": stream,
+    }
+    if stream:
+        # Chunk terakhir membawa usage asli untuk budget di backend Go
+        payload["stream_options"] = {"include_usage": True}
+    if req.tools:
+        payload["tools"] = req.tools
+    return payload
+
+# Kegagalan dikirim sebagai status non-2xx + field error, supaya backend Go bisa failover
+# ke provider lain dan tidak menyimpan pesan maaf sebagai balasan Sensei
+def chat_error(status: int, message: str):
+    return JSONResponse(status_code=status, content={"error": message})
+
+@app.post("/chat")
+def chat(req: ChatRequest):
+    # Cek API Key
+    if not OPENROUTER_API_KEY:
+        print("ERROR: API Key OpenRouter belum diset di .env")
+        return chat_error(503, "api key missing")
 
     try:
         response = requests.post(
-            url="https://openrouter.ai/api/v1/chat/completions",
-            headers={
-                "Authorization": f"Bearer {OPENROUTER_API_KEY}",
-                "Content-Type": "application/json",
-                "HTTP-Referer": "http://localhost:3000", # optional ini mah
-                "X-Title": "Kaiwa Rift",
-            },
-            json={
-                "model": AI_MODEL,
-                "messages": messages,
-                "temperature": 0.8, 
-                "max_tokens": 200,  # Batasi panjang jawaban agar tidak boros kasian ai nya
-            },
+            url=OPENROUTER_URL,
+            headers=openrouter_headers(),
+            json=openrouter_payload(req),
             timeout=20 
         )
         
@@ -148,11 +194,61 @@ def chat(req: ChatRequest):
         result = response.json()
         
         if 'choices' in result and len(result['choices']) > 0:
-            ai_reply = result['choices'][0]['message']['content']
-            return {"reply": ai_reply}
+            message = result['choices'][0]['message']
+            # Tool call: backend Go yang mengeksekusi, lalu memanggil /chat lagi
+            usage = result.get('usage')
+            if message.get('tool_calls'):
+                return {"reply": message.get('content') or "", "tool_calls": message['tool_calls'], "usage": usage}
+            return {"reply": message['content'], "usage": usage}
         else:
-            return {"reply": "Mmm... Angin sedang ribut, aku tidak bisa mendengar pikiranmu."}
+            return chat_error(502, "empty choices")
 
     except Exception as e:
         print(f"Error calling OpenRouter: {e}")
-        return {"reply": "Maaf Ronin, meditasiku terganggu (Connection Error). Cobalah sesaat lagi."}
\ No newline at end of file
+        return chat_error(502, "connection error")
+
+def sse(data):
+    return f"data: {json.dumps(data)}\n\n"
+
+@app.post("/chat/stream")
+def chat_stream(req: ChatRequest):
+    # Relay token dari OpenRouter sebagai SSE: data: {"delta": "..."} / {"tool_calls": [...]} ... data: [DONE]
+    def event_stream():
+        if not OPENROUTER_API_KEY:
+            print("ERROR: API Key OpenRouter belum diset di .env")
+            yield sse({"error": "api key missing"})
+            return
+
+        try:
+            with requests.post(
+                url=OPENROUTER_URL,
+                headers=openrouter_headers(),
+                json=openrouter_payload(req, stream=True),
+                stream=True,
+                timeout=20,
+            ) as response:
+                response.raise_for_status()
+                for line in response.iter_lines(decode_unicode=True):
+                    if not line or not line.startswith("data:"):
+                        continue
+                    data = line[len("data:"):].strip()
+                    if data == "[DONE]":
+                        break
+                    chunk = json.loads(data)
+                    if chunk.get("usage"):
+                        yield sse({"usage": chunk["usage"]})
+                    choices = chunk.get("choices") or [{}]
+                    delta = choices[0].get("delta", {})
+                    # Tool call ikut di-relay per potongan; backend Go yang menyusun & mengeksekusi
+                    if delta.get("tool_calls"):
+                        yield sse({"tool_calls": delta["tool_calls"]})
+                    if delta.get("content"):
+                        yield sse({"delta": delta["content"]})
+        except Exception as e:
+            print(f"Error streaming OpenRouter: {e}")
+            yield sse({"error": "connection error"})
+            return
+
+        yield "data: [DONE]\n\n"
+
+    return StreamingResponse(event_stream(), media_type="text/event-stream")